@@ -36,6 +36,8 @@ type App struct {
 	sessionService    *service.SessionService
 	sftpService       *service.SFTPService
 	monitorService    *service.MonitorService
+	alertService      *service.AlertService
+	agentService      *service.AgentService
 	settingsService   *service.SettingsService
 	devToolsService   *service.DevToolsService
 	databaseService   *service.DatabaseService
@@ -71,9 +73,80 @@ func (a *App) startup(ctx context.Context) {
 	a.sessionService = service.NewSessionService(sessionManager)
 	a.sftpService = service.NewSFTPService(sessionManager, transferManager)
 	a.monitorService = service.NewMonitorService(sessionManager)
+	a.alertService = service.NewAlertService(configManager)
+	a.agentService = service.NewAgentService(a.connectionService, configManager, a.monitorService)
 	a.settingsService = service.NewSettingsService(configManager)
 	a.devToolsService = service.NewDevToolsService()
 	a.databaseService = service.NewDatabaseService(a.configManager)
+
+	sessionManager.SetZmodemHandler(transferManager, a.zmodemHandler())
+	sessionManager.SetTrzszHandler(transferManager, a.trzszHandler())
+	a.alertService.SetHandler(func(event service.AlertEvent) {
+		runtime.EventsEmit(a.ctx, "monitor:alert", event)
+	})
+	a.monitorService.SetAlertService(a.alertService)
+	a.agentService.SetStatusHandler(func(host service.MonitoredHost) {
+		runtime.EventsEmit(a.ctx, "monitor:agent", host)
+	})
+	a.applySettings()
+	a.agentService.Start()
+}
+
+// shutdown is called when the app is closing, to save what is still buffered
+func (a *App) shutdown(ctx context.Context) {
+	if a.monitorService == nil {
+		return
+	}
+	a.agentService.Stop()
+	if err := a.monitorService.FlushHistory(); err != nil {
+		fmt.Printf("Failed to write metrics history: %v\n", err)
+	}
+}
+
+// zmodemHandler answers rz/sz in terminals with native file dialogs
+func (a *App) zmodemHandler() *ssh.ZmodemHandler {
+	return &ssh.ZmodemHandler{
+		SaveFile: func(sessionID, filename string, size int64) (string, error) {
+			return runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+				Title:           "保存文件",
+				DefaultFilename: filename,
+			})
+		},
+		SelectFiles: func(sessionID string) ([]string, error) {
+			return runtime.OpenMultipleFilesDialog(a.ctx, runtime.OpenDialogOptions{
+				Title: "选择要上传的文件",
+			})
+		},
+		Progress: func(progress ssh.TransferProgress) {
+			runtime.EventsEmit(a.ctx, "sftp:progress:"+progress.TransferID, progress)
+			runtime.EventsEmit(a.ctx, "zmodem:progress:"+progress.SessionID, progress)
+		},
+	}
+}
+
+// trzszHandler answers trz/tsz in terminals with native file dialogs
+func (a *App) trzszHandler() *ssh.TrzszHandler {
+	return &ssh.TrzszHandler{
+		SelectFiles: func(sessionID string) ([]string, error) {
+			return runtime.OpenMultipleFilesDialog(a.ctx, runtime.OpenDialogOptions{
+				Title: "选择要上传的文件",
+			})
+		},
+		SelectDirectory: func(sessionID string) (string, error) {
+			return runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+				Title: "选择要上传的目录",
+			})
+		},
+		SaveDirectory: func(sessionID string) (string, error) {
+			return runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+				Title: "选择下载目录",
+			})
+		},
+		Progress: func(progress ssh.TransferProgress) {
+			runtime.EventsEmit(a.ctx, "sftp:progress:"+progress.TransferID, progress)
+			runtime.EventsEmit(a.ctx, "trzsz:progress:"+progress.SessionID, progress)
+		},
+	}
 }
 
 // Greet returns a greeting for the given name
@@ -183,6 +256,7 @@ func (a *App) CloseSSH(sessionID string) error {
 	if err != nil {
 		return err
 	}
+	a.monitorService.ForgetSession(sessionID)
 	fmt.Printf("SSH session closed: %s\n", sessionID)
 	return nil
 }
@@ -336,7 +410,23 @@ func (a *App) GetSettings() config.AppSettings {
 
 // UpdateSettings updates application settings
 func (a *App) UpdateSettings(updates map[string]interface{}) error {
-	return a.settingsService.UpdateSettings(updates)
+	if err := a.settingsService.UpdateSettings(updates); err != nil {
+		return err
+	}
+	a.applySettings()
+	return nil
+}
+
+// applySettings passes the saved settings to the services that use them, also
+// updating running transfers
+func (a *App) applySettings() {
+	settings := a.settingsService.GetSettings()
+	a.sftpService.SetGlobalRateLimit(int64(settings.TransferRateLimit) * 1024)
+	a.sftpService.SetTransferChunks(settings.TransferChunks)
+	a.sftpService.SetTrash(settings.FileManagerTrash, time.Duration(settings.FileManagerTrashMaxAge)*24*time.Hour)
+	a.monitorService.SetHistoryPersistence(settings.MonitorHistoryPersist, time.Duration(settings.MonitorHistoryRetention)*24*time.Hour)
+	a.alertService.SetHooks(settings.AlertWebhookURL, settings.AlertCommand)
+	a.agentService.SetInterval(time.Duration(settings.MonitorAgentInterval) * time.Second)
 }
 
 // GetMonitoringData retrieves monitoring data for a session
@@ -344,11 +434,120 @@ func (a *App) GetMonitoringData(sessionID string) (*ssh.MonitoringData, error) {
 	return a.monitorService.GetMonitoringData(sessionID)
 }
 
+// QueryMonitoringHistory returns a session's metrics between from and to (Unix seconds),
+// averaged into points step seconds apart; zero values pick the last hour at chart resolution
+func (a *App) QueryMonitoringHistory(sessionID string, from, to, step int64) (*ssh.MetricsRange, error) {
+	return a.monitorService.QueryHistory(sessionID, from, to, step)
+}
+
+// GetAlertRules returns the alert rules evaluated against monitoring data
+func (a *App) GetAlertRules() []config.AlertRule {
+	return a.alertService.GetRules()
+}
+
+// AddAlertRule saves a new alert rule and returns it with its ID
+func (a *App) AddAlertRule(rule config.AlertRule) (config.AlertRule, error) {
+	return a.alertService.AddRule(rule)
+}
+
+// UpdateAlertRule saves changes to an alert rule
+func (a *App) UpdateAlertRule(rule config.AlertRule) error {
+	return a.alertService.UpdateRule(rule)
+}
+
+// DeleteAlertRule deletes an alert rule
+func (a *App) DeleteAlertRule(id string) error {
+	return a.alertService.DeleteRule(id)
+}
+
+// GetActiveAlerts returns the alerts currently firing
+// Firing and resolved events are emitted as "monitor:alert"
+func (a *App) GetActiveAlerts() []service.AlertEvent {
+	return a.alertService.ActiveAlerts()
+}
+
+// GetMonitoredHosts returns the hosts monitored in the background, for a dashboard.
+// Status changes are emitted as "monitor:agent"; a host's SessionID queries its history.
+func (a *App) GetMonitoredHosts() []service.MonitoredHost {
+	return a.agentService.GetHosts()
+}
+
+// AddMonitoredHost monitors a saved connection in the background, without a terminal
+func (a *App) AddMonitoredHost(connectionID string) error {
+	return a.agentService.AddHost(connectionID)
+}
+
+// RemoveMonitoredHost stops monitoring a saved connection in the background
+func (a *App) RemoveMonitoredHost(connectionID string) error {
+	return a.agentService.RemoveHost(connectionID)
+}
+
+// ScanDiskUsage starts a disk usage scan of a remote path
+// Progress and the final size tree are emitted as "monitor:du:<scanID>" events
+func (a *App) ScanDiskUsage(sessionID string, opts ssh.DiskUsageOptions) (string, error) {
+	return a.monitorService.ScanDiskUsage(sessionID, opts, a.emitDiskUsage)
+}
+
+// ScanPartition starts a disk usage scan of a partition's mount point
+func (a *App) ScanPartition(sessionID string, partition ssh.PartitionInfo, topN int) (string, error) {
+	return a.monitorService.ScanPartition(sessionID, partition, topN, a.emitDiskUsage)
+}
+
+// CancelDiskUsageScan stops a running disk usage scan
+func (a *App) CancelDiskUsageScan(scanID string) error {
+	return a.monitorService.CancelDiskUsageScan(scanID)
+}
+
+// ListProcesses returns the top processes of a session's host, sorted by "cpu" or "memory"
+func (a *App) ListProcesses(sessionID string, opts ssh.ProcessListOptions) (*ssh.ProcessList, error) {
+	return a.monitorService.ListProcesses(sessionID, opts)
+}
+
+// SignalProcess sends a signal such as "TERM" or "KILL" to a remote process
+func (a *App) SignalProcess(sessionID string, pid int, signal string, sudo ssh.SudoOptions) error {
+	return a.monitorService.SignalProcess(sessionID, pid, signal, sudo)
+}
+
+// ReniceProcess changes the nice value of a remote process
+func (a *App) ReniceProcess(sessionID string, pid, nice int, sudo ssh.SudoOptions) error {
+	return a.monitorService.ReniceProcess(sessionID, pid, nice, sudo)
+}
+
+// ListSockets returns the TCP and UDP sockets of a remote host with their owning processes
+func (a *App) ListSockets(sessionID string, opts ssh.SocketListOptions, sudo ssh.SudoOptions) (*ssh.SocketList, error) {
+	return a.monitorService.ListSockets(sessionID, opts, sudo)
+}
+
+// StartPortForward forwards a local port to a port reachable from a session's server,
+// such as one of its listening sockets
+func (a *App) StartPortForward(sessionID string, opts ssh.PortForwardOptions) (*ssh.PortForward, error) {
+	return a.sessionService.StartPortForward(sessionID, opts)
+}
+
+// StopPortForward stops a port forward
+func (a *App) StopPortForward(forwardID string) error {
+	return a.sessionService.StopPortForward("", forwardID)
+}
+
+// ListPortForwards returns the port forwards of a session, or of all sessions if sessionID is empty
+func (a *App) ListPortForwards(sessionID string) []ssh.PortForward {
+	return a.sessionService.ListPortForwards(sessionID)
+}
+
+func (a *App) emitDiskUsage(progress ssh.DiskUsageProgress) {
+	runtime.EventsEmit(a.ctx, "monitor:du:"+progress.ScanID, progress)
+}
+
 // ListFiles lists files in a directory
 func (a *App) ListFiles(sessionID string, path string) ([]ssh.FileInfo, error) {
 	return a.sftpService.ListFiles(sessionID, path)
 }
 
+// ListFilesPage lists one sorted page of a directory
+func (a *App) ListFilesPage(sessionID string, path string, opts ssh.ListOptions) (*ssh.DirectoryPage, error) {
+	return a.sftpService.ListFilesPage(sessionID, path, opts)
+}
+
 // ChangeDirectory changes the current working directory for a session
 func (a *App) ChangeDirectory(sessionID string, path string) error {
 	return a.sftpService.ChangeDirectory(sessionID, path)
@@ -403,16 +602,107 @@ func (a *App) DownloadFiles(sessionID string, remotePaths []string, localPath st
 	})
 }
 
+// RelayFiles copies files or directories from one session's server to another's
+func (a *App) RelayFiles(sourceSessionID string, sourcePaths []string, targetSessionID string, targetPath string, opts ssh.RelayOptions) ([]string, error) {
+	return a.sftpService.RelayFiles(sourceSessionID, sourcePaths, targetSessionID, targetPath, opts, func(progress ssh.TransferProgress) {
+		// Emit event to frontend
+		runtime.EventsEmit(a.ctx, "sftp:progress:"+progress.TransferID, progress)
+	})
+}
+
+// CopyFiles copies files or directories between any two filesystems: sessions, FTP connections or "local"
+func (a *App) CopyFiles(sourceID string, sourcePaths []string, targetID string, targetPath string) ([]string, error) {
+	return a.sftpService.CopyFiles(sourceID, sourcePaths, targetID, targetPath, func(progress ssh.TransferProgress) {
+		// Emit event to frontend
+		runtime.EventsEmit(a.ctx, "sftp:progress:"+progress.TransferID, progress)
+	})
+}
+
+// ConnectFTP connects to an FTP or FTPS server and returns its connection ID
+func (a *App) ConnectFTP(cfg ssh.FTPConfig) (string, error) {
+	return a.sftpService.ConnectFTP(cfg)
+}
+
+// DisconnectFTP closes an FTP connection
+func (a *App) DisconnectFTP(connectionID string) error {
+	return a.sftpService.DisconnectFTP(connectionID)
+}
+
+// CompressFiles packs remote files into a tar.gz or zip archive on the server
+func (a *App) CompressFiles(sessionID string, remotePaths []string, archivePath string, format string) (string, error) {
+	return a.sftpService.CompressFiles(sessionID, remotePaths, archivePath, format, func(progress ssh.TransferProgress) {
+		// Emit event to frontend
+		runtime.EventsEmit(a.ctx, "sftp:progress:"+progress.TransferID, progress)
+	})
+}
+
+// ExtractArchive extracts a remote archive on the server
+func (a *App) ExtractArchive(sessionID string, archivePath string, destDir string) (string, error) {
+	return a.sftpService.ExtractArchive(sessionID, archivePath, destDir, func(progress ssh.TransferProgress) {
+		// Emit event to frontend
+		runtime.EventsEmit(a.ctx, "sftp:progress:"+progress.TransferID, progress)
+	})
+}
+
+// DownloadDirectoryArchive downloads a remote directory as a single tar.gz archive
+func (a *App) DownloadDirectoryArchive(sessionID string, remoteDir string, localPath string) (string, error) {
+	return a.sftpService.DownloadDirectoryArchive(sessionID, remoteDir, localPath, func(progress ssh.TransferProgress) {
+		// Emit event to frontend
+		runtime.EventsEmit(a.ctx, "sftp:progress:"+progress.TransferID, progress)
+	})
+}
+
+// DownloadFilesBulk downloads many files or directories as one tar-streamed transfer
+func (a *App) DownloadFilesBulk(sessionID string, remotePaths []string, localPath string) (string, error) {
+	return a.sftpService.DownloadFilesBulk(sessionID, remotePaths, localPath, func(progress ssh.TransferProgress) {
+		// Emit event to frontend
+		runtime.EventsEmit(a.ctx, "sftp:progress:"+progress.TransferID, progress)
+	})
+}
+
+// UploadFilesBulk uploads many files or directories as one tar-streamed transfer
+func (a *App) UploadFilesBulk(sessionID string, localPaths []string, remotePath string) (string, error) {
+	return a.sftpService.UploadFilesBulk(sessionID, localPaths, remotePath, func(progress ssh.TransferProgress) {
+		// Emit event to frontend
+		runtime.EventsEmit(a.ctx, "sftp:progress:"+progress.TransferID, progress)
+	})
+}
+
 // DeleteFile deletes a single file or directory
 func (a *App) DeleteFile(sessionID string, path string) error {
 	return a.sftpService.DeleteFile(sessionID, path)
 }
 
-// DeleteFiles deletes multiple files or directories
+// DeleteFiles deletes multiple files or directories, moving them to the trash when trash mode is on
 func (a *App) DeleteFiles(sessionID string, paths []string) error {
 	return a.sftpService.DeleteFiles(sessionID, paths)
 }
 
+// DeleteFilesPermanently deletes files or directories without using the trash
+func (a *App) DeleteFilesPermanently(sessionID string, paths []string) error {
+	return a.sftpService.DeleteFilesPermanently(sessionID, paths)
+}
+
+// ListTrash lists the items in the server's trash
+func (a *App) ListTrash(sessionID string) ([]ssh.TrashItem, error) {
+	return a.sftpService.ListTrash(sessionID)
+}
+
+// RestoreFromTrash moves trashed items back to their original paths
+func (a *App) RestoreFromTrash(sessionID string, names []string) ([]string, error) {
+	return a.sftpService.RestoreFromTrash(sessionID, names)
+}
+
+// PurgeTrash permanently deletes items from the server's trash
+func (a *App) PurgeTrash(sessionID string, names []string) error {
+	return a.sftpService.PurgeTrash(sessionID, names)
+}
+
+// EmptyTrash permanently deletes everything in the server's trash
+func (a *App) EmptyTrash(sessionID string) error {
+	return a.sftpService.EmptyTrash(sessionID)
+}
+
 // RenameFile renames a file or directory
 func (a *App) RenameFile(sessionID string, oldPath string, newPath string) error {
 	return a.sftpService.RenameFile(sessionID, oldPath, newPath)
@@ -433,11 +723,60 @@ func (a *App) SearchDirectories(sessionID string, searchPath string, query strin
 	return a.sftpService.SearchDirectories(sessionID, searchPath, query, maxDepth, maxResults)
 }
 
+// SearchFiles starts a search for files by name, size, modification time and content
+// Result pages are emitted as "sftp:search:<searchID>" events
+func (a *App) SearchFiles(sessionID string, query ssh.FileSearchQuery) (string, error) {
+	return a.sftpService.SearchFiles(sessionID, query, func(page ssh.FileSearchPage) {
+		runtime.EventsEmit(a.ctx, "sftp:search:"+page.SearchID, page)
+	})
+}
+
+// StartTail follows a remote file
+// New lines are emitted as "sftp:tail:<tailID>" events
+func (a *App) StartTail(sessionID string, opts ssh.TailOptions) (string, error) {
+	return a.sftpService.StartTail(sessionID, opts, func(event ssh.TailEvent) {
+		runtime.EventsEmit(a.ctx, "sftp:tail:"+event.TailID, event)
+	})
+}
+
+// StopTail stops following a remote file
+func (a *App) StopTail(tailID string) error {
+	return a.sftpService.StopTail(tailID)
+}
+
+// ListTails returns the files currently followed in a session
+func (a *App) ListTails(sessionID string) []service.TailInfo {
+	return a.sftpService.ListTails(sessionID)
+}
+
+// WatchDirectory watches a remote directory for changes
+// Debounced changes are emitted as "sftp:watch:<watchID>" events
+func (a *App) WatchDirectory(sessionID string, opts ssh.WatchOptions) (string, error) {
+	return a.sftpService.WatchDirectory(sessionID, opts, func(event ssh.DirWatchEvent) {
+		runtime.EventsEmit(a.ctx, "sftp:watch:"+event.WatchID, event)
+	})
+}
+
+// StopWatch stops watching a remote directory
+func (a *App) StopWatch(watchID string) error {
+	return a.sftpService.StopWatch(watchID)
+}
+
+// CancelFileSearch stops a running file search
+func (a *App) CancelFileSearch(searchID string) error {
+	return a.sftpService.CancelSearch(searchID)
+}
+
 // CancelTransfer cancels a file transfer
 func (a *App) CancelTransfer(transferID string) error {
 	return a.sftpService.CancelTransfer(transferID)
 }
 
+// SetTransferRateLimit limits a single transfer to kbps KB/s, also while it is running; 0 removes the limit
+func (a *App) SetTransferRateLimit(transferID string, kbps int) error {
+	return a.sftpService.SetTransferRateLimit(transferID, int64(kbps)*1024)
+}
+
 // GetTransferStatus gets the status of a transfer
 func (a *App) GetTransferStatus(transferID string) (*ssh.TransferProgress, error) {
 	return a.sftpService.GetTransferStatus(transferID)
@@ -33,6 +33,8 @@ func main() {
 	// Initialize managers
 	sessionManager := ssh.NewSessionManager()
 	transferManager := ssh.NewTransferManager()
+	transferManager.SetGlobalRateLimit(int64(configManager.GetSettings().TransferRateLimit) * 1024)
+	sessionManager.SetTransferChunks(configManager.GetSettings().TransferChunks)
 	fmt.Println("✓ SSH managers initialized")
 
 	// Initialize services
@@ -41,8 +43,17 @@ func main() {
 		Session:    service.NewSessionService(sessionManager),
 		SFTP:       service.NewSFTPService(sessionManager, transferManager),
 		Monitor:    service.NewMonitorService(sessionManager),
+		Alert:      service.NewAlertService(configManager),
 		Settings:   service.NewSettingsService(configManager),
 	}
+	services.Agent = service.NewAgentService(services.Connection, configManager, services.Monitor)
+	services.Metrics = service.NewMetricsExporter(services.Monitor, configManager)
+	settings := configManager.GetSettings()
+	services.SFTP.SetTrash(settings.FileManagerTrash, time.Duration(settings.FileManagerTrashMaxAge)*24*time.Hour)
+	services.Monitor.SetAlertService(services.Alert)
+	services.Alert.SetHooks(settings.AlertWebhookURL, settings.AlertCommand)
+	services.Monitor.SetHistoryPersistence(settings.MonitorHistoryPersist, time.Duration(settings.MonitorHistoryRetention)*24*time.Hour)
+	services.Agent.SetInterval(time.Duration(settings.MonitorAgentInterval) * time.Second)
 	fmt.Println("✓ Business services initialized")
 
 	// Create API server (default port 8080)
@@ -53,6 +64,7 @@ func main() {
 
 	server := api.NewServer(services, port)
 	fmt.Println("✓ HTTP/WebSocket server created")
+	services.Agent.Start()
 
 	// Start server in goroutine
 	go func() {
@@ -75,6 +87,10 @@ func main() {
 	if err := server.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v\n", err)
 	}
+	services.Agent.Stop()
+	if err := services.Monitor.FlushHistory(); err != nil {
+		fmt.Printf("Failed to write metrics history: %v\n", err)
+	}
 
 	fmt.Println("Server exited successfully")
 }
@@ -4,10 +4,18 @@ import {config} from '../models';
 import {ssh} from '../models';
 import {service} from '../models';
 
+export function AddAlertRule(arg1:config.AlertRule):Promise<config.AlertRule>;
+
 export function AddConnection(arg1:config.ConnectionConfig):Promise<void>;
 
+export function AddMonitoredHost(arg1:string):Promise<void>;
+
 export function CalculateHash(arg1:string,arg2:string):Promise<string>;
 
+export function CancelDiskUsageScan(arg1:string):Promise<void>;
+
+export function CancelFileSearch(arg1:string):Promise<void>;
+
 export function CancelTransfer(arg1:string):Promise<void>;
 
 export function ChangeDirectory(arg1:string,arg2:string):Promise<void>;
@@ -16,12 +24,18 @@ export function CloseDatabase(arg1:string):Promise<void>;
 
 export function CloseSSH(arg1:string):Promise<void>;
 
+export function CompressFiles(arg1:string,arg2:Array<string>,arg3:string,arg4:string):Promise<string>;
+
 export function ConnectDatabase(arg1:string,arg2:string,arg3:number,arg4:string,arg5:string,arg6:string,arg7:string):Promise<void>;
 
+export function ConnectFTP(arg1:ssh.FTPConfig):Promise<string>;
+
 export function ConnectLocalShell(arg1:string,arg2:string,arg3:number,arg4:number):Promise<void>;
 
 export function ConnectSSH(arg1:string,arg2:string,arg3:number,arg4:string,arg5:string,arg6:string,arg7:string,arg8:number,arg9:number):Promise<void>;
 
+export function CopyFiles(arg1:string,arg2:Array<string>,arg3:string,arg4:string):Promise<Array<string>>;
+
 export function CreateDirectory(arg1:string,arg2:string):Promise<void>;
 
 export function DateTimeToTimestamp(arg1:string,arg2:string):Promise<number>;
@@ -32,16 +46,28 @@ export function DecodeBase64(arg1:string):Promise<string>;
 
 export function DecryptText(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
 
+export function DeleteAlertRule(arg1:string):Promise<void>;
+
 export function DeleteFile(arg1:string,arg2:string):Promise<void>;
 
 export function DeleteFiles(arg1:string,arg2:Array<string>):Promise<void>;
 
+export function DeleteFilesPermanently(arg1:string,arg2:Array<string>):Promise<void>;
+
 export function DeletePassword(arg1:string):Promise<void>;
 
+export function DisconnectFTP(arg1:string):Promise<void>;
+
+export function DownloadDirectoryArchive(arg1:string,arg2:string,arg3:string):Promise<string>;
+
 export function DownloadFile(arg1:string,arg2:string,arg3:string):Promise<string>;
 
 export function DownloadFiles(arg1:string,arg2:Array<string>,arg3:string):Promise<Array<string>>;
 
+export function DownloadFilesBulk(arg1:string,arg2:Array<string>,arg3:string):Promise<string>;
+
+export function EmptyTrash(arg1:string):Promise<void>;
+
 export function EncodeBase64(arg1:string):Promise<string>;
 
 export function EncryptText(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
@@ -56,10 +82,16 @@ export function ExportConnectionsByIDs(arg1:Array<string>,arg2:boolean):Promise<
 
 export function ExportConnectionsByIDsWithPassphrase(arg1:Array<string>,arg2:string):Promise<string>;
 
+export function ExtractArchive(arg1:string,arg2:string,arg3:string):Promise<string>;
+
 export function FormatJSON(arg1:string):Promise<string>;
 
 export function GenerateUUIDv4():Promise<string>;
 
+export function GetActiveAlerts():Promise<Array<service.AlertEvent>>;
+
+export function GetAlertRules():Promise<Array<config.AlertRule>>;
+
 export function GetConnection(arg1:string):Promise<config.ConnectionConfig>;
 
 export function GetConnections():Promise<Array<config.ConnectionConfig>>;
@@ -74,6 +106,8 @@ export function GetFileInfo(arg1:string,arg2:string):Promise<ssh.FileInfo>;
 
 export function GetFileManagerSettings(arg1:string):Promise<config.FileManagerSettings>;
 
+export function GetMonitoredHosts():Promise<Array<service.MonitoredHost>>;
+
 export function GetMonitoringData(arg1:string):Promise<ssh.MonitoringData>;
 
 export function GetPassword(arg1:string):Promise<string>;
@@ -106,26 +140,56 @@ export function ListDatabases(arg1:string):Promise<Array<string>>;
 
 export function ListFiles(arg1:string,arg2:string):Promise<Array<ssh.FileInfo>>;
 
+export function ListFilesPage(arg1:string,arg2:string,arg3:ssh.ListOptions):Promise<ssh.DirectoryPage>;
+
+export function ListPortForwards(arg1:string):Promise<Array<ssh.PortForward>>;
+
+export function ListProcesses(arg1:string,arg2:ssh.ProcessListOptions):Promise<ssh.ProcessList>;
+
 export function ListSSHSessions():Promise<Array<string>>;
 
+export function ListSockets(arg1:string,arg2:ssh.SocketListOptions,arg3:ssh.SudoOptions):Promise<ssh.SocketList>;
+
+export function ListTails(arg1:string):Promise<Array<service.TailInfo>>;
+
+export function ListTrash(arg1:string):Promise<Array<ssh.TrashItem>>;
+
 export function MinifyJSON(arg1:string):Promise<string>;
 
 export function ParseURL(arg1:string):Promise<Record<string, any>>;
 
+export function PurgeTrash(arg1:string,arg2:Array<string>):Promise<void>;
+
+export function QueryMonitoringHistory(arg1:string,arg2:number,arg3:number,arg4:number):Promise<ssh.MetricsRange>;
+
+export function RelayFiles(arg1:string,arg2:Array<string>,arg3:string,arg4:string,arg5:ssh.RelayOptions):Promise<Array<string>>;
+
 export function RemoveConnection(arg1:string):Promise<void>;
 
+export function RemoveMonitoredHost(arg1:string):Promise<void>;
+
 export function RenameFile(arg1:string,arg2:string,arg3:string):Promise<void>;
 
+export function ReniceProcess(arg1:string,arg2:number,arg3:number,arg4:ssh.SudoOptions):Promise<void>;
+
 export function ResizeLocalShell(arg1:string,arg2:number,arg3:number):Promise<void>;
 
 export function ResizeSSH(arg1:string,arg2:number,arg3:number):Promise<void>;
 
+export function RestoreFromTrash(arg1:string,arg2:Array<string>):Promise<Array<string>>;
+
 export function SaveBinaryFile(arg1:string,arg2:string):Promise<string>;
 
 export function SavePassword(arg1:string,arg2:string):Promise<void>;
 
+export function ScanDiskUsage(arg1:string,arg2:ssh.DiskUsageOptions):Promise<string>;
+
+export function ScanPartition(arg1:string,arg2:ssh.PartitionInfo,arg3:number):Promise<string>;
+
 export function SearchDirectories(arg1:string,arg2:string,arg3:string,arg4:number,arg5:number):Promise<Array<ssh.SearchResult>>;
 
+export function SearchFiles(arg1:string,arg2:ssh.FileSearchQuery):Promise<string>;
+
 export function SelectDownloadDirectory():Promise<string>;
 
 export function SelectImportFile():Promise<string>;
@@ -142,6 +206,8 @@ export function SendSSHData(arg1:string,arg2:string):Promise<void>;
 
 export function SendSSHDataBinary(arg1:string,arg2:string):Promise<void>;
 
+export function SetTransferRateLimit(arg1:string,arg2:number):Promise<void>;
+
 export function ShowAboutDialog():Promise<void>;
 
 export function ShowErrorDialog(arg1:string,arg2:string):Promise<void>;
@@ -150,6 +216,18 @@ export function ShowMessageDialog(arg1:string,arg2:string):Promise<void>;
 
 export function ShowQuestionDialog(arg1:string,arg2:string):Promise<boolean>;
 
+export function SignalProcess(arg1:string,arg2:number,arg3:string,arg4:ssh.SudoOptions):Promise<void>;
+
+export function StartPortForward(arg1:string,arg2:ssh.PortForwardOptions):Promise<ssh.PortForward>;
+
+export function StartTail(arg1:string,arg2:ssh.TailOptions):Promise<string>;
+
+export function StopPortForward(arg1:string):Promise<void>;
+
+export function StopTail(arg1:string):Promise<void>;
+
+export function StopWatch(arg1:string):Promise<void>;
+
 export function TestConnection(arg1:string,arg2:number,arg3:string,arg4:string,arg5:string,arg6:string):Promise<void>;
 
 export function TestDatabaseConnection(arg1:string,arg2:number,arg3:string,arg4:string,arg5:string,arg6:string):Promise<void>;
@@ -162,6 +240,8 @@ export function URLDecode(arg1:string,arg2:string):Promise<service.URLDecodeResu
 
 export function URLEncode(arg1:string,arg2:string):Promise<service.URLEncodeResult>;
 
+export function UpdateAlertRule(arg1:config.AlertRule):Promise<void>;
+
 export function UpdateConnection(arg1:config.ConnectionConfig):Promise<void>;
 
 export function UpdateCurrentPath(arg1:string,arg2:string):Promise<void>;
@@ -172,4 +252,8 @@ export function UpdateSettings(arg1:Record<string, any>):Promise<void>;
 
 export function UploadFiles(arg1:string,arg2:Array<string>,arg3:string):Promise<Array<string>>;
 
+export function UploadFilesBulk(arg1:string,arg2:Array<string>,arg3:string):Promise<string>;
+
 export function ValidateJSON(arg1:string):Promise<service.JSONValidationResult>;
+
+export function WatchDirectory(arg1:string,arg2:ssh.WatchOptions):Promise<string>;
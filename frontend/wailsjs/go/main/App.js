@@ -2,14 +2,30 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AddAlertRule(arg1) {
+  return window['go']['main']['App']['AddAlertRule'](arg1);
+}
+
 export function AddConnection(arg1) {
   return window['go']['main']['App']['AddConnection'](arg1);
 }
 
+export function AddMonitoredHost(arg1) {
+  return window['go']['main']['App']['AddMonitoredHost'](arg1);
+}
+
 export function CalculateHash(arg1, arg2) {
   return window['go']['main']['App']['CalculateHash'](arg1, arg2);
 }
 
+export function CancelDiskUsageScan(arg1) {
+  return window['go']['main']['App']['CancelDiskUsageScan'](arg1);
+}
+
+export function CancelFileSearch(arg1) {
+  return window['go']['main']['App']['CancelFileSearch'](arg1);
+}
+
 export function CancelTransfer(arg1) {
   return window['go']['main']['App']['CancelTransfer'](arg1);
 }
@@ -26,10 +42,18 @@ export function CloseSSH(arg1) {
   return window['go']['main']['App']['CloseSSH'](arg1);
 }
 
+export function CompressFiles(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['CompressFiles'](arg1, arg2, arg3, arg4);
+}
+
 export function ConnectDatabase(arg1, arg2, arg3, arg4, arg5, arg6, arg7) {
   return window['go']['main']['App']['ConnectDatabase'](arg1, arg2, arg3, arg4, arg5, arg6, arg7);
 }
 
+export function ConnectFTP(arg1) {
+  return window['go']['main']['App']['ConnectFTP'](arg1);
+}
+
 export function ConnectLocalShell(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['ConnectLocalShell'](arg1, arg2, arg3, arg4);
 }
@@ -38,6 +62,10 @@ export function ConnectSSH(arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9)
   return window['go']['main']['App']['ConnectSSH'](arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9);
 }
 
+export function CopyFiles(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['CopyFiles'](arg1, arg2, arg3, arg4);
+}
+
 export function CreateDirectory(arg1, arg2) {
   return window['go']['main']['App']['CreateDirectory'](arg1, arg2);
 }
@@ -58,6 +86,10 @@ export function DecryptText(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['DecryptText'](arg1, arg2, arg3, arg4);
 }
 
+export function DeleteAlertRule(arg1) {
+  return window['go']['main']['App']['DeleteAlertRule'](arg1);
+}
+
 export function DeleteFile(arg1, arg2) {
   return window['go']['main']['App']['DeleteFile'](arg1, arg2);
 }
@@ -66,10 +98,22 @@ export function DeleteFiles(arg1, arg2) {
   return window['go']['main']['App']['DeleteFiles'](arg1, arg2);
 }
 
+export function DeleteFilesPermanently(arg1, arg2) {
+  return window['go']['main']['App']['DeleteFilesPermanently'](arg1, arg2);
+}
+
 export function DeletePassword(arg1) {
   return window['go']['main']['App']['DeletePassword'](arg1);
 }
 
+export function DisconnectFTP(arg1) {
+  return window['go']['main']['App']['DisconnectFTP'](arg1);
+}
+
+export function DownloadDirectoryArchive(arg1, arg2, arg3) {
+  return window['go']['main']['App']['DownloadDirectoryArchive'](arg1, arg2, arg3);
+}
+
 export function DownloadFile(arg1, arg2, arg3) {
   return window['go']['main']['App']['DownloadFile'](arg1, arg2, arg3);
 }
@@ -78,6 +122,14 @@ export function DownloadFiles(arg1, arg2, arg3) {
   return window['go']['main']['App']['DownloadFiles'](arg1, arg2, arg3);
 }
 
+export function DownloadFilesBulk(arg1, arg2, arg3) {
+  return window['go']['main']['App']['DownloadFilesBulk'](arg1, arg2, arg3);
+}
+
+export function EmptyTrash(arg1) {
+  return window['go']['main']['App']['EmptyTrash'](arg1);
+}
+
 export function EncodeBase64(arg1) {
   return window['go']['main']['App']['EncodeBase64'](arg1);
 }
@@ -106,6 +158,10 @@ export function ExportConnectionsByIDsWithPassphrase(arg1, arg2) {
   return window['go']['main']['App']['ExportConnectionsByIDsWithPassphrase'](arg1, arg2);
 }
 
+export function ExtractArchive(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ExtractArchive'](arg1, arg2, arg3);
+}
+
 export function FormatJSON(arg1) {
   return window['go']['main']['App']['FormatJSON'](arg1);
 }
@@ -114,6 +170,14 @@ export function GenerateUUIDv4() {
   return window['go']['main']['App']['GenerateUUIDv4']();
 }
 
+export function GetActiveAlerts() {
+  return window['go']['main']['App']['GetActiveAlerts']();
+}
+
+export function GetAlertRules() {
+  return window['go']['main']['App']['GetAlertRules']();
+}
+
 export function GetConnection(arg1) {
   return window['go']['main']['App']['GetConnection'](arg1);
 }
@@ -142,6 +206,10 @@ export function GetFileManagerSettings(arg1) {
   return window['go']['main']['App']['GetFileManagerSettings'](arg1);
 }
 
+export function GetMonitoredHosts() {
+  return window['go']['main']['App']['GetMonitoredHosts']();
+}
+
 export function GetMonitoringData(arg1) {
   return window['go']['main']['App']['GetMonitoringData'](arg1);
 }
@@ -206,10 +274,34 @@ export function ListFiles(arg1, arg2) {
   return window['go']['main']['App']['ListFiles'](arg1, arg2);
 }
 
+export function ListFilesPage(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ListFilesPage'](arg1, arg2, arg3);
+}
+
+export function ListPortForwards(arg1) {
+  return window['go']['main']['App']['ListPortForwards'](arg1);
+}
+
+export function ListProcesses(arg1, arg2) {
+  return window['go']['main']['App']['ListProcesses'](arg1, arg2);
+}
+
 export function ListSSHSessions() {
   return window['go']['main']['App']['ListSSHSessions']();
 }
 
+export function ListSockets(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ListSockets'](arg1, arg2, arg3);
+}
+
+export function ListTails(arg1) {
+  return window['go']['main']['App']['ListTails'](arg1);
+}
+
+export function ListTrash(arg1) {
+  return window['go']['main']['App']['ListTrash'](arg1);
+}
+
 export function MinifyJSON(arg1) {
   return window['go']['main']['App']['MinifyJSON'](arg1);
 }
@@ -218,14 +310,34 @@ export function ParseURL(arg1) {
   return window['go']['main']['App']['ParseURL'](arg1);
 }
 
+export function PurgeTrash(arg1, arg2) {
+  return window['go']['main']['App']['PurgeTrash'](arg1, arg2);
+}
+
+export function QueryMonitoringHistory(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['QueryMonitoringHistory'](arg1, arg2, arg3, arg4);
+}
+
+export function RelayFiles(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['RelayFiles'](arg1, arg2, arg3, arg4, arg5);
+}
+
 export function RemoveConnection(arg1) {
   return window['go']['main']['App']['RemoveConnection'](arg1);
 }
 
+export function RemoveMonitoredHost(arg1) {
+  return window['go']['main']['App']['RemoveMonitoredHost'](arg1);
+}
+
 export function RenameFile(arg1, arg2, arg3) {
   return window['go']['main']['App']['RenameFile'](arg1, arg2, arg3);
 }
 
+export function ReniceProcess(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['ReniceProcess'](arg1, arg2, arg3, arg4);
+}
+
 export function ResizeLocalShell(arg1, arg2, arg3) {
   return window['go']['main']['App']['ResizeLocalShell'](arg1, arg2, arg3);
 }
@@ -234,6 +346,10 @@ export function ResizeSSH(arg1, arg2, arg3) {
   return window['go']['main']['App']['ResizeSSH'](arg1, arg2, arg3);
 }
 
+export function RestoreFromTrash(arg1, arg2) {
+  return window['go']['main']['App']['RestoreFromTrash'](arg1, arg2);
+}
+
 export function SaveBinaryFile(arg1, arg2) {
   return window['go']['main']['App']['SaveBinaryFile'](arg1, arg2);
 }
@@ -242,10 +358,22 @@ export function SavePassword(arg1, arg2) {
   return window['go']['main']['App']['SavePassword'](arg1, arg2);
 }
 
+export function ScanDiskUsage(arg1, arg2) {
+  return window['go']['main']['App']['ScanDiskUsage'](arg1, arg2);
+}
+
+export function ScanPartition(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ScanPartition'](arg1, arg2, arg3);
+}
+
 export function SearchDirectories(arg1, arg2, arg3, arg4, arg5) {
   return window['go']['main']['App']['SearchDirectories'](arg1, arg2, arg3, arg4, arg5);
 }
 
+export function SearchFiles(arg1, arg2) {
+  return window['go']['main']['App']['SearchFiles'](arg1, arg2);
+}
+
 export function SelectDownloadDirectory() {
   return window['go']['main']['App']['SelectDownloadDirectory']();
 }
@@ -278,6 +406,10 @@ export function SendSSHDataBinary(arg1, arg2) {
   return window['go']['main']['App']['SendSSHDataBinary'](arg1, arg2);
 }
 
+export function SetTransferRateLimit(arg1, arg2) {
+  return window['go']['main']['App']['SetTransferRateLimit'](arg1, arg2);
+}
+
 export function ShowAboutDialog() {
   return window['go']['main']['App']['ShowAboutDialog']();
 }
@@ -294,6 +426,30 @@ export function ShowQuestionDialog(arg1, arg2) {
   return window['go']['main']['App']['ShowQuestionDialog'](arg1, arg2);
 }
 
+export function SignalProcess(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['SignalProcess'](arg1, arg2, arg3, arg4);
+}
+
+export function StartPortForward(arg1, arg2) {
+  return window['go']['main']['App']['StartPortForward'](arg1, arg2);
+}
+
+export function StartTail(arg1, arg2) {
+  return window['go']['main']['App']['StartTail'](arg1, arg2);
+}
+
+export function StopPortForward(arg1) {
+  return window['go']['main']['App']['StopPortForward'](arg1);
+}
+
+export function StopTail(arg1) {
+  return window['go']['main']['App']['StopTail'](arg1);
+}
+
+export function StopWatch(arg1) {
+  return window['go']['main']['App']['StopWatch'](arg1);
+}
+
 export function TestConnection(arg1, arg2, arg3, arg4, arg5, arg6) {
   return window['go']['main']['App']['TestConnection'](arg1, arg2, arg3, arg4, arg5, arg6);
 }
@@ -318,6 +474,10 @@ export function URLEncode(arg1, arg2) {
   return window['go']['main']['App']['URLEncode'](arg1, arg2);
 }
 
+export function UpdateAlertRule(arg1) {
+  return window['go']['main']['App']['UpdateAlertRule'](arg1);
+}
+
 export function UpdateConnection(arg1) {
   return window['go']['main']['App']['UpdateConnection'](arg1);
 }
@@ -338,6 +498,14 @@ export function UploadFiles(arg1, arg2, arg3) {
   return window['go']['main']['App']['UploadFiles'](arg1, arg2, arg3);
 }
 
+export function UploadFilesBulk(arg1, arg2, arg3) {
+  return window['go']['main']['App']['UploadFilesBulk'](arg1, arg2, arg3);
+}
+
 export function ValidateJSON(arg1) {
   return window['go']['main']['App']['ValidateJSON'](arg1);
 }
+
+export function WatchDirectory(arg1, arg2) {
+  return window['go']['main']['App']['WatchDirectory'](arg1, arg2);
+}
@@ -1,5 +1,33 @@
 export namespace config {
 	
+	export class AlertRule {
+	    id: string;
+	    name: string;
+	    enabled: boolean;
+	    metric: string;
+	    comparator: string;
+	    threshold: number;
+	    duration: number;
+	    connection_id?: string;
+	    tag?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AlertRule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.enabled = source["enabled"];
+	        this.metric = source["metric"];
+	        this.comparator = source["comparator"];
+	        this.threshold = source["threshold"];
+	        this.duration = source["duration"];
+	        this.connection_id = source["connection_id"];
+	        this.tag = source["tag"];
+	    }
+	}
 	export class FileManagerSettings {
 	    directory_tracking: boolean;
 	    history_enabled: boolean;
@@ -34,12 +62,23 @@ export namespace config {
 	    monitor_collapsed: boolean;
 	    monitor_width: number;
 	    monitor_refresh_interval: number;
+	    monitor_history_persist: boolean;
+	    monitor_history_retention: number;
+	    monitor_prometheus: boolean;
+	    monitor_agent_connections: string[];
+	    monitor_agent_interval: number;
 	    file_manager_collapsed: boolean;
 	    file_manager_width: number;
 	    file_manager_show_hidden: boolean;
 	    file_manager_sort_by: string;
 	    file_manager_sort_order: string;
 	    file_manager_per_connection?: Record<string, FileManagerSettings>;
+	    file_manager_trash: boolean;
+	    file_manager_trash_max_age: number;
+	    transfer_rate_limit: number;
+	    transfer_chunks: number;
+	    alert_webhook_url: string;
+	    alert_command: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new AppSettings(source);
@@ -62,12 +101,23 @@ export namespace config {
 	        this.monitor_collapsed = source["monitor_collapsed"];
 	        this.monitor_width = source["monitor_width"];
 	        this.monitor_refresh_interval = source["monitor_refresh_interval"];
+	        this.monitor_history_persist = source["monitor_history_persist"];
+	        this.monitor_history_retention = source["monitor_history_retention"];
+	        this.monitor_prometheus = source["monitor_prometheus"];
+	        this.monitor_agent_connections = source["monitor_agent_connections"];
+	        this.monitor_agent_interval = source["monitor_agent_interval"];
 	        this.file_manager_collapsed = source["file_manager_collapsed"];
 	        this.file_manager_width = source["file_manager_width"];
 	        this.file_manager_show_hidden = source["file_manager_show_hidden"];
 	        this.file_manager_sort_by = source["file_manager_sort_by"];
 	        this.file_manager_sort_order = source["file_manager_sort_order"];
 	        this.file_manager_per_connection = this.convertValues(source["file_manager_per_connection"], FileManagerSettings, true);
+	        this.file_manager_trash = source["file_manager_trash"];
+	        this.file_manager_trash_max_age = source["file_manager_trash_max_age"];
+	        this.transfer_rate_limit = source["transfer_rate_limit"];
+	        this.transfer_chunks = source["transfer_chunks"];
+	        this.alert_webhook_url = source["alert_webhook_url"];
+	        this.alert_command = source["alert_command"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -123,6 +173,44 @@ export namespace config {
 
 export namespace service {
 	
+	export class AlertEvent {
+	    key: string;
+	    state: string;
+	    rule_id: string;
+	    rule_name: string;
+	    session_id: string;
+	    target: string;
+	    connection_id?: string;
+	    metric: string;
+	    instance?: string;
+	    value: number;
+	    comparator: string;
+	    threshold: number;
+	    since: number;
+	    timestamp: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AlertEvent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.state = source["state"];
+	        this.rule_id = source["rule_id"];
+	        this.rule_name = source["rule_name"];
+	        this.session_id = source["session_id"];
+	        this.target = source["target"];
+	        this.connection_id = source["connection_id"];
+	        this.metric = source["metric"];
+	        this.instance = source["instance"];
+	        this.value = source["value"];
+	        this.comparator = source["comparator"];
+	        this.threshold = source["threshold"];
+	        this.since = source["since"];
+	        this.timestamp = source["timestamp"];
+	    }
+	}
 	export class JSONValidationResult {
 	    valid: boolean;
 	    error?: string;
@@ -137,6 +225,68 @@ export namespace service {
 	        this.error = source["error"];
 	    }
 	}
+	export class MonitoredHost {
+	    connection_id: string;
+	    session_id: string;
+	    name: string;
+	    target: string;
+	    state: string;
+	    error?: string;
+	    last_seen: number;
+	    latest?: ssh.MonitoringData;
+	    active_alerts: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new MonitoredHost(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.connection_id = source["connection_id"];
+	        this.session_id = source["session_id"];
+	        this.name = source["name"];
+	        this.target = source["target"];
+	        this.state = source["state"];
+	        this.error = source["error"];
+	        this.last_seen = source["last_seen"];
+	        this.latest = this.convertValues(source["latest"], ssh.MonitoringData);
+	        this.active_alerts = source["active_alerts"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TailInfo {
+	    tail_id: string;
+	    session_id: string;
+	    path: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TailInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tail_id = source["tail_id"];
+	        this.session_id = source["session_id"];
+	        this.path = source["path"];
+	    }
+	}
 	export class URLDecodeResult {
 	    decoded: string;
 	    params?: Record<string, string>;
@@ -174,13 +324,35 @@ export namespace service {
 
 export namespace ssh {
 	
+	export class CoreUsage {
+	    overall: number;
+	    user: number;
+	    system: number;
+	    iowait: number;
+	    steal: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CoreUsage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.overall = source["overall"];
+	        this.user = source["user"];
+	        this.system = source["system"];
+	        this.iowait = source["iowait"];
+	        this.steal = source["steal"];
+	    }
+	}
 	export class CPUMetrics {
 	    overall: number;
 	    user: number;
 	    system: number;
 	    iowait: number;
+	    steal: number;
 	    idle: number;
 	    per_core: number[];
+	    cores: CoreUsage[];
 	    load_average: number[];
 	
 	    static createFrom(source: any = {}) {
@@ -193,10 +365,127 @@ export namespace ssh {
 	        this.user = source["user"];
 	        this.system = source["system"];
 	        this.iowait = source["iowait"];
+	        this.steal = source["steal"];
 	        this.idle = source["idle"];
 	        this.per_core = source["per_core"];
+	        this.cores = this.convertValues(source["cores"], CoreUsage);
 	        this.load_average = source["load_average"];
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class FileInfo {
+	    name: string;
+	    path: string;
+	    size: number;
+	    mode: string;
+	    mod_time: string;
+	    is_dir: boolean;
+	    is_symlink: boolean;
+	    link_target?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new FileInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.size = source["size"];
+	        this.mode = source["mode"];
+	        this.mod_time = source["mod_time"];
+	        this.is_dir = source["is_dir"];
+	        this.is_symlink = source["is_symlink"];
+	        this.link_target = source["link_target"];
+	    }
+	}
+	export class DirectoryPage {
+	    path: string;
+	    files: FileInfo[];
+	    offset: number;
+	    total: number;
+	    has_more: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new DirectoryPage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.files = this.convertValues(source["files"], FileInfo);
+	        this.offset = source["offset"];
+	        this.total = source["total"];
+	        this.has_more = source["has_more"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DiskIOMetrics {
+	    name: string;
+	    read_bytes: number;
+	    write_bytes: number;
+	    reads: number;
+	    writes: number;
+	    busy_ms: number;
+	    read_rate: number;
+	    write_rate: number;
+	    read_iops: number;
+	    write_iops: number;
+	    utilization: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DiskIOMetrics(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.read_bytes = source["read_bytes"];
+	        this.write_bytes = source["write_bytes"];
+	        this.reads = source["reads"];
+	        this.writes = source["writes"];
+	        this.busy_ms = source["busy_ms"];
+	        this.read_rate = source["read_rate"];
+	        this.write_rate = source["write_rate"];
+	        this.read_iops = source["read_iops"];
+	        this.write_iops = source["write_iops"];
+	        this.utilization = source["utilization"];
+	    }
 	}
 	export class PartitionInfo {
 	    mount_point: string;
@@ -204,6 +493,10 @@ export namespace ssh {
 	    used: number;
 	    free: number;
 	    used_percent: number;
+	    inodes_total: number;
+	    inodes_used: number;
+	    inodes_free: number;
+	    inodes_used_percent: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new PartitionInfo(source);
@@ -216,10 +509,15 @@ export namespace ssh {
 	        this.used = source["used"];
 	        this.free = source["free"];
 	        this.used_percent = source["used_percent"];
+	        this.inodes_total = source["inodes_total"];
+	        this.inodes_used = source["inodes_used"];
+	        this.inodes_free = source["inodes_free"];
+	        this.inodes_used_percent = source["inodes_used_percent"];
 	    }
 	}
 	export class DiskMetrics {
 	    partitions: PartitionInfo[];
+	    devices: DiskIOMetrics[];
 	
 	    static createFrom(source: any = {}) {
 	        return new DiskMetrics(source);
@@ -228,6 +526,7 @@ export namespace ssh {
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.partitions = this.convertValues(source["partitions"], PartitionInfo);
+	        this.devices = this.convertValues(source["devices"], DiskIOMetrics);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -248,30 +547,133 @@ export namespace ssh {
 		    return a;
 		}
 	}
-	export class FileInfo {
-	    name: string;
+	export class DiskUsageOptions {
 	    path: string;
-	    size: number;
-	    mode: string;
-	    mod_time: string;
-	    is_dir: boolean;
-	    is_symlink: boolean;
-	    link_target?: string;
+	    max_depth: number;
+	    top_n: number;
+	    one_file_system: boolean;
 	
 	    static createFrom(source: any = {}) {
-	        return new FileInfo(source);
+	        return new DiskUsageOptions(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.name = source["name"];
 	        this.path = source["path"];
-	        this.size = source["size"];
-	        this.mode = source["mode"];
-	        this.mod_time = source["mod_time"];
-	        this.is_dir = source["is_dir"];
-	        this.is_symlink = source["is_symlink"];
-	        this.link_target = source["link_target"];
+	        this.max_depth = source["max_depth"];
+	        this.top_n = source["top_n"];
+	        this.one_file_system = source["one_file_system"];
+	    }
+	}
+	export class FTPConfig {
+	    host: string;
+	    port: number;
+	    user: string;
+	    password: string;
+	    tls: string;
+	    insecure_skip_verify: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FTPConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.host = source["host"];
+	        this.port = source["port"];
+	        this.user = source["user"];
+	        this.password = source["password"];
+	        this.tls = source["tls"];
+	        this.insecure_skip_verify = source["insecure_skip_verify"];
+	    }
+	}
+	
+	export class FileSearchQuery {
+	    path: string;
+	    name_pattern: string;
+	    name_regex: boolean;
+	    content: string;
+	    content_regex: boolean;
+	    ignore_case: boolean;
+	    min_size: number;
+	    max_size: number;
+	    modified_after: number;
+	    modified_before: number;
+	    max_depth: number;
+	    max_results: number;
+	    page_size: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FileSearchQuery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.name_pattern = source["name_pattern"];
+	        this.name_regex = source["name_regex"];
+	        this.content = source["content"];
+	        this.content_regex = source["content_regex"];
+	        this.ignore_case = source["ignore_case"];
+	        this.min_size = source["min_size"];
+	        this.max_size = source["max_size"];
+	        this.modified_after = source["modified_after"];
+	        this.modified_before = source["modified_before"];
+	        this.max_depth = source["max_depth"];
+	        this.max_results = source["max_results"];
+	        this.page_size = source["page_size"];
+	    }
+	}
+	export class InterfaceMetrics {
+	    name: string;
+	    rx_bytes: number;
+	    tx_bytes: number;
+	    rx_rate: number;
+	    tx_rate: number;
+	    rx_errors: number;
+	    tx_errors: number;
+	    rx_dropped: number;
+	    tx_dropped: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new InterfaceMetrics(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.rx_bytes = source["rx_bytes"];
+	        this.tx_bytes = source["tx_bytes"];
+	        this.rx_rate = source["rx_rate"];
+	        this.tx_rate = source["tx_rate"];
+	        this.rx_errors = source["rx_errors"];
+	        this.tx_errors = source["tx_errors"];
+	        this.rx_dropped = source["rx_dropped"];
+	        this.tx_dropped = source["tx_dropped"];
+	    }
+	}
+	export class ListOptions {
+	    offset: number;
+	    limit: number;
+	    sort_by: string;
+	    sort_order: string;
+	    dirs_first: boolean;
+	    hide_hidden: boolean;
+	    refresh: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ListOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.offset = source["offset"];
+	        this.limit = source["limit"];
+	        this.sort_by = source["sort_by"];
+	        this.sort_order = source["sort_order"];
+	        this.dirs_first = source["dirs_first"];
+	        this.hide_hidden = source["hide_hidden"];
+	        this.refresh = source["refresh"];
 	    }
 	}
 	export class MemoryMetrics {
@@ -300,11 +702,78 @@ export namespace ssh {
 	        this.swap_free = source["swap_free"];
 	    }
 	}
+	export class MetricsPoint {
+	    timestamp: number;
+	    cpu: number;
+	    memory: number;
+	    memory_used: number;
+	    swap_used: number;
+	    load: number;
+	    rx_rate: number;
+	    tx_rate: number;
+	    disk: number;
+	    samples: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new MetricsPoint(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.timestamp = source["timestamp"];
+	        this.cpu = source["cpu"];
+	        this.memory = source["memory"];
+	        this.memory_used = source["memory_used"];
+	        this.swap_used = source["swap_used"];
+	        this.load = source["load"];
+	        this.rx_rate = source["rx_rate"];
+	        this.tx_rate = source["tx_rate"];
+	        this.disk = source["disk"];
+	        this.samples = source["samples"];
+	    }
+	}
+	export class MetricsRange {
+	    from: number;
+	    to: number;
+	    step: number;
+	    points: MetricsPoint[];
+	
+	    static createFrom(source: any = {}) {
+	        return new MetricsRange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.step = source["step"];
+	        this.points = this.convertValues(source["points"], MetricsPoint);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class NetworkMetrics {
 	    total_rx_bytes: number;
 	    total_tx_bytes: number;
 	    rx_rate: number;
 	    tx_rate: number;
+	    interfaces: InterfaceMetrics[];
 	
 	    static createFrom(source: any = {}) {
 	        return new NetworkMetrics(source);
@@ -316,7 +785,26 @@ export namespace ssh {
 	        this.total_tx_bytes = source["total_tx_bytes"];
 	        this.rx_rate = source["rx_rate"];
 	        this.tx_rate = source["tx_rate"];
+	        this.interfaces = this.convertValues(source["interfaces"], InterfaceMetrics);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class SystemInfo {
 	    hostname: string;
@@ -382,6 +870,150 @@ export namespace ssh {
 	}
 	
 	
+	export class PortForward {
+	    id: string;
+	    session_id: string;
+	    local_address: string;
+	    local_port: number;
+	    remote_host: string;
+	    remote_port: number;
+	    active_connections: number;
+	    total_connections: number;
+	    created_at: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PortForward(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.session_id = source["session_id"];
+	        this.local_address = source["local_address"];
+	        this.local_port = source["local_port"];
+	        this.remote_host = source["remote_host"];
+	        this.remote_port = source["remote_port"];
+	        this.active_connections = source["active_connections"];
+	        this.total_connections = source["total_connections"];
+	        this.created_at = source["created_at"];
+	    }
+	}
+	export class PortForwardOptions {
+	    remote_host: string;
+	    remote_port: number;
+	    local_port: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PortForwardOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.remote_host = source["remote_host"];
+	        this.remote_port = source["remote_port"];
+	        this.local_port = source["local_port"];
+	    }
+	}
+	export class ProcessInfo {
+	    pid: number;
+	    ppid: number;
+	    user: string;
+	    name: string;
+	    command: string;
+	    rss: number;
+	    cpu_percent: number;
+	    mem_percent: number;
+	    nice: number;
+	    state: string;
+	    start_time: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProcessInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pid = source["pid"];
+	        this.ppid = source["ppid"];
+	        this.user = source["user"];
+	        this.name = source["name"];
+	        this.command = source["command"];
+	        this.rss = source["rss"];
+	        this.cpu_percent = source["cpu_percent"];
+	        this.mem_percent = source["mem_percent"];
+	        this.nice = source["nice"];
+	        this.state = source["state"];
+	        this.start_time = source["start_time"];
+	    }
+	}
+	export class ProcessList {
+	    processes: ProcessInfo[];
+	    total: number;
+	    timestamp: number;
+	    basic: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProcessList(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.processes = this.convertValues(source["processes"], ProcessInfo);
+	        this.total = source["total"];
+	        this.timestamp = source["timestamp"];
+	        this.basic = source["basic"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProcessListOptions {
+	    sort_by: string;
+	    limit: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProcessListOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sort_by = source["sort_by"];
+	        this.limit = source["limit"];
+	    }
+	}
+	export class RelayOptions {
+	    direct: boolean;
+	    tool: string;
+	    target_host: string;
+	    accept_new_host_key: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new RelayOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.direct = source["direct"];
+	        this.tool = source["tool"];
+	        this.target_host = source["target_host"];
+	        this.accept_new_host_key = source["accept_new_host_key"];
+	    }
+	}
 	export class SearchResult {
 	    path: string;
 	    name: string;
@@ -398,15 +1030,126 @@ export namespace ssh {
 	        this.depth = source["depth"];
 	    }
 	}
+	export class SocketInfo {
+	    protocol: string;
+	    state: string;
+	    local_address: string;
+	    local_port: number;
+	    peer_address: string;
+	    peer_port: number;
+	    pid: number;
+	    process: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SocketInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.protocol = source["protocol"];
+	        this.state = source["state"];
+	        this.local_address = source["local_address"];
+	        this.local_port = source["local_port"];
+	        this.peer_address = source["peer_address"];
+	        this.peer_port = source["peer_port"];
+	        this.pid = source["pid"];
+	        this.process = source["process"];
+	    }
+	}
+	export class SocketList {
+	    sockets: SocketInfo[];
+	    timestamp: number;
+	    source: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SocketList(source);
+	    }
 	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sockets = this.convertValues(source["sockets"], SocketInfo);
+	        this.timestamp = source["timestamp"];
+	        this.source = source["source"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SocketListOptions {
+	    listening_only: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new SocketListOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.listening_only = source["listening_only"];
+	    }
+	}
+	export class SudoOptions {
+	    enabled: boolean;
+	    password?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SudoOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.password = source["password"];
+	    }
+	}
+	
+	export class TailOptions {
+	    path: string;
+	    mode: string;
+	    filter: string;
+	    ignore_case: boolean;
+	    initial_lines: number;
+	    poll_interval: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TailOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.mode = source["mode"];
+	        this.filter = source["filter"];
+	        this.ignore_case = source["ignore_case"];
+	        this.initial_lines = source["initial_lines"];
+	        this.poll_interval = source["poll_interval"];
+	    }
+	}
 	export class TransferProgress {
 	    transfer_id: string;
 	    session_id: string;
+	    target_session_id?: string;
 	    filename: string;
 	    bytes_sent: number;
 	    total_bytes: number;
 	    percentage: number;
 	    speed: number;
+	    entries_done?: number;
+	    entries_total?: number;
 	    status: string;
 	    error?: string;
 	
@@ -418,15 +1161,56 @@ export namespace ssh {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.transfer_id = source["transfer_id"];
 	        this.session_id = source["session_id"];
+	        this.target_session_id = source["target_session_id"];
 	        this.filename = source["filename"];
 	        this.bytes_sent = source["bytes_sent"];
 	        this.total_bytes = source["total_bytes"];
 	        this.percentage = source["percentage"];
 	        this.speed = source["speed"];
+	        this.entries_done = source["entries_done"];
+	        this.entries_total = source["entries_total"];
 	        this.status = source["status"];
 	        this.error = source["error"];
 	    }
 	}
+	export class TrashItem {
+	    name: string;
+	    original_path: string;
+	    deleted_at: string;
+	    size: number;
+	    is_dir: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new TrashItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.original_path = source["original_path"];
+	        this.deleted_at = source["deleted_at"];
+	        this.size = source["size"];
+	        this.is_dir = source["is_dir"];
+	    }
+	}
+	export class WatchOptions {
+	    path: string;
+	    mode: string;
+	    poll_interval: number;
+	    debounce: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new WatchOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.mode = source["mode"];
+	        this.poll_interval = source["poll_interval"];
+	        this.debounce = source["debounce"];
+	    }
+	}
 
 }
 
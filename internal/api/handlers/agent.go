@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"AHaSSHTools/internal/api/dto"
+	"AHaSSHTools/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AgentHandler handles requests for hosts monitored in the background
+type AgentHandler struct {
+	service *service.AgentService
+}
+
+// NewAgentHandler creates a new agent handler
+func NewAgentHandler(s *service.AgentService) *AgentHandler {
+	return &AgentHandler{service: s}
+}
+
+// addHostRequest selects a saved connection to monitor
+type addHostRequest struct {
+	ConnectionID string `json:"connection_id" binding:"required"`
+}
+
+// ListHosts handles GET /api/v1/agent/hosts
+func (h *AgentHandler) ListHosts(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.NewSuccessResponse(h.service.GetHosts()))
+}
+
+// AddHost handles POST /api/v1/agent/hosts
+func (h *AgentHandler) AddHost(c *gin.Context) {
+	var req addHostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorMessageResponse("Invalid request body"))
+		return
+	}
+
+	if err := h.service.AddHost(req.ConnectionID); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewSuccessResponse(nil))
+}
+
+// RemoveHost handles DELETE /api/v1/agent/hosts/:connectionId
+func (h *AgentHandler) RemoveHost(c *gin.Context) {
+	if err := h.service.RemoveHost(c.Param("connectionId")); err != nil {
+		c.JSON(http.StatusNotFound, dto.NewErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse(nil))
+}
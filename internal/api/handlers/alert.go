@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+
+	"AHaSSHTools/internal/api/dto"
+	"AHaSSHTools/internal/config"
+	"AHaSSHTools/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AlertHandler handles alert-related HTTP requests
+type AlertHandler struct {
+	service *service.AlertService
+}
+
+// NewAlertHandler creates a new alert handler
+func NewAlertHandler(s *service.AlertService) *AlertHandler {
+	return &AlertHandler{service: s}
+}
+
+// ListActiveAlerts handles GET /api/v1/alerts
+func (h *AlertHandler) ListActiveAlerts(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.NewSuccessResponse(h.service.ActiveAlerts()))
+}
+
+// GetRules handles GET /api/v1/alerts/rules
+func (h *AlertHandler) GetRules(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.NewSuccessResponse(h.service.GetRules()))
+}
+
+// AddRule handles POST /api/v1/alerts/rules
+func (h *AlertHandler) AddRule(c *gin.Context) {
+	var rule config.AlertRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorMessageResponse("Invalid request body"))
+		return
+	}
+
+	rule, err := h.service.AddRule(rule)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewSuccessResponse(rule))
+}
+
+// UpdateRule handles PUT /api/v1/alerts/rules/:id
+func (h *AlertHandler) UpdateRule(c *gin.Context) {
+	var rule config.AlertRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorMessageResponse("Invalid request body"))
+		return
+	}
+
+	// Ensure the ID matches
+	rule.ID = c.Param("id")
+
+	if err := h.service.UpdateRule(rule); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse(rule))
+}
+
+// DeleteRule handles DELETE /api/v1/alerts/rules/:id
+func (h *AlertHandler) DeleteRule(c *gin.Context) {
+	if err := h.service.DeleteRule(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, dto.NewErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessMessageResponse("Alert rule deleted successfully"))
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	"AHaSSHTools/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsHandler serves collected metrics to Prometheus
+type MetricsHandler struct {
+	exporter *service.MetricsExporter
+}
+
+// NewMetricsHandler creates a new metrics handler
+func NewMetricsHandler(e *service.MetricsExporter) *MetricsHandler {
+	return &MetricsHandler{exporter: e}
+}
+
+// GetMetrics handles GET /metrics, in the Prometheus text format; it is not found
+// unless enabled in the settings
+func (h *MetricsHandler) GetMetrics(c *gin.Context) {
+	if !h.exporter.Enabled() {
+		c.String(http.StatusNotFound, "Prometheus metrics are disabled\n")
+		return
+	}
+
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := h.exporter.Write(c.Writer); err != nil {
+		c.Error(err)
+	}
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"AHaSSHTools/internal/api/dto"
+	"AHaSSHTools/internal/service"
+	"AHaSSHTools/internal/ssh"
+	"github.com/gin-gonic/gin"
+)
+
+// MonitorHandler handles monitoring-related HTTP requests
+type MonitorHandler struct {
+	service *service.MonitorService
+}
+
+// NewMonitorHandler creates a new monitor handler
+func NewMonitorHandler(s *service.MonitorService) *MonitorHandler {
+	return &MonitorHandler{service: s}
+}
+
+// signalRequest is the body of POST /api/v1/monitor/:id/processes/:pid/signal
+type signalRequest struct {
+	Signal string          `json:"signal"`
+	Sudo   ssh.SudoOptions `json:"sudo"`
+}
+
+// reniceRequest is the body of POST /api/v1/monitor/:id/processes/:pid/renice
+type reniceRequest struct {
+	Nice *int            `json:"nice"`
+	Sudo ssh.SudoOptions `json:"sudo"`
+}
+
+// ListProcesses handles GET /api/v1/monitor/:id/processes?sort_by=cpu|memory&limit=N
+func (h *MonitorHandler) ListProcesses(c *gin.Context) {
+	opts := ssh.ProcessListOptions{SortBy: c.DefaultQuery("sort_by", "cpu")}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, dto.NewErrorMessageResponse("Invalid limit"))
+			return
+		}
+		opts.Limit = n
+	}
+
+	processes, err := h.service.ListProcesses(c.Param("id"), opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse(processes))
+}
+
+// ListSockets handles GET /api/v1/monitor/:id/sockets?listening_only=true&sudo=true
+// sudo must not ask for a password here, as there is no request body to carry one
+func (h *MonitorHandler) ListSockets(c *gin.Context) {
+	opts := ssh.SocketListOptions{ListeningOnly: c.Query("listening_only") == "true"}
+	sudo := ssh.SudoOptions{Enabled: c.Query("sudo") == "true"}
+
+	sockets, err := h.service.ListSockets(c.Param("id"), opts, sudo)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse(sockets))
+}
+
+// GetMonitoringData handles GET /api/v1/monitor/:id
+func (h *MonitorHandler) GetMonitoringData(c *gin.Context) {
+	data, err := h.service.GetMonitoringData(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse(data))
+}
+
+// QueryHistory handles GET /api/v1/monitor/:id/history?from=&to=&step=
+// Times are Unix seconds and step is in seconds; all are optional
+func (h *MonitorHandler) QueryHistory(c *gin.Context) {
+	var values [3]int64
+	for i, name := range []string{"from", "to", "step"} {
+		if value := c.Query(name); value != "" {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n < 0 {
+				c.JSON(http.StatusBadRequest, dto.NewErrorMessageResponse("Invalid "+name))
+				return
+			}
+			values[i] = n
+		}
+	}
+
+	history, err := h.service.QueryHistory(c.Param("id"), values[0], values[1], values[2])
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse(history))
+}
+
+// SignalProcess handles POST /api/v1/monitor/:id/processes/:pid/signal
+func (h *MonitorHandler) SignalProcess(c *gin.Context) {
+	pid, err := strconv.Atoi(c.Param("pid"))
+	var req signalRequest
+	if err != nil || c.ShouldBindJSON(&req) != nil || req.Signal == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorMessageResponse("Invalid request body"))
+		return
+	}
+
+	if err := h.service.SignalProcess(c.Param("id"), pid, req.Signal, req.Sudo); err != nil {
+		c.JSON(processErrorStatus(err), dto.NewErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessMessageResponse("Signal sent successfully"))
+}
+
+// ReniceProcess handles POST /api/v1/monitor/:id/processes/:pid/renice
+func (h *MonitorHandler) ReniceProcess(c *gin.Context) {
+	pid, err := strconv.Atoi(c.Param("pid"))
+	var req reniceRequest
+	if err != nil || c.ShouldBindJSON(&req) != nil || req.Nice == nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorMessageResponse("Invalid request body"))
+		return
+	}
+
+	if err := h.service.ReniceProcess(c.Param("id"), pid, *req.Nice, req.Sudo); err != nil {
+		c.JSON(processErrorStatus(err), dto.NewErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessMessageResponse("Priority changed successfully"))
+}
+
+// processErrorStatus tells clients when an action may succeed with sudo
+func processErrorStatus(err error) int {
+	if errors.Is(err, ssh.ErrSudoRequired) {
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
@@ -7,19 +7,22 @@ import (
 	"AHaSSHTools/internal/api/dto"
 	"AHaSSHTools/internal/api/websocket"
 	"AHaSSHTools/internal/service"
+	"AHaSSHTools/internal/ssh"
 	"github.com/gin-gonic/gin"
 )
 
 // SessionHandler handles SSH session-related HTTP requests
 type SessionHandler struct {
 	service *service.SessionService
+	monitor *service.MonitorService
 	wsHub   *websocket.Hub
 }
 
 // NewSessionHandler creates a new session handler
-func NewSessionHandler(s *service.SessionService, hub *websocket.Hub) *SessionHandler {
+func NewSessionHandler(s *service.SessionService, monitor *service.MonitorService, hub *websocket.Hub) *SessionHandler {
 	return &SessionHandler{
 		service: s,
+		monitor: monitor,
 		wsHub:   hub,
 	}
 }
@@ -128,6 +131,7 @@ func (h *SessionHandler) Disconnect(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(err))
 		return
 	}
+	h.monitor.ForgetSession(sessionID)
 
 	c.JSON(http.StatusOK, dto.NewSuccessMessageResponse("Session closed successfully"))
 }
@@ -137,3 +141,36 @@ func (h *SessionHandler) ListSessions(c *gin.Context) {
 	sessions := h.service.ListSessions()
 	c.JSON(http.StatusOK, dto.NewSuccessResponse(sessions))
 }
+
+// StartPortForward handles POST /api/v1/sessions/:id/forwards
+func (h *SessionHandler) StartPortForward(c *gin.Context) {
+	var opts ssh.PortForwardOptions
+	if err := c.ShouldBindJSON(&opts); err != nil || opts.RemotePort == 0 {
+		c.JSON(http.StatusBadRequest, dto.NewErrorMessageResponse("Invalid request body"))
+		return
+	}
+
+	forward, err := h.service.StartPortForward(c.Param("id"), opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse(forward))
+}
+
+// ListPortForwards handles GET /api/v1/sessions/:id/forwards
+func (h *SessionHandler) ListPortForwards(c *gin.Context) {
+	forwards := h.service.ListPortForwards(c.Param("id"))
+	c.JSON(http.StatusOK, dto.NewSuccessResponse(forwards))
+}
+
+// StopPortForward handles DELETE /api/v1/sessions/:id/forwards/:forwardId
+func (h *SessionHandler) StopPortForward(c *gin.Context) {
+	if err := h.service.StopPortForward(c.Param("id"), c.Param("forwardId")); err != nil {
+		c.JSON(http.StatusNotFound, dto.NewErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessMessageResponse("Port forward stopped successfully"))
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+
+	"AHaSSHTools/internal/api/dto"
+	"AHaSSHTools/internal/api/websocket"
+	"AHaSSHTools/internal/service"
+	"AHaSSHTools/internal/ssh"
+	"github.com/gin-gonic/gin"
+)
+
+// SFTPHandler handles SFTP-related HTTP requests
+type SFTPHandler struct {
+	service *service.SFTPService
+	wsHub   *websocket.Hub
+}
+
+// NewSFTPHandler creates a new SFTP handler
+func NewSFTPHandler(s *service.SFTPService, hub *websocket.Hub) *SFTPHandler {
+	return &SFTPHandler{
+		service: s,
+		wsHub:   hub,
+	}
+}
+
+// StartTail handles POST /api/v1/sftp/:id/tails
+func (h *SFTPHandler) StartTail(c *gin.Context) {
+	sessionID := c.Param("id")
+
+	var opts ssh.TailOptions
+	if err := c.ShouldBindJSON(&opts); err != nil || opts.Path == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorMessageResponse("Invalid request body"))
+		return
+	}
+
+	tailID, err := h.service.StartTail(sessionID, opts, func(event ssh.TailEvent) {
+		// Broadcast new lines via WebSocket
+		h.wsHub.BroadcastToSession(sessionID, "sftp:tail", event)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse(map[string]string{
+		"tail_id": tailID,
+	}))
+}
+
+// ListTails handles GET /api/v1/sftp/:id/tails
+func (h *SFTPHandler) ListTails(c *gin.Context) {
+	tails := h.service.ListTails(c.Param("id"))
+	c.JSON(http.StatusOK, dto.NewSuccessResponse(tails))
+}
+
+// StopTail handles DELETE /api/v1/sftp/:id/tails/:tailId
+func (h *SFTPHandler) StopTail(c *gin.Context) {
+	if err := h.service.StopTail(c.Param("tailId")); err != nil {
+		c.JSON(http.StatusNotFound, dto.NewErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessMessageResponse("Tail stopped successfully"))
+}
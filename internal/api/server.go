@@ -26,6 +26,9 @@ type Services struct {
 	Session    *service.SessionService
 	SFTP       *service.SFTPService
 	Monitor    *service.MonitorService
+	Alert      *service.AlertService
+	Agent      *service.AgentService
+	Metrics    *service.MetricsExporter
 	Settings   *service.SettingsService
 }
 
@@ -43,6 +46,18 @@ func NewServer(services *Services, port int) *Server {
 		services: services,
 	}
 
+	// Alerts go out to every client
+	if services.Alert != nil {
+		services.Alert.SetHandler(func(event service.AlertEvent) {
+			wsHub.Broadcast(websocket.NewEventMessage("monitor:alert", event))
+		})
+	}
+	if services.Agent != nil {
+		services.Agent.SetStatusHandler(func(host service.MonitoredHost) {
+			wsHub.Broadcast(websocket.NewEventMessage("monitor:agent", host))
+		})
+	}
+
 	// Setup routes
 	server.setupRoutes()
 
@@ -65,6 +80,12 @@ func (s *Server) setupRoutes() {
 	s.router.Use(Logger())
 	s.router.Use(Recovery())
 
+	// Prometheus scrape endpoint, at the conventional path outside the API
+	if s.services.Metrics != nil {
+		metricsHandler := handlers.NewMetricsHandler(s.services.Metrics)
+		s.router.GET("/metrics", metricsHandler.GetMetrics)
+	}
+
 	// API version 1 group
 	api := s.router.Group("/api/v1")
 
@@ -90,12 +111,56 @@ func (s *Server) setupRoutes() {
 	// SSH session routes
 	sessions := api.Group("/sessions")
 	{
-		sessHandler := handlers.NewSessionHandler(s.services.Session, s.wsHub)
+		sessHandler := handlers.NewSessionHandler(s.services.Session, s.services.Monitor, s.wsHub)
 		sessions.POST("/connect", sessHandler.Connect)
 		sessions.POST("/:id/send", sessHandler.SendData)
 		sessions.POST("/:id/resize", sessHandler.Resize)
 		sessions.DELETE("/:id", sessHandler.Disconnect)
 		sessions.GET("", sessHandler.ListSessions)
+		sessions.POST("/:id/forwards", sessHandler.StartPortForward)
+		sessions.GET("/:id/forwards", sessHandler.ListPortForwards)
+		sessions.DELETE("/:id/forwards/:forwardId", sessHandler.StopPortForward)
+	}
+
+	// SFTP routes
+	sftp := api.Group("/sftp")
+	{
+		sftpHandler := handlers.NewSFTPHandler(s.services.SFTP, s.wsHub)
+		sftp.POST("/:id/tails", sftpHandler.StartTail)
+		sftp.GET("/:id/tails", sftpHandler.ListTails)
+		sftp.DELETE("/:id/tails/:tailId", sftpHandler.StopTail)
+	}
+
+	// Monitoring routes
+	monitor := api.Group("/monitor")
+	{
+		monitorHandler := handlers.NewMonitorHandler(s.services.Monitor)
+		monitor.GET("/:id", monitorHandler.GetMonitoringData)
+		monitor.GET("/:id/history", monitorHandler.QueryHistory)
+		monitor.GET("/:id/processes", monitorHandler.ListProcesses)
+		monitor.POST("/:id/processes/:pid/signal", monitorHandler.SignalProcess)
+		monitor.POST("/:id/processes/:pid/renice", monitorHandler.ReniceProcess)
+		monitor.GET("/:id/sockets", monitorHandler.ListSockets)
+	}
+
+	// Alert routes
+	alerts := api.Group("/alerts")
+	{
+		alertHandler := handlers.NewAlertHandler(s.services.Alert)
+		alerts.GET("", alertHandler.ListActiveAlerts)
+		alerts.GET("/rules", alertHandler.GetRules)
+		alerts.POST("/rules", alertHandler.AddRule)
+		alerts.PUT("/rules/:id", alertHandler.UpdateRule)
+		alerts.DELETE("/rules/:id", alertHandler.DeleteRule)
+	}
+
+	// Background monitoring routes
+	agent := api.Group("/agent")
+	{
+		agentHandler := handlers.NewAgentHandler(s.services.Agent)
+		agent.GET("/hosts", agentHandler.ListHosts)
+		agent.POST("/hosts", agentHandler.AddHost)
+		agent.DELETE("/hosts/:connectionId", agentHandler.RemoveHost)
 	}
 
 	// WebSocket endpoint
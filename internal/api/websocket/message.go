@@ -36,3 +36,12 @@ func NewTransferProgressMessage(transferID string, data interface{}) *Message {
 		Timestamp:  time.Now().Unix(),
 	}
 }
+
+// NewEventMessage creates a message for all clients, whatever they subscribed to
+func NewEventMessage(eventType string, data interface{}) *Message {
+	return &Message{
+		Type:      eventType,
+		Data:      data,
+		Timestamp: time.Now().Unix(),
+	}
+}
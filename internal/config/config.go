@@ -22,9 +22,23 @@ type ConnectionConfig struct {
 	Type     string            `json:"type,omitempty"` // "ssh", "database", "docker"
 }
 
+// AlertRule raises an alert when a monitored metric crosses a threshold for long enough
+type AlertRule struct {
+	ID           string  `json:"id"`
+	Name         string  `json:"name"`
+	Enabled      bool    `json:"enabled"`
+	Metric       string  `json:"metric"`                  // Path into the monitoring data's JSON, "*" for every element, e.g. "disk.partitions.*.used_percent"
+	Comparator   string  `json:"comparator"`              // ">", ">=", "<", "<=", "==" or "!="
+	Threshold    float64 `json:"threshold"`               // Value the metric is compared to
+	Duration     int     `json:"duration"`                // Seconds the condition must hold before firing, 0 = at once
+	ConnectionID string  `json:"connection_id,omitempty"` // Only hosts of this saved connection
+	Tag          string  `json:"tag,omitempty"`           // Only hosts of saved connections with this tag
+}
+
 // AppConfig represents application configuration
 type AppConfig struct {
 	Connections []ConnectionConfig `json:"connections"`
+	AlertRules  []AlertRule        `json:"alert_rules,omitempty"`
 	Settings    AppSettings        `json:"settings"`
 }
 
@@ -44,9 +58,16 @@ type AppSettings struct {
 	SidebarWidth       int    `json:"sidebar_width"`
 
 	// Monitor panel settings
-	MonitorCollapsed       bool `json:"monitor_collapsed"`
-	MonitorWidth           int  `json:"monitor_width"`
-	MonitorRefreshInterval int  `json:"monitor_refresh_interval"` // seconds
+	MonitorCollapsed        bool `json:"monitor_collapsed"`
+	MonitorWidth            int  `json:"monitor_width"`
+	MonitorRefreshInterval  int  `json:"monitor_refresh_interval"`  // seconds
+	MonitorHistoryPersist   bool `json:"monitor_history_persist"`   // Keep metrics history on disk across sessions
+	MonitorHistoryRetention int  `json:"monitor_history_retention"` // Days of history kept on disk, 0 = forever
+	MonitorPrometheus       bool `json:"monitor_prometheus"`        // Serve the latest metrics at /metrics on the API server
+
+	// Background monitoring of saved connections, without a terminal open
+	MonitorAgentConnections []string `json:"monitor_agent_connections"` // IDs of the monitored connections
+	MonitorAgentInterval    int      `json:"monitor_agent_interval"`    // seconds between collections
 
 	// File manager settings
 	FileManagerCollapsed     bool                           `json:"file_manager_collapsed"`
@@ -55,6 +76,16 @@ type AppSettings struct {
 	FileManagerSortBy        string                         `json:"file_manager_sort_by"`
 	FileManagerSortOrder     string                         `json:"file_manager_sort_order"`
 	FileManagerPerConnection map[string]FileManagerSettings `json:"file_manager_per_connection,omitempty"`
+	FileManagerTrash         bool                           `json:"file_manager_trash"`         // Move deleted remote items to the server's trash
+	FileManagerTrashMaxAge   int                            `json:"file_manager_trash_max_age"` // Days before trashed items are purged, 0 = never
+
+	// Transfer settings
+	TransferRateLimit int `json:"transfer_rate_limit"` // KB/s for all transfers combined, 0 = unlimited
+	TransferChunks    int `json:"transfer_chunks"`     // Concurrent ranges per large file, 1 = sequential
+
+	// Alert hooks, run for every firing and resolved alert besides the in-app notification
+	AlertWebhookURL string `json:"alert_webhook_url"` // Receives each alert as a JSON POST
+	AlertCommand    string `json:"alert_command"`     // Local shell command, given the alert as JSON on stdin
 }
 
 // FileManagerSettings stores file manager configuration per connection
@@ -68,26 +99,37 @@ type FileManagerSettings struct {
 // DefaultSettings returns default application settings
 func DefaultSettings() AppSettings {
 	return AppSettings{
-		Theme:                  "dark",
-		ThemeMode:              "system",
-		UseSystemTheme:         true,
-		AccentColor:            "teal",
-		FontFamily:             "\"Avenir Next\", \"SF Pro Text\", \"Segoe UI\", \"PingFang SC\", \"Hiragino Sans GB\", \"Microsoft YaHei\", sans-serif",
-		FontSize:               14,
-		TerminalTheme:          "default",
-		TerminalFontFamily:     "Menlo, Monaco, \"Courier New\", monospace",
-		TerminalFontSize:       14,
-		CompactMode:            false,
-		ReducedMotion:          false,
-		SidebarWidth:           300,
-		MonitorCollapsed:       true,
-		MonitorWidth:           350,
-		MonitorRefreshInterval: 2,
-		FileManagerCollapsed:   true,
-		FileManagerWidth:       400,
-		FileManagerShowHidden:  false,
-		FileManagerSortBy:      "name",
-		FileManagerSortOrder:   "asc",
+		Theme:                   "dark",
+		ThemeMode:               "system",
+		UseSystemTheme:          true,
+		AccentColor:             "teal",
+		FontFamily:              "\"Avenir Next\", \"SF Pro Text\", \"Segoe UI\", \"PingFang SC\", \"Hiragino Sans GB\", \"Microsoft YaHei\", sans-serif",
+		FontSize:                14,
+		TerminalTheme:           "default",
+		TerminalFontFamily:      "Menlo, Monaco, \"Courier New\", monospace",
+		TerminalFontSize:        14,
+		CompactMode:             false,
+		ReducedMotion:           false,
+		SidebarWidth:            300,
+		MonitorCollapsed:        true,
+		MonitorWidth:            350,
+		MonitorRefreshInterval:  2,
+		MonitorHistoryPersist:   false,
+		MonitorHistoryRetention: 7,
+		MonitorPrometheus:       false,
+		MonitorAgentConnections: []string{},
+		MonitorAgentInterval:    30,
+		FileManagerCollapsed:    true,
+		FileManagerWidth:        400,
+		FileManagerShowHidden:   false,
+		FileManagerSortBy:       "name",
+		FileManagerSortOrder:    "asc",
+		FileManagerTrash:        false,
+		FileManagerTrashMaxAge:  30,
+		TransferRateLimit:       0,
+		TransferChunks:          4,
+		AlertWebhookURL:         "",
+		AlertCommand:            "",
 	}
 }
 
@@ -210,6 +252,45 @@ func (cm *ConfigManager) UpdateConnection(conn ConnectionConfig) error {
 	return fmt.Errorf("connection not found: %s", conn.ID)
 }
 
+// GetAlertRules returns the saved alert rules
+func (cm *ConfigManager) GetAlertRules() []AlertRule {
+	return append([]AlertRule{}, cm.config.AlertRules...)
+}
+
+// AddAlertRule adds a new alert rule
+func (cm *ConfigManager) AddAlertRule(rule AlertRule) error {
+	cm.config.AlertRules = append(cm.config.AlertRules, rule)
+	return cm.Save()
+}
+
+// UpdateAlertRule updates an existing alert rule
+func (cm *ConfigManager) UpdateAlertRule(rule AlertRule) error {
+	for i, r := range cm.config.AlertRules {
+		if r.ID == rule.ID {
+			cm.config.AlertRules[i] = rule
+			return cm.Save()
+		}
+	}
+	return fmt.Errorf("alert rule not found: %s", rule.ID)
+}
+
+// RemoveAlertRule removes an alert rule by ID
+func (cm *ConfigManager) RemoveAlertRule(id string) error {
+	for i, rule := range cm.config.AlertRules {
+		if rule.ID == id {
+			cm.config.AlertRules = append(cm.config.AlertRules[:i], cm.config.AlertRules[i+1:]...)
+			return cm.Save()
+		}
+	}
+	return fmt.Errorf("alert rule not found: %s", id)
+}
+
+// SetMonitorAgentConnections sets the connections monitored in the background
+func (cm *ConfigManager) SetMonitorAgentConnections(ids []string) error {
+	cm.config.Settings.MonitorAgentConnections = ids
+	return cm.Save()
+}
+
 // GetSettings returns the current application settings
 func (cm *ConfigManager) GetSettings() AppSettings {
 	return cm.config.Settings
@@ -274,6 +355,18 @@ func (cm *ConfigManager) UpdateSettings(updates map[string]interface{}) error {
 	if monitorRefreshInterval, ok := updates["monitor_refresh_interval"].(float64); ok {
 		cm.config.Settings.MonitorRefreshInterval = int(monitorRefreshInterval)
 	}
+	if monitorHistoryPersist, ok := updates["monitor_history_persist"].(bool); ok {
+		cm.config.Settings.MonitorHistoryPersist = monitorHistoryPersist
+	}
+	if monitorHistoryRetention, ok := updates["monitor_history_retention"].(float64); ok {
+		cm.config.Settings.MonitorHistoryRetention = max(int(monitorHistoryRetention), 0)
+	}
+	if monitorPrometheus, ok := updates["monitor_prometheus"].(bool); ok {
+		cm.config.Settings.MonitorPrometheus = monitorPrometheus
+	}
+	if monitorAgentInterval, ok := updates["monitor_agent_interval"].(float64); ok {
+		cm.config.Settings.MonitorAgentInterval = max(int(monitorAgentInterval), 5)
+	}
 
 	// File manager settings
 	if fileManagerCollapsed, ok := updates["file_manager_collapsed"].(bool); ok {
@@ -291,6 +384,28 @@ func (cm *ConfigManager) UpdateSettings(updates map[string]interface{}) error {
 	if fileManagerSortOrder, ok := updates["file_manager_sort_order"].(string); ok {
 		cm.config.Settings.FileManagerSortOrder = fileManagerSortOrder
 	}
+	if fileManagerTrash, ok := updates["file_manager_trash"].(bool); ok {
+		cm.config.Settings.FileManagerTrash = fileManagerTrash
+	}
+	if fileManagerTrashMaxAge, ok := updates["file_manager_trash_max_age"].(float64); ok {
+		cm.config.Settings.FileManagerTrashMaxAge = max(int(fileManagerTrashMaxAge), 0)
+	}
+
+	// Transfer settings
+	if transferRateLimit, ok := updates["transfer_rate_limit"].(float64); ok {
+		cm.config.Settings.TransferRateLimit = max(int(transferRateLimit), 0)
+	}
+	if transferChunks, ok := updates["transfer_chunks"].(float64); ok {
+		cm.config.Settings.TransferChunks = max(int(transferChunks), 1)
+	}
+
+	// Alert hooks
+	if alertWebhookURL, ok := updates["alert_webhook_url"].(string); ok {
+		cm.config.Settings.AlertWebhookURL = alertWebhookURL
+	}
+	if alertCommand, ok := updates["alert_command"].(string); ok {
+		cm.config.Settings.AlertCommand = alertCommand
+	}
 
 	// File manager per-connection settings
 	if connID, ok := updates["connection_id"].(string); ok {
@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"AHaSSHTools/internal/config"
+	"AHaSSHTools/internal/ssh"
+)
+
+// Monitored host states
+const (
+	HostConnecting = "connecting"
+	HostOnline     = "online"
+	HostOffline    = "offline" // Connecting or collecting failed; retried with backoff
+)
+
+const (
+	// agentMaxRetryDelay caps the backoff between reconnection attempts
+	agentMaxRetryDelay = 5 * time.Minute
+
+	// agentConnectTimeout bounds connecting to a monitored host
+	agentConnectTimeout = 15 * time.Second
+)
+
+// MonitoredHost is the status of a host monitored in the background
+type MonitoredHost struct {
+	ConnectionID string              `json:"connection_id"`
+	SessionID    string              `json:"session_id"` // Stands in for a session ID in history queries
+	Name         string              `json:"name"`
+	Target       string              `json:"target"` // user@host:port
+	State        string              `json:"state"`  // HostConnecting, HostOnline or HostOffline
+	Error        string              `json:"error,omitempty"`
+	LastSeen     int64               `json:"last_seen"` // Last successful collection, 0 = never
+	Latest       *ssh.MonitoringData `json:"latest,omitempty"`
+	ActiveAlerts int                 `json:"active_alerts"`
+}
+
+// MonitoredHostHandler receives a host's status whenever it changes
+type MonitoredHostHandler func(host MonitoredHost)
+
+// agentHost is a monitored host and the goroutine collecting from it
+type agentHost struct {
+	mu     sync.Mutex
+	status MonitoredHost
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// AgentService monitors a chosen set of saved connections in the background. Each host
+// gets its own headless connection, without a shell or PTY, so metrics are collected on
+// schedule whether or not a terminal is open; history and alerts work as for sessions.
+type AgentService struct {
+	connectionService *ConnectionService
+	configManager     *config.ConfigManager
+	monitorService    *MonitorService
+
+	mu       sync.Mutex
+	hosts    map[string]*agentHost // connectionID -> host
+	handler  MonitoredHostHandler
+	interval atomic.Int64 // Collection interval as a time.Duration
+}
+
+// NewAgentService creates a new monitoring agent; Start begins monitoring
+func NewAgentService(cs *ConnectionService, cm *config.ConfigManager, ms *MonitorService) *AgentService {
+	s := &AgentService{
+		connectionService: cs,
+		configManager:     cm,
+		monitorService:    ms,
+		hosts:             make(map[string]*agentHost),
+	}
+	s.interval.Store(int64(30 * time.Second))
+	return s
+}
+
+// SetInterval sets how often metrics are collected, from the next collection on
+func (s *AgentService) SetInterval(interval time.Duration) {
+	s.interval.Store(int64(max(interval, 5*time.Second)))
+}
+
+// SetStatusHandler sets where host status changes are delivered
+func (s *AgentService) SetStatusHandler(handler MonitoredHostHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handler = handler
+}
+
+// Start monitors the connections saved in the settings
+func (s *AgentService) Start() {
+	for _, connectionID := range s.configManager.GetSettings().MonitorAgentConnections {
+		if err := s.startHost(connectionID); err != nil {
+			fmt.Printf("Failed to start monitoring %s: %v\n", connectionID, err)
+		}
+	}
+}
+
+// Stop stops monitoring all hosts and closes their connections
+func (s *AgentService) Stop() {
+	s.mu.Lock()
+	hosts := s.hosts
+	s.hosts = make(map[string]*agentHost)
+	s.mu.Unlock()
+
+	for _, host := range hosts {
+		host.cancel()
+	}
+	for _, host := range hosts {
+		<-host.done
+	}
+}
+
+// AddHost starts monitoring a saved connection and remembers it across restarts
+func (s *AgentService) AddHost(connectionID string) error {
+	if err := s.startHost(connectionID); err != nil {
+		return err
+	}
+
+	ids := s.configManager.GetSettings().MonitorAgentConnections
+	if !slices.Contains(ids, connectionID) {
+		return s.configManager.SetMonitorAgentConnections(append(slices.Clone(ids), connectionID))
+	}
+	return nil
+}
+
+// RemoveHost stops monitoring a connection and drops its in-memory history
+func (s *AgentService) RemoveHost(connectionID string) error {
+	s.mu.Lock()
+	host, exists := s.hosts[connectionID]
+	delete(s.hosts, connectionID)
+	s.mu.Unlock()
+
+	if exists {
+		host.cancel()
+		<-host.done
+		s.monitorService.ForgetSession(agentSessionID(connectionID))
+	}
+
+	ids := s.configManager.GetSettings().MonitorAgentConnections
+	if !slices.Contains(ids, connectionID) {
+		if !exists {
+			return fmt.Errorf("connection not monitored: %s", connectionID)
+		}
+		return nil
+	}
+	return s.configManager.SetMonitorAgentConnections(slices.DeleteFunc(slices.Clone(ids), func(id string) bool {
+		return id == connectionID
+	}))
+}
+
+// GetHosts returns the status of all monitored hosts, for a dashboard
+func (s *AgentService) GetHosts() []MonitoredHost {
+	s.mu.Lock()
+	hosts := make([]MonitoredHost, 0, len(s.hosts))
+	for _, host := range s.hosts {
+		host.mu.Lock()
+		hosts = append(hosts, host.status)
+		host.mu.Unlock()
+	}
+	s.mu.Unlock()
+
+	s.countAlerts(hosts)
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Name < hosts[j].Name })
+	return hosts
+}
+
+// startHost starts the collection goroutine of a saved SSH connection
+func (s *AgentService) startHost(connectionID string) error {
+	conn, err := s.configManager.GetConnection(connectionID)
+	if err != nil {
+		return err
+	}
+	if conn.Type != "" && conn.Type != "ssh" {
+		return fmt.Errorf("only SSH connections can be monitored: %s", conn.Name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.hosts[connectionID]; exists {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	host := &agentHost{
+		status: MonitoredHost{
+			ConnectionID: connectionID,
+			SessionID:    agentSessionID(connectionID),
+			Name:         conn.Name,
+			Target:       fmt.Sprintf("%s@%s:%d", conn.User, conn.Host, connectionPort(conn)),
+			State:        HostConnecting,
+		},
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	s.hosts[connectionID] = host
+
+	go s.run(ctx, host)
+	return nil
+}
+
+// run connects to a host and collects its metrics until cancelled, reconnecting with
+// backoff when the connection fails
+func (s *AgentService) run(ctx context.Context, host *agentHost) {
+	defer close(host.done)
+
+	var client *ssh.Client
+	defer func() {
+		if client != nil {
+			client.Close()
+		}
+	}()
+
+	var retryDelay time.Duration
+	for {
+		if client == nil {
+			s.update(host, func(status *MonitoredHost) { status.State = HostConnecting })
+			var err error
+			if client, err = s.connect(host.status.ConnectionID); err != nil {
+				s.update(host, func(status *MonitoredHost) {
+					status.State = HostOffline
+					status.Error = err.Error()
+				})
+			}
+		}
+
+		if client != nil {
+			data, err := s.monitorService.CollectClientMetrics(host.status.SessionID, client)
+			if err != nil {
+				client.Close()
+				client = nil
+				s.update(host, func(status *MonitoredHost) {
+					status.State = HostOffline
+					status.Error = err.Error()
+				})
+			} else {
+				s.update(host, func(status *MonitoredHost) {
+					status.State = HostOnline
+					status.Error = ""
+					status.LastSeen = data.Timestamp
+					status.Latest = data
+				})
+			}
+		}
+
+		wait := time.Duration(s.interval.Load())
+		if client == nil {
+			retryDelay = min(max(retryDelay*2, wait), agentMaxRetryDelay)
+			wait = retryDelay
+		} else {
+			retryDelay = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// connect opens a headless connection with the saved connection's credentials. For key
+// authentication the saved password, if any, is the key's passphrase.
+func (s *AgentService) connect(connectionID string) (*ssh.Client, error) {
+	conn, err := s.configManager.GetConnection(connectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := ""
+	if s.connectionService.HasPassword(connectionID) {
+		if secret, err = s.connectionService.GetPassword(connectionID); err != nil {
+			return nil, fmt.Errorf("failed to read saved password: %w", err)
+		}
+	}
+
+	sshConfig := &ssh.Config{
+		Host:    conn.Host,
+		Port:    connectionPort(conn),
+		User:    conn.User,
+		Timeout: agentConnectTimeout,
+	}
+	if conn.AuthType == "key" {
+		sshConfig.KeyPath = conn.KeyPath
+		sshConfig.Passphrase = secret
+	} else {
+		sshConfig.Password = secret
+	}
+
+	client, err := ssh.NewClient(sshConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	if err := client.Connect(); err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	return client, nil
+}
+
+// update changes a host's status and reports it
+func (s *AgentService) update(host *agentHost, change func(status *MonitoredHost)) {
+	host.mu.Lock()
+	change(&host.status)
+	status := host.status
+	host.mu.Unlock()
+
+	s.mu.Lock()
+	handler := s.handler
+	s.mu.Unlock()
+
+	if handler != nil {
+		statuses := []MonitoredHost{status}
+		s.countAlerts(statuses)
+		handler(statuses[0])
+	}
+}
+
+// countAlerts fills in how many alerts are firing for each host
+func (s *AgentService) countAlerts(hosts []MonitoredHost) {
+	alerts := s.monitorService.alerts
+	if alerts == nil {
+		return
+	}
+
+	for _, alert := range alerts.ActiveAlerts() {
+		for i := range hosts {
+			if alert.Target == hosts[i].Target {
+				hosts[i].ActiveAlerts++
+			}
+		}
+	}
+}
+
+// agentSessionID is the key a monitored host's samples and history are kept under
+func agentSessionID(connectionID string) string {
+	return "agent:" + connectionID
+}
+
+// connectionPort returns a saved connection's port, 22 if unset
+func connectionPort(conn config.ConnectionConfig) int {
+	if conn.Port == 0 {
+		return 22
+	}
+	return conn.Port
+}
@@ -0,0 +1,524 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"AHaSSHTools/internal/config"
+	"AHaSSHTools/internal/ssh"
+)
+
+// Alert states
+const (
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+// alertHookTimeout bounds how long a webhook or command hook may take
+const alertHookTimeout = 10 * time.Second
+
+// AlertEvent reports an alert starting to fire or resolving
+type AlertEvent struct {
+	Key          string  `json:"key"`   // Identifies the alert across its firing and resolved events
+	State        string  `json:"state"` // AlertFiring or AlertResolved
+	RuleID       string  `json:"rule_id"`
+	RuleName     string  `json:"rule_name"`
+	SessionID    string  `json:"session_id"`
+	Target       string  `json:"target"`                  // user@host:port of the monitored host
+	ConnectionID string  `json:"connection_id,omitempty"` // Saved connection of the host, if any
+	Metric       string  `json:"metric"`
+	Instance     string  `json:"instance,omitempty"` // Element matched by "*", e.g. a mount point
+	Value        float64 `json:"value"`
+	Comparator   string  `json:"comparator"`
+	Threshold    float64 `json:"threshold"`
+	Since        int64   `json:"since"` // When the condition started to hold
+	Timestamp    int64   `json:"timestamp"`
+}
+
+// AlertHandler receives alert events, e.g. to forward them to the frontend
+type AlertHandler func(event AlertEvent)
+
+// alertState tracks a condition that holds, until it stops holding
+type alertState struct {
+	event AlertEvent // Last evaluation, sent as is when the alert fires
+	fired bool
+}
+
+// alertHook is an event waiting for the hooks that were set when it happened
+type alertHook struct {
+	event   AlertEvent
+	webhook string
+	command string
+}
+
+// AlertService evaluates alert rules against collected monitoring data. Each rule, host
+// and instance is one alert, which fires once when its condition has held for the rule's
+// duration and resolves once when it stops holding, however many sessions watch the host.
+type AlertService struct {
+	configManager *config.ConfigManager
+
+	mu         sync.Mutex
+	rules      []config.AlertRule
+	states     map[string]*alertState     // alert key -> state
+	watchers   map[string]map[string]bool // target -> sessions evaluating it
+	hookQueues map[string][]alertHook     // rule|target -> events not yet sent, present while being sent
+	handler    AlertHandler
+	webhook    string
+	command    string
+}
+
+// NewAlertService creates a new alert service with the saved rules
+func NewAlertService(cm *config.ConfigManager) *AlertService {
+	return &AlertService{
+		configManager: cm,
+		rules:         cm.GetAlertRules(),
+		states:        make(map[string]*alertState),
+		watchers:      make(map[string]map[string]bool),
+		hookQueues:    make(map[string][]alertHook),
+	}
+}
+
+// SetHandler sets where alert events are delivered. It is called during evaluation, so it
+// must not block or call back into the service.
+func (s *AlertService) SetHandler(handler AlertHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handler = handler
+}
+
+// SetHooks sets the webhook URL and local command run for every event; empty disables them
+func (s *AlertService) SetHooks(webhookURL, command string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.webhook = webhookURL
+	s.command = command
+}
+
+// GetRules returns the alert rules
+func (s *AlertService) GetRules() []config.AlertRule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]config.AlertRule{}, s.rules...)
+}
+
+// AddRule saves a new alert rule, assigning it an ID if it has none
+func (s *AlertService) AddRule(rule config.AlertRule) (config.AlertRule, error) {
+	if err := validateAlertRule(rule); err != nil {
+		return rule, err
+	}
+	if rule.ID == "" {
+		rule.ID = fmt.Sprintf("alert_%d", time.Now().UnixNano())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.configManager.AddAlertRule(rule); err != nil {
+		return rule, err
+	}
+	s.rules = s.configManager.GetAlertRules()
+	return rule, nil
+}
+
+// UpdateRule saves changes to a rule; its alerts start over under the new conditions
+func (s *AlertService) UpdateRule(rule config.AlertRule) error {
+	if err := validateAlertRule(rule); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.configManager.UpdateAlertRule(rule); err != nil {
+		return err
+	}
+	s.rules = s.configManager.GetAlertRules()
+	s.resolveRule(rule.ID)
+	return nil
+}
+
+// DeleteRule deletes a rule, resolving its firing alerts
+func (s *AlertService) DeleteRule(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.configManager.RemoveAlertRule(id); err != nil {
+		return err
+	}
+	s.rules = s.configManager.GetAlertRules()
+	s.resolveRule(id)
+	return nil
+}
+
+// ActiveAlerts returns the alerts currently firing, oldest first
+func (s *AlertService) ActiveAlerts() []AlertEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	active := []AlertEvent{}
+	for _, state := range s.states {
+		if state.fired {
+			active = append(active, state.event)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].Since < active[j].Since })
+	return active
+}
+
+// Evaluate checks the rules against a snapshot of a host. target is the host's
+// user@host:port, or "" for a session without one.
+func (s *AlertService) Evaluate(sessionID, target string, data *ssh.MonitoringData) {
+	if target == "" {
+		target = sessionID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.watchers[target] == nil {
+		s.watchers[target] = make(map[string]bool)
+	}
+	s.watchers[target][sessionID] = true
+
+	if len(s.rules) == 0 {
+		return
+	}
+
+	var values map[string]any
+	encoded, err := json.Marshal(data)
+	if err == nil {
+		err = json.Unmarshal(encoded, &values)
+	}
+	if err != nil {
+		fmt.Printf("Failed to evaluate alerts: %v\n", err)
+		return
+	}
+
+	connection, hasConnection := findConnection(s.configManager, target)
+	seen := make(map[string]bool)
+	for _, rule := range s.rules {
+		if !rule.Enabled {
+			continue
+		}
+		if rule.ConnectionID != "" && (!hasConnection || connection.ID != rule.ConnectionID) {
+			continue
+		}
+		if rule.Tag != "" && (!hasConnection || !slices.Contains(connection.Tags, rule.Tag)) {
+			continue
+		}
+
+		for instance, value := range resolveMetric(values, strings.Split(rule.Metric, "."), "") {
+			key := rule.ID + "|" + target + "|" + instance
+			seen[key] = true
+
+			if !compareMetric(value, rule.Comparator, rule.Threshold) {
+				s.resolve(key, data.Timestamp)
+				continue
+			}
+
+			state, ok := s.states[key]
+			if !ok {
+				state = &alertState{event: AlertEvent{
+					Key:        key,
+					RuleID:     rule.ID,
+					RuleName:   rule.Name,
+					Target:     target,
+					Metric:     rule.Metric,
+					Instance:   instance,
+					Comparator: rule.Comparator,
+					Threshold:  rule.Threshold,
+					Since:      data.Timestamp,
+				}}
+				if hasConnection {
+					state.event.ConnectionID = connection.ID
+				}
+				s.states[key] = state
+			}
+			state.event.SessionID = sessionID
+			state.event.Value = value
+			state.event.Timestamp = data.Timestamp
+
+			if !state.fired && data.Timestamp-state.event.Since >= int64(rule.Duration) {
+				state.fired = true
+				state.event.State = AlertFiring
+				s.deliver(state.event)
+			}
+		}
+	}
+
+	// Instances that are gone, such as an unmounted partition, no longer hold
+	for key := range s.states {
+		if strings.Contains(key, "|"+target+"|") && !seen[key] {
+			s.resolve(key, data.Timestamp)
+		}
+	}
+}
+
+// ForgetSession stops counting a closed session as watching its host. Once no session
+// watches a host its alerts are resolved, so hooks are not left with alerts that never end.
+func (s *AlertService) ForgetSession(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for target, sessions := range s.watchers {
+		if !sessions[sessionID] {
+			continue
+		}
+		delete(sessions, sessionID)
+		if len(sessions) > 0 {
+			continue
+		}
+
+		delete(s.watchers, target)
+		now := time.Now().Unix()
+		for key, state := range s.states {
+			if state.event.Target == target {
+				s.resolve(key, now)
+			}
+		}
+	}
+}
+
+// resolve ends an alert, sending a resolved event if it had fired
+func (s *AlertService) resolve(key string, timestamp int64) {
+	state, ok := s.states[key]
+	if !ok {
+		return
+	}
+	delete(s.states, key)
+
+	if state.fired {
+		state.event.State = AlertResolved
+		state.event.Timestamp = timestamp
+		s.deliver(state.event)
+	}
+}
+
+// resolveRule ends all alerts of a rule
+func (s *AlertService) resolveRule(ruleID string) {
+	now := time.Now().Unix()
+	for key, state := range s.states {
+		if state.event.RuleID == ruleID {
+			s.resolve(key, now)
+		}
+	}
+}
+
+// findConnection finds the saved SSH connection a user@host:port belongs to
+func findConnection(cm *config.ConfigManager, target string) (config.ConnectionConfig, bool) {
+	for _, conn := range cm.GetConfig().Connections {
+		if conn.Type != "" && conn.Type != "ssh" {
+			continue
+		}
+		if fmt.Sprintf("%s@%s:%d", conn.User, conn.Host, connectionPort(conn)) == target {
+			return conn, true
+		}
+	}
+	return config.ConnectionConfig{}, false
+}
+
+// deliver hands an event to the handler and queues it for the hooks without blocking
+// evaluation. Events of a rule and host reach the hooks in order, so an alert's resolved
+// event never overtakes its firing one.
+func (s *AlertService) deliver(event AlertEvent) {
+	if s.handler != nil {
+		s.handler(event)
+	}
+
+	if s.webhook == "" && s.command == "" {
+		return
+	}
+
+	queueKey := event.RuleID + "|" + event.Target
+	queue, sending := s.hookQueues[queueKey]
+	s.hookQueues[queueKey] = append(queue, alertHook{event: event, webhook: s.webhook, command: s.command})
+	if !sending {
+		go s.sendHooks(queueKey)
+	}
+}
+
+// sendHooks runs the hooks for the queued events of a rule and host one at a time, until
+// the queue is empty
+func (s *AlertService) sendHooks(queueKey string) {
+	for {
+		s.mu.Lock()
+		queue := s.hookQueues[queueKey]
+		if len(queue) == 0 {
+			delete(s.hookQueues, queueKey)
+			s.mu.Unlock()
+			return
+		}
+		hook := queue[0]
+		s.hookQueues[queueKey] = queue[1:]
+		s.mu.Unlock()
+
+		hook.send()
+	}
+}
+
+// send posts the event to the webhook and runs the command
+func (h alertHook) send() {
+	payload, err := json.Marshal(h.event)
+	if err != nil {
+		return
+	}
+	if h.webhook != "" {
+		if err := postAlertWebhook(h.webhook, payload); err != nil {
+			fmt.Printf("Failed to send alert webhook: %v\n", err)
+		}
+	}
+	if h.command != "" {
+		if err := runAlertCommand(h.command, h.event, payload); err != nil {
+			fmt.Printf("Failed to run alert command: %v\n", err)
+		}
+	}
+}
+
+// postAlertWebhook posts an event as JSON
+func postAlertWebhook(url string, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), alertHookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// runAlertCommand runs the command hook with the event as JSON on stdin and its main
+// fields in AHASSH_ALERT_* environment variables
+func runAlertCommand(command string, event AlertEvent, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), alertHookTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	}
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"AHASSH_ALERT_STATE="+event.State,
+		"AHASSH_ALERT_RULE="+event.RuleName,
+		"AHASSH_ALERT_TARGET="+event.Target,
+		"AHASSH_ALERT_METRIC="+event.Metric,
+		"AHASSH_ALERT_INSTANCE="+event.Instance,
+		"AHASSH_ALERT_VALUE="+strconv.FormatFloat(event.Value, 'f', -1, 64),
+	)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// validateAlertRule checks a rule can be evaluated
+func validateAlertRule(rule config.AlertRule) error {
+	if rule.Metric == "" {
+		return fmt.Errorf("alert rule has no metric")
+	}
+	if !slices.Contains([]string{">", ">=", "<", "<=", "==", "!="}, rule.Comparator) {
+		return fmt.Errorf("invalid comparator: %s", rule.Comparator)
+	}
+	if rule.Duration < 0 {
+		return fmt.Errorf("invalid duration: %d", rule.Duration)
+	}
+	return nil
+}
+
+// resolveMetric looks up a dotted path in decoded JSON. "*" matches every element of an
+// array or object; each match is named after the element's mount point or name if it has
+// one, or else its index or key. Values that are not numbers are skipped.
+func resolveMetric(value any, path []string, instance string) map[string]float64 {
+	if len(path) == 0 {
+		if number, ok := value.(float64); ok {
+			return map[string]float64{instance: number}
+		}
+		return nil
+	}
+
+	segment, rest := path[0], path[1:]
+	result := make(map[string]float64)
+	merge := func(child any, name string) {
+		if instance != "" {
+			name = instance + "/" + name
+		}
+		for k, v := range resolveMetric(child, rest, name) {
+			result[k] = v
+		}
+	}
+
+	switch node := value.(type) {
+	case map[string]any:
+		if segment != "*" {
+			return resolveMetric(node[segment], rest, instance)
+		}
+		for key, child := range node {
+			merge(child, key)
+		}
+	case []any:
+		if segment != "*" {
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil
+			}
+			return resolveMetric(node[index], rest, instance)
+		}
+		for index, child := range node {
+			name := strconv.Itoa(index)
+			if element, ok := child.(map[string]any); ok {
+				for _, field := range []string{"mount_point", "name"} {
+					if label, ok := element[field].(string); ok && label != "" {
+						name = label
+						break
+					}
+				}
+			}
+			merge(child, name)
+		}
+	}
+	return result
+}
+
+// compareMetric applies a rule's comparator
+func compareMetric(value float64, comparator string, threshold float64) bool {
+	switch comparator {
+	case ">":
+		return value > threshold
+	case ">=":
+		return value >= threshold
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	case "==":
+		return value == threshold
+	case "!=":
+		return value != threshold
+	}
+	return false
+}
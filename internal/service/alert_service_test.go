@@ -0,0 +1,197 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"AHaSSHTools/internal/config"
+	"AHaSSHTools/internal/ssh"
+)
+
+func newTestAlertService(t *testing.T, rules ...config.AlertRule) (*AlertService, *[]AlertEvent) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	cm, err := config.NewConfigManager()
+	if err != nil {
+		t.Fatalf("failed to create config manager: %v", err)
+	}
+	cm.AddConnection(config.ConnectionConfig{ID: "web", Host: "web1", Port: 22, User: "root", Tags: []string{"prod"}})
+
+	s := NewAlertService(cm)
+	for _, rule := range rules {
+		if _, err := s.AddRule(rule); err != nil {
+			t.Fatalf("failed to add rule: %v", err)
+		}
+	}
+
+	events := &[]AlertEvent{}
+	s.SetHandler(func(event AlertEvent) { *events = append(*events, event) })
+	return s, events
+}
+
+func diskSnapshot(timestamp int64, usedPercent float64) *ssh.MonitoringData {
+	return &ssh.MonitoringData{
+		Timestamp: timestamp,
+		Disk: ssh.DiskMetrics{Partitions: []ssh.PartitionInfo{
+			{MountPoint: "/", UsedPercent: 40},
+			{MountPoint: "/data", UsedPercent: usedPercent},
+		}},
+	}
+}
+
+func TestAlertService_Evaluate_FiresOnceAfterDuration(t *testing.T) {
+	s, events := newTestAlertService(t, config.AlertRule{
+		ID: "disk", Name: "Disk full", Enabled: true, Tag: "prod",
+		Metric: "disk.partitions.*.used_percent", Comparator: ">", Threshold: 90, Duration: 60,
+	})
+
+	// Two sessions watch the same host; the alert fires once, after a minute
+	s.Evaluate("s1", "root@web1:22", diskSnapshot(1000, 95))
+	s.Evaluate("s2", "root@web1:22", diskSnapshot(1030, 96))
+	if len(*events) != 0 {
+		t.Fatalf("fired before the duration: %+v", *events)
+	}
+	s.Evaluate("s1", "root@web1:22", diskSnapshot(1060, 97))
+	s.Evaluate("s2", "root@web1:22", diskSnapshot(1062, 97))
+	if len(*events) != 1 {
+		t.Fatalf("events = %+v, want one firing event", *events)
+	}
+	fired := (*events)[0]
+	if fired.State != AlertFiring || fired.Instance != "/data" || fired.Value != 97 || fired.Since != 1000 || fired.ConnectionID != "web" {
+		t.Errorf("firing event = %+v", fired)
+	}
+	if active := s.ActiveAlerts(); len(active) != 1 {
+		t.Errorf("active alerts = %+v", active)
+	}
+
+	s.Evaluate("s1", "root@web1:22", diskSnapshot(1090, 50))
+	if len(*events) != 2 || (*events)[1].State != AlertResolved || (*events)[1].Key != fired.Key {
+		t.Errorf("events = %+v, want a resolved event", *events)
+	}
+
+	// Hosts without the tag are not evaluated
+	s.Evaluate("s3", "root@db1:22", diskSnapshot(2000, 99))
+	if len(*events) != 2 || len(s.ActiveAlerts()) != 0 {
+		t.Errorf("untagged host raised alerts: %+v", *events)
+	}
+}
+
+func TestAlertService_DeleteRule_ResolvesAlerts(t *testing.T) {
+	s, events := newTestAlertService(t, config.AlertRule{
+		ID: "load", Enabled: true, Metric: "cpu.load_average.0", Comparator: ">=", Threshold: 4,
+	})
+
+	s.Evaluate("s1", "root@db1:22", &ssh.MonitoringData{Timestamp: 10, CPU: ssh.CPUMetrics{LoadAverage: []float64{8, 2, 1}}})
+	if err := s.DeleteRule("load"); err != nil {
+		t.Fatalf("failed to delete rule: %v", err)
+	}
+
+	var states []string
+	for _, event := range *events {
+		states = append(states, event.State)
+	}
+	if !reflect.DeepEqual(states, []string{AlertFiring, AlertResolved}) {
+		t.Errorf("states = %v", states)
+	}
+}
+
+func TestAlertService_ForgetSession_KeepsWatchedHosts(t *testing.T) {
+	s, events := newTestAlertService(t, config.AlertRule{
+		ID: "disk", Enabled: true, Metric: "disk.partitions.*.used_percent", Comparator: ">", Threshold: 90,
+	})
+
+	s.Evaluate("s1", "root@web1:22", diskSnapshot(1000, 95))
+	s.Evaluate("s2", "root@web1:22", diskSnapshot(1010, 95))
+	s.ForgetSession("s1")
+
+	// s2 still watches the host, so its alert keeps firing without a second event
+	s.Evaluate("s2", "root@web1:22", diskSnapshot(1020, 95))
+	if len(*events) != 1 || len(s.ActiveAlerts()) != 1 {
+		t.Fatalf("events = %+v, want one firing event", *events)
+	}
+
+	// Without watchers the alert is resolved and fires anew when the host comes back
+	s.ForgetSession("s2")
+	if active := s.ActiveAlerts(); len(active) != 0 {
+		t.Errorf("active alerts = %+v, want none", active)
+	}
+	if len(*events) != 2 || (*events)[1].State != AlertResolved {
+		t.Fatalf("events = %+v, want a resolved event", *events)
+	}
+	s.Evaluate("s3", "root@web1:22", diskSnapshot(2000, 95))
+	if len(*events) != 3 || (*events)[2].State != AlertFiring {
+		t.Errorf("events = %+v, want a new firing event", *events)
+	}
+}
+
+func TestAlertService_Hooks_DeliverInOrder(t *testing.T) {
+	var mu sync.Mutex
+	var states []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event AlertEvent
+		json.NewDecoder(r.Body).Decode(&event)
+		if event.State == AlertFiring {
+			// A slow first request must not be overtaken by the next one
+			time.Sleep(100 * time.Millisecond)
+		}
+		mu.Lock()
+		states = append(states, event.State)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	s, _ := newTestAlertService(t, config.AlertRule{
+		ID: "load", Enabled: true, Metric: "cpu.load_average.0", Comparator: ">=", Threshold: 4,
+	})
+	s.SetHooks(server.URL, "")
+
+	s.Evaluate("s1", "root@db1:22", &ssh.MonitoringData{Timestamp: 10, CPU: ssh.CPUMetrics{LoadAverage: []float64{8, 2, 1}}})
+	s.Evaluate("s1", "root@db1:22", &ssh.MonitoringData{Timestamp: 20, CPU: ssh.CPUMetrics{LoadAverage: []float64{1, 2, 1}}})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		got := append([]string{}, states...)
+		mu.Unlock()
+		if len(got) == 2 || time.Now().After(deadline) {
+			if !reflect.DeepEqual(got, []string{AlertFiring, AlertResolved}) {
+				t.Errorf("webhook states = %v, want firing then resolved", got)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestResolveMetric(t *testing.T) {
+	values := map[string]any{
+		"cpu": map[string]any{"overall": 12.5, "per_core": []any{10.0, 15.0}},
+		"os":  "linux",
+	}
+
+	tests := []struct {
+		path string
+		want map[string]float64
+	}{
+		{"cpu.overall", map[string]float64{"": 12.5}},
+		{"cpu.per_core.1", map[string]float64{"": 15}},
+		{"cpu.per_core.*", map[string]float64{"0": 10, "1": 15}},
+		{"cpu.per_core.2", nil},
+		{"os", nil},
+		{"missing.path", nil},
+	}
+
+	for _, tt := range tests {
+		got := resolveMetric(values, strings.Split(tt.path, "."), "")
+		if len(got) != len(tt.want) || (len(tt.want) > 0 && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("resolveMetric(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"AHaSSHTools/internal/config"
+)
+
+// metricsExportMaxAge is how old a host's latest data may be and still be exported, so
+// hosts that went offline drop out instead of repeating their last values
+const metricsExportMaxAge = 10 * time.Minute
+
+// MetricsExporter exposes the latest collected metrics of every monitored host in the
+// Prometheus text format, for scraping by Prometheus or compatible agents
+type MetricsExporter struct {
+	monitorService *MonitorService
+	configManager  *config.ConfigManager
+}
+
+// NewMetricsExporter creates a new metrics exporter
+func NewMetricsExporter(ms *MonitorService, cm *config.ConfigManager) *MetricsExporter {
+	return &MetricsExporter{monitorService: ms, configManager: cm}
+}
+
+// Enabled reports whether exporting is turned on in the settings
+func (e *MetricsExporter) Enabled() bool {
+	return e.configManager.GetSettings().MonitorPrometheus
+}
+
+// Write writes the metrics of all hosts monitored in the last few minutes
+func (e *MetricsExporter) Write(w io.Writer) error {
+	cutoff := time.Now().Add(-metricsExportMaxAge).Unix()
+
+	var snapshots []MonitorSnapshot
+	for _, snapshot := range e.monitorService.LatestSnapshots() {
+		if snapshot.Data.Timestamp >= cutoff {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+
+	return writePrometheusMetrics(w, snapshots, func(target string) (config.ConnectionConfig, bool) {
+		return findConnection(e.configManager, target)
+	})
+}
+
+// promFamily is a metric with its samples from every host, which the text format
+// requires to be written together
+type promFamily struct {
+	name, kind, help string
+	samples          []string
+}
+
+// promWriter collects samples into families, keeping the order families were first seen
+type promWriter struct {
+	families []*promFamily
+	byName   map[string]*promFamily
+}
+
+// add records a sample; labels are the host's labels followed by extra name/value pairs
+func (p *promWriter) add(name, kind, help, labels string, value float64, extra ...string) {
+	family, ok := p.byName[name]
+	if !ok {
+		family = &promFamily{name: name, kind: kind, help: help}
+		p.byName[name] = family
+		p.families = append(p.families, family)
+	}
+
+	for i := 0; i+1 < len(extra); i += 2 {
+		labels += "," + promLabel(extra[i], extra[i+1])
+	}
+	family.samples = append(family.samples, fmt.Sprintf("%s{%s} %s", name, labels, strconv.FormatFloat(value, 'f', -1, 64)))
+}
+
+// writePrometheusMetrics renders snapshots in the Prometheus text exposition format. Each
+// host is labelled with its saved connection's name and tags, its host name and the
+// user@host:port it was reached at.
+func writePrometheusMetrics(w io.Writer, snapshots []MonitorSnapshot, lookup func(target string) (config.ConnectionConfig, bool)) error {
+	p := &promWriter{byName: make(map[string]*promFamily)}
+
+	for _, snapshot := range snapshots {
+		data := snapshot.Data
+
+		host := snapshot.Target
+		if at := strings.LastIndex(host, "@"); at >= 0 {
+			host = host[at+1:]
+		}
+		if colon := strings.LastIndex(host, ":"); colon >= 0 {
+			host = strings.Trim(host[:colon], "[]")
+		}
+		var name, tags string
+		if conn, ok := lookup(snapshot.Target); ok {
+			name = conn.Name
+			host = conn.Host
+			tags = strings.Join(conn.Tags, ",")
+		}
+		labels := strings.Join([]string{
+			promLabel("connection", name),
+			promLabel("host", host),
+			promLabel("tags", tags),
+			promLabel("target", snapshot.Target),
+		}, ",")
+
+		p.add("ahassh_system_info", "gauge", "Host details, always 1.", labels, 1,
+			"hostname", data.System.Hostname, "os", data.System.OS, "kernel", data.System.Kernel)
+		p.add("ahassh_last_collected_timestamp_seconds", "gauge", "When the metrics were collected.", labels, float64(data.Timestamp))
+		p.add("ahassh_processes", "gauge", "Number of processes.", labels, float64(data.System.Processes))
+
+		// CPU
+		p.add("ahassh_cpu_usage_percent", "gauge", "CPU usage across all cores.", labels, data.CPU.Overall)
+		for _, mode := range []struct {
+			name  string
+			value float64
+		}{
+			{"user", data.CPU.User},
+			{"system", data.CPU.System},
+			{"iowait", data.CPU.IOWait},
+			{"steal", data.CPU.Steal},
+			{"idle", data.CPU.Idle},
+		} {
+			p.add("ahassh_cpu_mode_percent", "gauge", "CPU time by mode.", labels, mode.value, "mode", mode.name)
+		}
+		for core, usage := range data.CPU.PerCore {
+			p.add("ahassh_cpu_core_usage_percent", "gauge", "CPU usage of a core.", labels, usage, "core", strconv.Itoa(core))
+		}
+		for i, period := range []string{"1m", "5m", "15m"} {
+			if i < len(data.CPU.LoadAverage) {
+				p.add("ahassh_load_average", "gauge", "System load average.", labels, data.CPU.LoadAverage[i], "period", period)
+			}
+		}
+
+		// Memory
+		p.add("ahassh_memory_total_bytes", "gauge", "Physical memory.", labels, float64(data.Memory.Total))
+		p.add("ahassh_memory_used_bytes", "gauge", "Memory in use, excluding caches.", labels, float64(data.Memory.Used))
+		p.add("ahassh_memory_free_bytes", "gauge", "Unused memory.", labels, float64(data.Memory.Free))
+		p.add("ahassh_memory_available_bytes", "gauge", "Memory available to applications.", labels, float64(data.Memory.Available))
+		p.add("ahassh_swap_total_bytes", "gauge", "Swap space.", labels, float64(data.Memory.SwapTotal))
+		p.add("ahassh_swap_used_bytes", "gauge", "Swap space in use.", labels, float64(data.Memory.SwapUsed))
+
+		// Network
+		for _, iface := range data.Network.Interfaces {
+			for _, counter := range []struct {
+				name, help string
+				value      uint64
+			}{
+				{"ahassh_network_receive_bytes_total", "Bytes received.", iface.RxBytes},
+				{"ahassh_network_transmit_bytes_total", "Bytes sent.", iface.TxBytes},
+				{"ahassh_network_receive_errors_total", "Receive errors.", iface.RxErrors},
+				{"ahassh_network_transmit_errors_total", "Transmit errors.", iface.TxErrors},
+				{"ahassh_network_receive_drop_total", "Received packets dropped.", iface.RxDropped},
+				{"ahassh_network_transmit_drop_total", "Outgoing packets dropped.", iface.TxDropped},
+			} {
+				p.add(counter.name, "counter", counter.help, labels, float64(counter.value), "interface", iface.Name)
+			}
+		}
+
+		// Filesystems
+		for _, partition := range data.Disk.Partitions {
+			mount := []string{"mountpoint", partition.MountPoint}
+			p.add("ahassh_filesystem_size_bytes", "gauge", "Filesystem size.", labels, float64(partition.Total), mount...)
+			p.add("ahassh_filesystem_used_bytes", "gauge", "Filesystem space used.", labels, float64(partition.Used), mount...)
+			p.add("ahassh_filesystem_avail_bytes", "gauge", "Filesystem space available to users.", labels, float64(partition.Free), mount...)
+			if partition.InodesTotal > 0 {
+				p.add("ahassh_filesystem_inodes", "gauge", "Inodes on the filesystem.", labels, float64(partition.InodesTotal), mount...)
+				p.add("ahassh_filesystem_inodes_used", "gauge", "Inodes in use.", labels, float64(partition.InodesUsed), mount...)
+			}
+		}
+
+		// Disk I/O
+		for _, device := range data.Disk.Devices {
+			for _, counter := range []struct {
+				name, help string
+				value      float64
+			}{
+				{"ahassh_disk_read_bytes_total", "Bytes read.", float64(device.ReadBytes)},
+				{"ahassh_disk_written_bytes_total", "Bytes written.", float64(device.WriteBytes)},
+				{"ahassh_disk_reads_completed_total", "Read requests completed.", float64(device.Reads)},
+				{"ahassh_disk_writes_completed_total", "Write requests completed.", float64(device.Writes)},
+				{"ahassh_disk_io_time_seconds_total", "Time spent doing I/O.", float64(device.BusyTime) / 1000},
+			} {
+				p.add(counter.name, "counter", counter.help, labels, counter.value, "device", device.Name)
+			}
+		}
+	}
+
+	buf := bufio.NewWriter(w)
+	for _, family := range p.families {
+		fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", family.name, family.help, family.name, family.kind)
+		for _, sample := range family.samples {
+			buf.WriteString(sample)
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Flush()
+}
+
+// promLabel formats a label pair, escaping the value as the text format requires
+func promLabel(name, value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return name + `="` + value + `"`
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"AHaSSHTools/internal/config"
+	"AHaSSHTools/internal/ssh"
+)
+
+func TestWritePrometheusMetrics(t *testing.T) {
+	snapshots := []MonitorSnapshot{
+		{
+			Target: "root@10.0.0.5:22",
+			Data: &ssh.MonitoringData{
+				Timestamp: 1712345678,
+				System:    ssh.SystemInfo{Hostname: "web1", OS: `Debian "12"`},
+				CPU:       ssh.CPUMetrics{Overall: 12.5, PerCore: []float64{10, 15}},
+				Network: ssh.NetworkMetrics{Interfaces: []ssh.InterfaceMetrics{
+					{Name: "eth0", RxBytes: 1234567890123},
+				}},
+				Disk: ssh.DiskMetrics{Partitions: []ssh.PartitionInfo{
+					{MountPoint: "/", Total: 1000, InodesTotal: 50, InodesUsed: 5},
+					{MountPoint: "/btrfs", Total: 2000},
+				}},
+			},
+		},
+		{
+			Target: "admin@[fd00::7]:2222",
+			Data:   &ssh.MonitoringData{Timestamp: 1712345679, CPU: ssh.CPUMetrics{Overall: 3}},
+		},
+	}
+	lookup := func(target string) (config.ConnectionConfig, bool) {
+		if target == "root@10.0.0.5:22" {
+			return config.ConnectionConfig{Name: "Web 1", Host: "10.0.0.5", Tags: []string{"prod", "eu"}}, true
+		}
+		return config.ConnectionConfig{}, false
+	}
+
+	var out strings.Builder
+	if err := writePrometheusMetrics(&out, snapshots, lookup); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	text := out.String()
+
+	web := `connection="Web 1",host="10.0.0.5",tags="prod,eu",target="root@10.0.0.5:22"`
+	adHoc := `connection="",host="fd00::7",tags="",target="admin@[fd00::7]:2222"`
+	for _, line := range []string{
+		"# HELP ahassh_cpu_usage_percent CPU usage across all cores.\n# TYPE ahassh_cpu_usage_percent gauge\n" +
+			"ahassh_cpu_usage_percent{" + web + "} 12.5\nahassh_cpu_usage_percent{" + adHoc + "} 3\n",
+		"ahassh_system_info{" + web + `,hostname="web1",os="Debian \"12\"",kernel=""} 1` + "\n",
+		"ahassh_cpu_core_usage_percent{" + web + `,core="1"} 15` + "\n",
+		"# TYPE ahassh_network_receive_bytes_total counter\n",
+		"ahassh_network_receive_bytes_total{" + web + `,interface="eth0"} 1234567890123` + "\n",
+		"ahassh_filesystem_inodes_used{" + web + `,mountpoint="/"} 5` + "\n",
+		"ahassh_last_collected_timestamp_seconds{" + adHoc + "} 1712345679\n",
+	} {
+		if !strings.Contains(text, line) {
+			t.Errorf("output lacks %q:\n%s", line, text)
+		}
+	}
+
+	// Filesystems without an inode table have no inode metrics, and each family is declared once
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "ahassh_filesystem_inodes") && strings.Contains(line, "/btrfs") {
+			t.Errorf("output has inode metrics for /btrfs: %s", line)
+		}
+	}
+	if n := strings.Count(text, "# TYPE ahassh_cpu_usage_percent "); n != 1 {
+		t.Errorf("cpu usage declared %d times", n)
+	}
+}
@@ -1,13 +1,44 @@
 package service
 
 import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"AHaSSHTools/internal/ssh"
+	"AHaSSHTools/internal/store"
+)
+
+const (
+	// historyCapacity is how many snapshots are kept in memory per session,
+	// two hours at the default refresh interval
+	historyCapacity = 3600
+
+	// historyMaxPoints is how many points a history query returns when no step is given
+	historyMaxPoints = 300
 )
 
+// DiskUsageCallback is a callback function for disk usage scan progress
+type DiskUsageCallback func(progress ssh.DiskUsageProgress)
+
 // MonitorService handles system monitoring operations
 type MonitorService struct {
 	sessionManager   *ssh.SessionManager
 	monitorCollector *ssh.MonitorCollector
+
+	scanMu sync.Mutex
+	scans  map[string]context.CancelFunc // scanID -> cancel
+
+	historyMu      sync.Mutex
+	histories      map[string]*ssh.MetricsHistory // sessionID -> recent snapshots
+	historySeries  map[string]string              // sessionID -> "user@host:port" the history is stored under
+	metricsStore   *store.MetricsStore
+	persistHistory atomic.Bool
+
+	alerts *AlertService // Evaluates alert rules against collected data, if set
 }
 
 // NewMonitorService creates a new monitor service
@@ -15,10 +46,311 @@ func NewMonitorService(sm *ssh.SessionManager) *MonitorService {
 	return &MonitorService{
 		sessionManager:   sm,
 		monitorCollector: ssh.NewMonitorCollector(sm),
+		scans:            make(map[string]context.CancelFunc),
+		histories:        make(map[string]*ssh.MetricsHistory),
+		historySeries:    make(map[string]string),
+		metricsStore:     store.NewMetricsStore(),
 	}
 }
 
-// GetMonitoringData retrieves monitoring data for a session
+// GetMonitoringData retrieves monitoring data for a session and records it in its history
 func (s *MonitorService) GetMonitoringData(sessionID string) (*ssh.MonitoringData, error) {
-	return s.monitorCollector.CollectMetrics(sessionID)
+	data, err := s.monitorCollector.CollectMetrics(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.record(sessionID, data)
+	return data, nil
+}
+
+// CollectClientMetrics collects metrics over a headless client and records them under
+// key as a session's are, so history and alerts work the same
+func (s *MonitorService) CollectClientMetrics(key string, client *ssh.Client) (*ssh.MonitoringData, error) {
+	data, err := s.monitorCollector.CollectClientMetrics(key, client)
+	if err != nil {
+		return nil, err
+	}
+
+	s.historyMu.Lock()
+	s.historySeries[key] = client.User() + "@" + client.Address()
+	s.historyMu.Unlock()
+
+	s.record(key, data)
+	return data, nil
+}
+
+// record adds a snapshot to the history and evaluates the alert rules against it
+func (s *MonitorService) record(key string, data *ssh.MonitoringData) {
+	series := s.recordHistory(key, data)
+	if s.alerts != nil {
+		s.alerts.Evaluate(key, series, data)
+	}
+}
+
+// SetAlertService sets the service alert rules are evaluated by
+func (s *MonitorService) SetAlertService(alerts *AlertService) {
+	s.alerts = alerts
+}
+
+// ForgetSession drops the samples kept to compute rates and the in-memory history of a
+// closed session; history persisted on disk is kept
+func (s *MonitorService) ForgetSession(sessionID string) {
+	s.monitorCollector.Forget(sessionID)
+
+	s.historyMu.Lock()
+	delete(s.histories, sessionID)
+	delete(s.historySeries, sessionID)
+	s.historyMu.Unlock()
+
+	if s.alerts != nil {
+		s.alerts.ForgetSession(sessionID)
+	}
+}
+
+// SetHistoryPersistence turns on-disk metrics history on or off and sets how long it is kept
+func (s *MonitorService) SetHistoryPersistence(enabled bool, retention time.Duration) {
+	s.metricsStore.SetRetention(retention)
+	if s.persistHistory.Swap(enabled) && !enabled {
+		if err := s.metricsStore.Flush(); err != nil {
+			fmt.Printf("Failed to write metrics history: %v\n", err)
+		}
+	}
+}
+
+// FlushHistory writes buffered on-disk history out, before the application exits
+func (s *MonitorService) FlushHistory() error {
+	return s.metricsStore.Flush()
+}
+
+// recordHistory adds a snapshot to the session's ring and, if enabled, the on-disk store.
+// Returns the series the session's history is stored under.
+func (s *MonitorService) recordHistory(sessionID string, data *ssh.MonitoringData) string {
+	s.historyMu.Lock()
+	history, ok := s.histories[sessionID]
+	if !ok {
+		history = ssh.NewMetricsHistory(historyCapacity)
+		s.histories[sessionID] = history
+	}
+	series, ok := s.historySeries[sessionID]
+	if !ok {
+		series = s.seriesOf(sessionID)
+		s.historySeries[sessionID] = series
+	}
+	s.historyMu.Unlock()
+
+	history.Add(data)
+
+	if series != "" && s.persistHistory.Load() {
+		if err := s.metricsStore.Add(series, ssh.NewMetricsPoint(data)); err != nil {
+			fmt.Printf("Failed to write metrics history: %v\n", err)
+		}
+	}
+	return series
+}
+
+// seriesOf names the on-disk history of a session after the account it is logged in to,
+// so history carries over between sessions; local sessions have none
+func (s *MonitorService) seriesOf(sessionID string) string {
+	client, err := s.sessionManager.GetClient(sessionID)
+	if err != nil {
+		return ""
+	}
+	return client.User() + "@" + client.Address()
+}
+
+// QueryHistory returns a session's metrics between from and to (Unix seconds), averaged
+// into points step seconds apart. to defaults to now and from to an hour before it; a
+// step of 0 picks one giving at most a few hundred points for charts.
+// The in-memory history is used where it reaches back far enough, the on-disk store before that.
+func (s *MonitorService) QueryHistory(sessionID string, from, to, step int64) (*ssh.MetricsRange, error) {
+	if to <= 0 {
+		to = time.Now().Unix()
+	}
+	if from <= 0 {
+		from = to - 3600
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid range: %d > %d", from, to)
+	}
+	if step <= 0 {
+		step = max((to-from+historyMaxPoints-1)/historyMaxPoints, 1)
+	}
+
+	s.historyMu.Lock()
+	history := s.histories[sessionID]
+	series, ok := s.historySeries[sessionID]
+	s.historyMu.Unlock()
+	if !ok {
+		series = s.seriesOf(sessionID)
+	}
+
+	var points []ssh.MetricsPoint
+	memoryFrom := to + 1
+	if history != nil {
+		for _, data := range history.Snapshots(from, to) {
+			points = append(points, ssh.NewMetricsPoint(data))
+		}
+		if oldest := history.Oldest(); oldest > 0 {
+			memoryFrom = max(oldest, from)
+		}
+	}
+
+	if series != "" && s.persistHistory.Load() && from < memoryFrom {
+		stored, err := s.metricsStore.Query(series, from, memoryFrom-1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read metrics history: %w", err)
+		}
+		points = append(points, stored...)
+	}
+
+	return &ssh.MetricsRange{
+		From:   from,
+		To:     to,
+		Step:   step,
+		Points: ssh.DownsamplePoints(points, step),
+	}, nil
+}
+
+// MonitorSnapshot is the latest data collected from a host
+type MonitorSnapshot struct {
+	SessionID string
+	Target    string // user@host:port
+	Data      *ssh.MonitoringData
+}
+
+// LatestSnapshots returns the latest data of each remote host being monitored, by a
+// session or in the background. A host monitored several times over is listed once, with
+// its freshest data.
+func (s *MonitorService) LatestSnapshots() []MonitorSnapshot {
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+
+	latest := make(map[string]MonitorSnapshot)
+	for sessionID, history := range s.histories {
+		target := s.historySeries[sessionID]
+		data := history.Latest()
+		if target == "" || data == nil {
+			continue
+		}
+		if current, ok := latest[target]; ok && current.Data.Timestamp >= data.Timestamp {
+			continue
+		}
+		latest[target] = MonitorSnapshot{SessionID: sessionID, Target: target, Data: data}
+	}
+
+	snapshots := make([]MonitorSnapshot, 0, len(latest))
+	for _, snapshot := range latest {
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Target < snapshots[j].Target })
+	return snapshots
+}
+
+// ScanDiskUsage starts a disk usage scan below opts.Path
+// Progress and the final size tree are delivered through progressCallback; returns scanID for cancellation
+func (s *MonitorService) ScanDiskUsage(sessionID string, opts ssh.DiskUsageOptions, progressCallback DiskUsageCallback) (string, error) {
+	sftpClient, err := s.sessionManager.GetOrCreateSFTPClient(sessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get SFTP client: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.scanMu.Lock()
+	scanID := fmt.Sprintf("du_%d_%d", time.Now().UnixNano(), len(s.scans))
+	s.scans[scanID] = cancel
+	s.scanMu.Unlock()
+
+	go func() {
+		defer func() {
+			s.scanMu.Lock()
+			delete(s.scans, scanID)
+			s.scanMu.Unlock()
+			cancel()
+		}()
+
+		result, err := sftpClient.ScanDiskUsage(ctx, opts, func(progress ssh.DiskUsageProgress) {
+			progress.ScanID = scanID
+			if progressCallback != nil {
+				progressCallback(progress)
+			}
+		})
+		if progressCallback == nil {
+			return
+		}
+
+		final := ssh.DiskUsageProgress{ScanID: scanID, Path: opts.Path, Done: true, Result: result}
+		if err != nil && ctx.Err() == nil {
+			final.Error = err.Error()
+		}
+		progressCallback(final)
+	}()
+
+	return scanID, nil
+}
+
+// ScanPartition starts a disk usage scan of a partition reported by GetMonitoringData,
+// staying on that filesystem so other mounts below it are not counted
+func (s *MonitorService) ScanPartition(sessionID string, partition ssh.PartitionInfo, topN int, progressCallback DiskUsageCallback) (string, error) {
+	if partition.MountPoint == "" {
+		return "", fmt.Errorf("partition has no mount point")
+	}
+
+	return s.ScanDiskUsage(sessionID, ssh.DiskUsageOptions{
+		Path:          partition.MountPoint,
+		TopN:          topN,
+		OneFileSystem: true,
+	}, progressCallback)
+}
+
+// CancelDiskUsageScan stops a running disk usage scan
+func (s *MonitorService) CancelDiskUsageScan(scanID string) error {
+	s.scanMu.Lock()
+	cancel, exists := s.scans[scanID]
+	s.scanMu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("disk usage scan not found: %s", scanID)
+	}
+
+	cancel()
+	return nil
+}
+
+// ListProcesses returns the top processes of a session's host by CPU or memory use
+func (s *MonitorService) ListProcesses(sessionID string, opts ssh.ProcessListOptions) (*ssh.ProcessList, error) {
+	client, err := s.sessionManager.GetClient(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return client.ListProcesses(context.Background(), opts)
+}
+
+// ListSockets returns the TCP and UDP sockets of a session's host with their processes
+func (s *MonitorService) ListSockets(sessionID string, opts ssh.SocketListOptions, sudo ssh.SudoOptions) (*ssh.SocketList, error) {
+	client, err := s.sessionManager.GetClient(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return client.ListSockets(context.Background(), opts, sudo)
+}
+
+// SignalProcess sends a signal to a process; a result wrapping ssh.ErrSudoRequired
+// means it may succeed with sudo
+func (s *MonitorService) SignalProcess(sessionID string, pid int, signal string, sudo ssh.SudoOptions) error {
+	client, err := s.sessionManager.GetClient(sessionID)
+	if err != nil {
+		return err
+	}
+	return client.SignalProcess(context.Background(), pid, signal, sudo)
+}
+
+// ReniceProcess changes a process's nice value
+func (s *MonitorService) ReniceProcess(sessionID string, pid, nice int, sudo ssh.SudoOptions) error {
+	client, err := s.sessionManager.GetClient(sessionID)
+	if err != nil {
+		return err
+	}
+	return client.ReniceProcess(context.Background(), pid, nice, sudo)
 }
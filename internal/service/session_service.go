@@ -101,6 +101,21 @@ func (s *SessionService) GetSFTPClient(sessionID string) (*ssh.SFTPClient, error
 	return s.sessionManager.GetOrCreateSFTPClient(sessionID)
 }
 
+// StartPortForward forwards a local port to a host and port reached from a session's server
+func (s *SessionService) StartPortForward(sessionID string, opts ssh.PortForwardOptions) (*ssh.PortForward, error) {
+	return s.sessionManager.StartPortForward(sessionID, opts)
+}
+
+// StopPortForward stops a port forward of a session, or of any session if sessionID is empty
+func (s *SessionService) StopPortForward(sessionID, forwardID string) error {
+	return s.sessionManager.StopPortForward(sessionID, forwardID)
+}
+
+// ListPortForwards returns the port forwards of a session, or of all sessions if sessionID is empty
+func (s *SessionService) ListPortForwards(sessionID string) []ssh.PortForward {
+	return s.sessionManager.ListPortForwards(sessionID)
+}
+
 // ConnectLocalShell creates and starts a local shell session
 func (s *SessionService) ConnectLocalShell(sessionID string, shellType string, cols, rows int, outputCallback OutputCallback) error {
 	_, err := s.sessionManager.CreateLocalSession(sessionID, shellType)
@@ -1,8 +1,16 @@
 package service
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"AHaSSHTools/internal/ssh"
 )
@@ -10,10 +18,43 @@ import (
 // ProgressCallback is a callback function for transfer progress
 type ProgressCallback func(progress ssh.TransferProgress)
 
+// SearchPageCallback is a callback function for file search result pages
+type SearchPageCallback func(page ssh.FileSearchPage)
+
+// TailCallback is a callback function for lines from a followed file
+type TailCallback func(event ssh.TailEvent)
+
+// WatchCallback is a callback function for directory change events
+type WatchCallback func(event ssh.DirWatchEvent)
+
+// TailInfo describes a running file tail
+type TailInfo struct {
+	TailID    string `json:"tail_id"`
+	SessionID string `json:"session_id"`
+	Path      string `json:"path"`
+
+	cancel context.CancelFunc
+}
+
 // SFTPService handles SFTP file operations
 type SFTPService struct {
 	sessionManager  *ssh.SessionManager
 	transferManager *ssh.TransferManager
+
+	searchMu sync.Mutex
+	searches map[string]context.CancelFunc // searchID -> cancel
+
+	tailMu sync.Mutex
+	tails  map[string]*TailInfo // tailID -> tail
+
+	watchMu sync.Mutex
+	watches map[string]string // watchID -> sessionID
+
+	ftpMu      sync.Mutex
+	ftpClients map[string]*ssh.FTPClient // connectionID -> client
+
+	trashEnabled atomic.Bool
+	trashMaxAge  atomic.Int64 // Auto-purge age as a time.Duration, 0 = keep forever
 }
 
 // NewSFTPService creates a new SFTP service
@@ -21,17 +62,119 @@ func NewSFTPService(sm *ssh.SessionManager, tm *ssh.TransferManager) *SFTPServic
 	return &SFTPService{
 		sessionManager:  sm,
 		transferManager: tm,
+		searches:        make(map[string]context.CancelFunc),
+		tails:           make(map[string]*TailInfo),
+		watches:         make(map[string]string),
+		ftpClients:      make(map[string]*ssh.FTPClient),
+	}
+}
+
+// fileSystem resolves the backend behind an ID: the local disk, an FTP connection or an SSH session's SFTP client
+func (s *SFTPService) fileSystem(id string) (ssh.FileSystem, error) {
+	if id == ssh.LocalFileSystemID {
+		return ssh.NewLocalFileSystem(), nil
+	}
+
+	s.ftpMu.Lock()
+	ftpClient, ok := s.ftpClients[id]
+	s.ftpMu.Unlock()
+	if ok {
+		return ftpClient, nil
+	}
+
+	sftpClient, err := s.sessionManager.GetOrCreateSFTPClient(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SFTP client: %w", err)
+	}
+	return sftpClient, nil
+}
+
+// fileTransferer returns what uploads and downloads single files for a session ID,
+// an FTP connection ID or ssh.LocalFileSystemID. SSH sessions fall back to SCP when
+// the server has no SFTP subsystem.
+func (s *SFTPService) fileTransferer(id string) (ssh.FileTransferer, error) {
+	s.ftpMu.Lock()
+	_, isFTP := s.ftpClients[id]
+	s.ftpMu.Unlock()
+
+	if id != ssh.LocalFileSystemID && !isFTP {
+		transferer, err := s.sessionManager.GetFileTransferer(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get SFTP client: %w", err)
+		}
+		return transferer, nil
+	}
+
+	fs, err := s.fileSystem(id)
+	if err != nil {
+		return nil, err
+	}
+	return ssh.NewFileSystemTransferer(fs), nil
+}
+
+// ConnectFTP connects to an FTP or FTPS server
+// Returns a connection ID usable wherever the file manager takes a session ID
+func (s *SFTPService) ConnectFTP(cfg ssh.FTPConfig) (string, error) {
+	client, err := ssh.NewFTPClient(&cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to FTP server: %w", err)
+	}
+
+	s.ftpMu.Lock()
+	defer s.ftpMu.Unlock()
+
+	connectionID := fmt.Sprintf("ftp_%d", time.Now().UnixNano())
+	s.ftpClients[connectionID] = client
+	return connectionID, nil
+}
+
+// DisconnectFTP closes an FTP connection
+func (s *SFTPService) DisconnectFTP(connectionID string) error {
+	s.ftpMu.Lock()
+	client, ok := s.ftpClients[connectionID]
+	delete(s.ftpClients, connectionID)
+	s.ftpMu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("FTP connection not found: %s", connectionID)
 	}
+	return client.Close()
 }
 
 // ListFiles lists files in a directory
 func (s *SFTPService) ListFiles(sessionID string, path string) ([]ssh.FileInfo, error) {
-	sftpClient, err := s.sessionManager.GetOrCreateSFTPClient(sessionID)
+	fs, err := s.fileSystem(sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get SFTP client: %w", err)
+		return nil, err
+	}
+
+	return fs.ListDirectory(path)
+}
+
+// ListFilesPage lists one sorted page of a directory, for directories too large to list at once
+func (s *SFTPService) ListFilesPage(sessionID string, path string, opts ssh.ListOptions) (*ssh.DirectoryPage, error) {
+	fs, err := s.fileSystem(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// SFTP caches listings between pages; other backends list the whole directory each time
+	if sftpClient, ok := fs.(*ssh.SFTPClient); ok {
+		return sftpClient.ListDirectoryPage(context.Background(), path, opts)
+	}
+
+	files, err := fs.ListDirectory(path)
+	if err != nil {
+		return nil, err
 	}
+	return ssh.PageFiles(path, files, opts), nil
+}
 
-	return sftpClient.ListDirectory(path)
+// invalidateListings drops cached listings after a change made by a command on the server
+func (s *SFTPService) invalidateListings(sessionID string, paths ...string) {
+	if sftpClient, err := s.sessionManager.GetOrCreateSFTPClient(sessionID); err == nil {
+		sftpClient.InvalidateListings(paths...)
+	}
 }
 
 // ChangeDirectory changes the current working directory for a session
@@ -57,20 +200,20 @@ func (s *SFTPService) UpdateCurrentPath(sessionID, path string) error {
 
 // GetFileInfo gets information about a file
 func (s *SFTPService) GetFileInfo(sessionID string, path string) (*ssh.FileInfo, error) {
-	sftpClient, err := s.sessionManager.GetOrCreateSFTPClient(sessionID)
+	fs, err := s.fileSystem(sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get SFTP client: %w", err)
+		return nil, err
 	}
 
-	return sftpClient.GetFileInfo(path)
+	return fs.GetFileInfo(path)
 }
 
-// UploadFile uploads a single file
+// UploadFile uploads a single file to an SSH session, FTP connection or the local disk
 // Returns transferID for progress tracking
 func (s *SFTPService) UploadFile(sessionID string, localPath string, remotePath string, progressCallback ProgressCallback) (string, error) {
-	sftpClient, err := s.sessionManager.GetOrCreateSFTPClient(sessionID)
+	transferer, err := s.fileTransferer(sessionID)
 	if err != nil {
-		return "", fmt.Errorf("failed to get SFTP client: %w", err)
+		return "", err
 	}
 
 	// Extract filename from local path and append to remote directory
@@ -101,8 +244,8 @@ func (s *SFTPService) UploadFile(sessionID string, localPath string, remotePath
 		}
 
 		// Perform upload
-		err := sftpClient.UploadFile(localPath, remoteFilePath, progressCb)
-		if err != nil {
+		err := transferer.UploadFile(transfer.Context(), localPath, remoteFilePath, progressCb, transfer.Limiters()...)
+		if err != nil && !transfer.IsCancelled() {
 			// Report error
 			errorProgress := ssh.TransferProgress{
 				TransferID: transfer.ID,
@@ -142,12 +285,12 @@ func (s *SFTPService) UploadFiles(sessionID string, localPaths []string, remoteP
 	return transferIDs, nil
 }
 
-// DownloadFile downloads a single file
+// DownloadFile downloads a single file from an SSH session, FTP connection or the local disk
 // Returns transferID for progress tracking
 func (s *SFTPService) DownloadFile(sessionID string, remotePath string, localPath string, progressCallback ProgressCallback) (string, error) {
-	sftpClient, err := s.sessionManager.GetOrCreateSFTPClient(sessionID)
+	transferer, err := s.fileTransferer(sessionID)
 	if err != nil {
-		return "", fmt.Errorf("failed to get SFTP client: %w", err)
+		return "", err
 	}
 
 	// Extract filename from remote path and append to local directory
@@ -178,8 +321,8 @@ func (s *SFTPService) DownloadFile(sessionID string, remotePath string, localPat
 		}
 
 		// Perform download
-		err := sftpClient.DownloadFile(remotePath, localFilePath, progressCb)
-		if err != nil {
+		err := transferer.DownloadFile(transfer.Context(), remotePath, localFilePath, progressCb, transfer.Limiters()...)
+		if err != nil && !transfer.IsCancelled() {
 			// Report error
 			errorProgress := ssh.TransferProgress{
 				TransferID: transfer.ID,
@@ -219,54 +362,491 @@ func (s *SFTPService) DownloadFiles(sessionID string, remotePaths []string, loca
 	return transferIDs, nil
 }
 
-// DeleteFile deletes a single file or directory
-func (s *SFTPService) DeleteFile(sessionID string, path string) error {
+// RelayFiles copies files or directories from one session's server to another's
+// Each source path gets its own transfer; in direct mode a single transfer runs scp/rsync on the source host
+func (s *SFTPService) RelayFiles(sourceSessionID string, sourcePaths []string, targetSessionID string, targetPath string, opts ssh.RelayOptions, progressCallback ProgressCallback) ([]string, error) {
+	if opts.Direct {
+		transferID, err := s.relayDirect(sourceSessionID, sourcePaths, targetSessionID, targetPath, opts, progressCallback)
+		if err != nil {
+			return nil, err
+		}
+		return []string{transferID}, nil
+	}
+
+	transferIDs := make([]string, 0, len(sourcePaths))
+
+	for _, sourcePath := range sourcePaths {
+		transferID, err := s.RelayFile(sourceSessionID, sourcePath, targetSessionID, targetPath, progressCallback)
+		if err != nil {
+			return transferIDs, err
+		}
+		transferIDs = append(transferIDs, transferID)
+	}
+
+	return transferIDs, nil
+}
+
+// RelayFile copies a single file or directory between two sessions, streaming through this machine
+// Returns transferID for progress tracking
+func (s *SFTPService) RelayFile(sourceSessionID string, sourcePath string, targetSessionID string, targetPath string, progressCallback ProgressCallback) (string, error) {
+	sourceClient, err := s.sessionManager.GetOrCreateSFTPClient(sourceSessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get source SFTP client: %w", err)
+	}
+
+	targetClient, err := s.sessionManager.GetOrCreateSFTPClient(targetSessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get target SFTP client: %w", err)
+	}
+
+	// Copy into the target directory under the same name
+	sourceName := path.Base(sourcePath)
+	targetFilePath := path.Join(targetPath, sourceName)
+
+	transfer, err := s.transferManager.StartRelayTransfer(sourceSessionID, targetSessionID, []string{sourcePath})
+	if err != nil {
+		return "", fmt.Errorf("failed to start transfer: %w", err)
+	}
+
+	s.runTransfer(transfer, sourceName, progressCallback, func(ctx context.Context, progressCb func(ssh.TransferProgress)) error {
+		return ssh.RelayPath(ctx, sourceClient, targetClient, sourcePath, targetFilePath, progressCb, transfer.Limiters()...)
+	})
+
+	return transfer.ID, nil
+}
+
+// CopyFiles copies files or directories between any two filesystems: SSH sessions,
+// FTP connections or the local disk (ssh.LocalFileSystemID), streaming through this machine
+// Each source path gets its own transfer
+func (s *SFTPService) CopyFiles(sourceID string, sourcePaths []string, targetID string, targetPath string, progressCallback ProgressCallback) ([]string, error) {
+	source, err := s.fileSystem(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	// Use the same backend for both sides so CopyPath can refuse copies onto the source
+	target := source
+	if targetID != sourceID {
+		if target, err = s.fileSystem(targetID); err != nil {
+			return nil, err
+		}
+	}
+
+	transferIDs := make([]string, 0, len(sourcePaths))
+
+	for _, sourcePath := range sourcePaths {
+		// Copy into the target directory under the same name
+		sourceName := path.Base(filepath.ToSlash(sourcePath))
+		targetFilePath := path.Join(targetPath, sourceName)
+
+		transfer, err := s.transferManager.StartRelayTransfer(sourceID, targetID, []string{sourcePath})
+		if err != nil {
+			return transferIDs, fmt.Errorf("failed to start transfer: %w", err)
+		}
+
+		s.runTransfer(transfer, sourceName, progressCallback, func(ctx context.Context, progressCb func(ssh.TransferProgress)) error {
+			return ssh.CopyPath(ctx, source, target, sourcePath, targetFilePath, progressCb, transfer.Limiters()...)
+		})
+		transferIDs = append(transferIDs, transfer.ID)
+	}
+
+	return transferIDs, nil
+}
+
+// relayDirect starts a direct scp/rsync transfer on the source host
+func (s *SFTPService) relayDirect(sourceSessionID string, sourcePaths []string, targetSessionID string, targetPath string, opts ssh.RelayOptions, progressCallback ProgressCallback) (string, error) {
+	if len(sourcePaths) == 0 {
+		return "", fmt.Errorf("no source paths")
+	}
+
+	source, err := s.sessionManager.GetSession(sourceSessionID)
+	if err != nil {
+		return "", err
+	}
+	target, err := s.sessionManager.GetSession(targetSessionID)
+	if err != nil {
+		return "", err
+	}
+	if source.Client == nil || target.Client == nil {
+		return "", fmt.Errorf("direct transfer requires two SSH sessions")
+	}
+
+	transfer, err := s.transferManager.StartRelayTransfer(sourceSessionID, targetSessionID, sourcePaths)
+	if err != nil {
+		return "", fmt.Errorf("failed to start transfer: %w", err)
+	}
+
+	s.runTransfer(transfer, bulkDisplayName(sourcePaths), progressCallback, func(ctx context.Context, progressCb func(ssh.TransferProgress)) error {
+		defer s.invalidateListings(targetSessionID, targetPath)
+		return ssh.DirectRelay(ctx, source.Client, target.Client, sourcePaths, targetPath, opts, progressCb)
+	})
+
+	return transfer.ID, nil
+}
+
+// CompressFiles packs remote paths into an archive on the server ("tar.gz" or "zip")
+// Returns transferID for progress tracking
+func (s *SFTPService) CompressFiles(sessionID string, remotePaths []string, archivePath string, format string, progressCallback ProgressCallback) (string, error) {
+	client, err := s.sshClient(sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	transfer, err := s.transferManager.StartTransfer(sessionID, "compress", remotePaths)
+	if err != nil {
+		return "", fmt.Errorf("failed to start transfer: %w", err)
+	}
+
+	s.runTransfer(transfer, path.Base(archivePath), progressCallback, func(ctx context.Context, progressCb func(ssh.TransferProgress)) error {
+		defer s.invalidateListings(sessionID, archivePath)
+		return client.CreateArchive(ctx, remotePaths, archivePath, format, progressCb)
+	})
+
+	return transfer.ID, nil
+}
+
+// ExtractArchive extracts a remote archive into destDir (next to the archive when empty)
+// Returns transferID for progress tracking
+func (s *SFTPService) ExtractArchive(sessionID string, archivePath string, destDir string, progressCallback ProgressCallback) (string, error) {
+	client, err := s.sshClient(sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	transfer, err := s.transferManager.StartTransfer(sessionID, "extract", []string{archivePath})
+	if err != nil {
+		return "", fmt.Errorf("failed to start transfer: %w", err)
+	}
+
+	s.runTransfer(transfer, path.Base(archivePath), progressCallback, func(ctx context.Context, progressCb func(ssh.TransferProgress)) error {
+		// An empty destDir extracts next to the archive, which the archive path already covers
+		defer s.invalidateListings(sessionID, archivePath, cmp.Or(destDir, archivePath))
+		return client.ExtractArchive(ctx, archivePath, destDir, progressCb)
+	})
+
+	return transfer.ID, nil
+}
+
+// DownloadDirectoryArchive downloads a remote directory into localPath as a single <name>.tar.gz
+// The archive is built by tar on the server when available, otherwise from files read over SFTP
+// Returns transferID for progress tracking
+func (s *SFTPService) DownloadDirectoryArchive(sessionID string, remoteDir string, localPath string, progressCallback ProgressCallback) (string, error) {
+	client, err := s.sshClient(sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	archiveName := path.Base(remoteDir) + ".tar.gz"
+	localFilePath := filepath.Join(localPath, archiveName)
+
+	transfer, err := s.transferManager.StartTransfer(sessionID, "download", []string{remoteDir})
+	if err != nil {
+		return "", fmt.Errorf("failed to start transfer: %w", err)
+	}
+
+	s.runTransfer(transfer, archiveName, progressCallback, func(ctx context.Context, progressCb func(ssh.TransferProgress)) error {
+		localFile, err := os.Create(localFilePath)
+		if err != nil {
+			return fmt.Errorf("failed to create local file: %w", err)
+		}
+
+		err = client.StreamDirectoryArchive(ctx, remoteDir, localFile, progressCb, transfer.Limiters()...)
+		localFile.Close()
+
+		// Fall back to building the archive locally over SFTP only when the server has no tar
+		var toolErr *ssh.ToolNotFoundError
+		if errors.As(err, &toolErr) {
+			// The SFTP subsystem is only needed here, so servers without it can still use tar
+			sftpClient, sftpErr := s.sessionManager.GetOrCreateSFTPClient(sessionID)
+			if sftpErr != nil {
+				err = fmt.Errorf("failed to get SFTP client: %w", sftpErr)
+			} else {
+				err = sftpClient.DownloadDirectoryArchive(ctx, remoteDir, localFilePath, progressCb, transfer.Limiters()...)
+			}
+		}
+		if err != nil {
+			// Don't leave a truncated archive behind
+			os.Remove(localFilePath)
+		}
+		return err
+	})
+
+	return transfer.ID, nil
+}
+
+// DownloadFilesBulk downloads files and directories as one transfer, streaming a remote tar
+// to avoid per-file round trips; falls back to plain SFTP when tar is not available
+// Returns transferID for progress tracking
+func (s *SFTPService) DownloadFilesBulk(sessionID string, remotePaths []string, localPath string, progressCallback ProgressCallback) (string, error) {
+	client, err := s.sshClient(sessionID)
+	if err != nil {
+		return "", err
+	}
+
 	sftpClient, err := s.sessionManager.GetOrCreateSFTPClient(sessionID)
 	if err != nil {
-		return fmt.Errorf("failed to get SFTP client: %w", err)
+		return "", fmt.Errorf("failed to get SFTP client: %w", err)
 	}
 
-	// Check if it's a directory
-	fileInfo, err := sftpClient.GetFileInfo(path)
+	transfer, err := s.transferManager.StartTransfer(sessionID, "download", remotePaths)
 	if err != nil {
-		return fmt.Errorf("failed to get file info: %w", err)
+		return "", fmt.Errorf("failed to start transfer: %w", err)
+	}
+
+	s.runTransfer(transfer, bulkDisplayName(remotePaths), progressCallback, func(ctx context.Context, progressCb func(ssh.TransferProgress)) error {
+		err := client.DownloadTar(ctx, remotePaths, localPath, progressCb, transfer.Limiters()...)
+
+		var toolErr *ssh.ToolNotFoundError
+		if errors.As(err, &toolErr) {
+			return sftpClient.DownloadTree(ctx, remotePaths, localPath, progressCb, transfer.Limiters()...)
+		}
+		return err
+	})
+
+	return transfer.ID, nil
+}
+
+// UploadFilesBulk uploads files and directories as one transfer, piping a local tar into
+// tar on the server; falls back to plain SFTP when tar is not available
+// Returns transferID for progress tracking
+func (s *SFTPService) UploadFilesBulk(sessionID string, localPaths []string, remotePath string, progressCallback ProgressCallback) (string, error) {
+	client, err := s.sshClient(sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	sftpClient, err := s.sessionManager.GetOrCreateSFTPClient(sessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get SFTP client: %w", err)
+	}
+
+	transfer, err := s.transferManager.StartTransfer(sessionID, "upload", localPaths)
+	if err != nil {
+		return "", fmt.Errorf("failed to start transfer: %w", err)
+	}
+
+	s.runTransfer(transfer, bulkDisplayName(localPaths), progressCallback, func(ctx context.Context, progressCb func(ssh.TransferProgress)) error {
+		// The tar stream bypasses SFTP, so its cached listings are stale even after a partial upload
+		defer s.invalidateListings(sessionID, remotePath)
+		err := client.UploadTar(ctx, localPaths, remotePath, progressCb, transfer.Limiters()...)
+
+		var toolErr *ssh.ToolNotFoundError
+		if errors.As(err, &toolErr) {
+			return sftpClient.UploadTree(ctx, localPaths, remotePath, progressCb, transfer.Limiters()...)
+		}
+		return err
+	})
+
+	return transfer.ID, nil
+}
+
+// bulkDisplayName names a multi-path transfer after its first path
+func bulkDisplayName(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	name := filepath.Base(paths[0])
+	if len(paths) > 1 {
+		name = fmt.Sprintf("%s (+%d)", name, len(paths)-1)
+	}
+	return name
+}
+
+// sshClient returns the SSH client behind a session, for operations that need exec channels
+func (s *SFTPService) sshClient(sessionID string) (*ssh.Client, error) {
+	managed, err := s.sessionManager.GetSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if managed.Client == nil {
+		return nil, fmt.Errorf("session is not an SSH session: %s", sessionID)
 	}
+	return managed.Client, nil
+}
+
+// runTransfer runs fn in the background, recording its progress under the transfer and
+// reporting a failure unless the transfer was cancelled
+func (s *SFTPService) runTransfer(transfer *ssh.TransferContext, filename string, progressCallback ProgressCallback, fn func(ctx context.Context, progressCb func(ssh.TransferProgress)) error) {
+	progressCb := func(progress ssh.TransferProgress) {
+		progress.TransferID = transfer.ID
+		progress.SessionID = transfer.SessionID
+		progress.TargetSessionID = transfer.TargetSessionID
+		if progress.Filename == "" {
+			progress.Filename = filename
+		}
+
+		// Update transfer manager
+		s.transferManager.UpdateProgress(transfer.ID, progress)
 
-	if fileInfo.IsDir {
-		return sftpClient.DeleteDirectory(path)
+		// Call external callback
+		if progressCallback != nil {
+			progressCallback(progress)
+		}
 	}
 
-	return sftpClient.DeleteFile(path)
+	go func() {
+		if err := fn(transfer.Context(), progressCb); err != nil && !transfer.IsCancelled() {
+			progressCb(ssh.TransferProgress{
+				Status: "failed",
+				Error:  err.Error(),
+			})
+		}
+
+		// Cleanup after completion
+		go func() {
+			<-transfer.Context().Done()
+			s.transferManager.CleanupTransfer(transfer.ID)
+		}()
+	}()
+}
+
+// SetTrash enables moving deleted items on SSH sessions to the server's trash, and
+// purges items older than maxAge whenever the trash is used (0 keeps them forever)
+func (s *SFTPService) SetTrash(enabled bool, maxAge time.Duration) {
+	s.trashEnabled.Store(enabled)
+	s.trashMaxAge.Store(int64(maxAge))
 }
 
-// DeleteFiles deletes multiple files or directories
+// DeleteFile deletes a single file or directory, moving it to the trash when trash mode is on
+func (s *SFTPService) DeleteFile(sessionID string, path string) error {
+	return s.DeleteFiles(sessionID, []string{path})
+}
+
+// DeleteFiles deletes multiple files or directories, moving them to the trash when trash
+// mode is on. An item that cannot be trashed fails with an error wrapping
+// ssh.ErrTrashUnavailable, so the user can be asked to delete it permanently.
 func (s *SFTPService) DeleteFiles(sessionID string, paths []string) error {
+	fs, err := s.fileSystem(sessionID)
+	if err != nil {
+		return err
+	}
+
+	// FTP servers and the local disk have no server-side trash
+	sftpClient, ok := fs.(*ssh.SFTPClient)
+	if !ok || !s.trashEnabled.Load() {
+		return s.DeleteFilesPermanently(sessionID, paths)
+	}
+
+	// Expired items are purged once per batch, not once per item
+	defer s.autoPurgeTrash(sftpClient)
 	for _, path := range paths {
-		if err := s.DeleteFile(sessionID, path); err != nil {
+		if _, err := sftpClient.MoveToTrash(path); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// RenameFile renames a file or directory
-func (s *SFTPService) RenameFile(sessionID string, oldPath string, newPath string) error {
+// DeleteFilesPermanently deletes files or directories without using the trash
+func (s *SFTPService) DeleteFilesPermanently(sessionID string, paths []string) error {
+	fs, err := s.fileSystem(sessionID)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if err := s.deletePermanently(fs, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SFTPService) deletePermanently(fs ssh.FileSystem, path string) error {
+	// Check if it's a directory
+	fileInfo, err := fs.GetFileInfo(path)
+	if err != nil {
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	if fileInfo.IsDir && !fileInfo.IsSymlink {
+		return fs.DeleteDirectory(path)
+	}
+
+	return fs.DeleteFile(path)
+}
+
+// ListTrash lists the items in a session's trash, most recently deleted first
+func (s *SFTPService) ListTrash(sessionID string) ([]ssh.TrashItem, error) {
+	sftpClient, err := s.sessionManager.GetOrCreateSFTPClient(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SFTP client: %w", err)
+	}
+
+	s.autoPurgeTrash(sftpClient)
+	return sftpClient.ListTrash()
+}
+
+// RestoreFromTrash moves trashed items back to their original paths
+// Returns the restored paths
+func (s *SFTPService) RestoreFromTrash(sessionID string, names []string) ([]string, error) {
+	sftpClient, err := s.sessionManager.GetOrCreateSFTPClient(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SFTP client: %w", err)
+	}
+
+	restored := make([]string, 0, len(names))
+	for _, name := range names {
+		originalPath, err := sftpClient.RestoreFromTrash(name)
+		if err != nil {
+			return restored, err
+		}
+		restored = append(restored, originalPath)
+	}
+	return restored, nil
+}
+
+// PurgeTrash permanently deletes items from a session's trash
+func (s *SFTPService) PurgeTrash(sessionID string, names []string) error {
 	sftpClient, err := s.sessionManager.GetOrCreateSFTPClient(sessionID)
 	if err != nil {
 		return fmt.Errorf("failed to get SFTP client: %w", err)
 	}
 
-	return sftpClient.RenameFile(oldPath, newPath)
+	return sftpClient.PurgeTrash(names)
 }
 
-// CreateDirectory creates a new directory
-func (s *SFTPService) CreateDirectory(sessionID string, path string) error {
+// EmptyTrash permanently deletes everything in a session's trash
+func (s *SFTPService) EmptyTrash(sessionID string) error {
 	sftpClient, err := s.sessionManager.GetOrCreateSFTPClient(sessionID)
 	if err != nil {
 		return fmt.Errorf("failed to get SFTP client: %w", err)
 	}
 
-	return sftpClient.CreateDirectory(path)
+	return sftpClient.EmptyTrash()
+}
+
+// autoPurgeTrash removes expired trash items in the background
+func (s *SFTPService) autoPurgeTrash(sftpClient *ssh.SFTPClient) {
+	maxAge := time.Duration(s.trashMaxAge.Load())
+	if maxAge <= 0 {
+		return
+	}
+
+	go func() {
+		if err := sftpClient.PurgeTrashOlderThan(maxAge); err != nil {
+			fmt.Printf("Failed to purge trash: %v\n", err)
+		}
+	}()
+}
+
+// RenameFile renames a file or directory
+func (s *SFTPService) RenameFile(sessionID string, oldPath string, newPath string) error {
+	fs, err := s.fileSystem(sessionID)
+	if err != nil {
+		return err
+	}
+
+	return fs.RenameFile(oldPath, newPath)
+}
+
+// CreateDirectory creates a new directory
+func (s *SFTPService) CreateDirectory(sessionID string, path string) error {
+	fs, err := s.fileSystem(sessionID)
+	if err != nil {
+		return err
+	}
+
+	return fs.CreateDirectory(path)
 }
 
 // CancelTransfer cancels a file transfer
@@ -274,6 +854,21 @@ func (s *SFTPService) CancelTransfer(transferID string) error {
 	return s.transferManager.CancelTransfer(transferID)
 }
 
+// SetGlobalRateLimit limits the combined throughput of all transfers in bytes per second; 0 removes the limit
+func (s *SFTPService) SetGlobalRateLimit(bytesPerSecond int64) {
+	s.transferManager.SetGlobalRateLimit(bytesPerSecond)
+}
+
+// SetTransferChunks sets how many ranges large files are split into for concurrent transfer
+func (s *SFTPService) SetTransferChunks(chunks int) {
+	s.sessionManager.SetTransferChunks(chunks)
+}
+
+// SetTransferRateLimit limits a single transfer in bytes per second; 0 removes the limit
+func (s *SFTPService) SetTransferRateLimit(transferID string, bytesPerSecond int64) error {
+	return s.transferManager.SetTransferRateLimit(transferID, bytesPerSecond)
+}
+
 // GetTransferStatus gets the status of a transfer
 func (s *SFTPService) GetTransferStatus(transferID string) (*ssh.TransferProgress, error) {
 	progress, err := s.transferManager.GetProgress(transferID)
@@ -292,3 +887,184 @@ func (s *SFTPService) SearchDirectories(sessionID string, searchPath string, que
 
 	return sftpClient.SearchDirectories(searchPath, query, maxDepth, maxResults)
 }
+
+// SearchFiles starts a file search by name, size, modification time and content
+// Results are delivered in pages through pageCallback; returns searchID for cancellation
+func (s *SFTPService) SearchFiles(sessionID string, query ssh.FileSearchQuery, pageCallback SearchPageCallback) (string, error) {
+	sftpClient, err := s.sessionManager.GetOrCreateSFTPClient(sessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get SFTP client: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.searchMu.Lock()
+	searchID := fmt.Sprintf("search_%d_%d", time.Now().UnixNano(), len(s.searches))
+	s.searches[searchID] = cancel
+	s.searchMu.Unlock()
+
+	go func() {
+		defer func() {
+			s.searchMu.Lock()
+			delete(s.searches, searchID)
+			s.searchMu.Unlock()
+			cancel()
+		}()
+
+		err := sftpClient.SearchFiles(ctx, query, func(page ssh.FileSearchPage) {
+			page.SearchID = searchID
+			if pageCallback != nil {
+				pageCallback(page)
+			}
+		})
+		if err != nil && pageCallback != nil {
+			page := ssh.FileSearchPage{SearchID: searchID, Done: true}
+			if ctx.Err() == nil {
+				page.Error = err.Error()
+			}
+			pageCallback(page)
+		}
+	}()
+
+	return searchID, nil
+}
+
+// CancelSearch stops a running file search
+func (s *SFTPService) CancelSearch(searchID string) error {
+	s.searchMu.Lock()
+	cancel, exists := s.searches[searchID]
+	s.searchMu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("search not found: %s", searchID)
+	}
+
+	cancel()
+	return nil
+}
+
+// StartTail follows a remote file, delivering new lines through eventCallback until StopTail
+// Several files can be followed per session; returns tailID
+func (s *SFTPService) StartTail(sessionID string, opts ssh.TailOptions, eventCallback TailCallback) (string, error) {
+	sftpClient, err := s.sessionManager.GetOrCreateSFTPClient(sessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get SFTP client: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.tailMu.Lock()
+	tailID := fmt.Sprintf("tail_%d_%d", time.Now().UnixNano(), len(s.tails))
+	s.tails[tailID] = &TailInfo{
+		TailID:    tailID,
+		SessionID: sessionID,
+		Path:      opts.Path,
+		cancel:    cancel,
+	}
+	s.tailMu.Unlock()
+
+	go func() {
+		defer func() {
+			s.tailMu.Lock()
+			delete(s.tails, tailID)
+			s.tailMu.Unlock()
+			cancel()
+		}()
+
+		err := sftpClient.TailFile(ctx, opts, func(event ssh.TailEvent) {
+			event.TailID = tailID
+			event.SessionID = sessionID
+			if eventCallback != nil {
+				eventCallback(event)
+			}
+		})
+		if eventCallback != nil {
+			event := ssh.TailEvent{TailID: tailID, SessionID: sessionID, Path: opts.Path, Done: true}
+			if err != nil && ctx.Err() == nil {
+				event.Error = err.Error()
+			}
+			eventCallback(event)
+		}
+	}()
+
+	return tailID, nil
+}
+
+// StopTail stops following a file
+func (s *SFTPService) StopTail(tailID string) error {
+	s.tailMu.Lock()
+	tail, exists := s.tails[tailID]
+	s.tailMu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("tail not found: %s", tailID)
+	}
+
+	tail.cancel()
+	return nil
+}
+
+// ListTails returns the running tails of a session
+func (s *SFTPService) ListTails(sessionID string) []TailInfo {
+	s.tailMu.Lock()
+	defer s.tailMu.Unlock()
+
+	tails := make([]TailInfo, 0)
+	for _, tail := range s.tails {
+		if tail.SessionID == sessionID {
+			tails = append(tails, *tail)
+		}
+	}
+	return tails
+}
+
+// WatchDirectory starts watching a remote directory for created, modified and deleted entries
+// The watch ends with StopWatch or when the session is closed; returns watchID
+func (s *SFTPService) WatchDirectory(sessionID string, opts ssh.WatchOptions, eventCallback WatchCallback) (string, error) {
+	sftpClient, err := s.sessionManager.GetOrCreateSFTPClient(sessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get SFTP client: %w", err)
+	}
+
+	// A watch that ends immediately waits until it is added before removing itself
+	registered := make(chan struct{})
+	watchID, err := sftpClient.WatchDirectory(opts, func(event ssh.DirWatchEvent) {
+		event.SessionID = sessionID
+		if event.Done {
+			<-registered
+			s.watchMu.Lock()
+			delete(s.watches, event.WatchID)
+			s.watchMu.Unlock()
+		}
+		if eventCallback != nil {
+			eventCallback(event)
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+
+	s.watchMu.Lock()
+	s.watches[watchID] = sessionID
+	s.watchMu.Unlock()
+	close(registered)
+	return watchID, nil
+}
+
+// StopWatch stops watching a directory
+func (s *SFTPService) StopWatch(watchID string) error {
+	s.watchMu.Lock()
+	sessionID, exists := s.watches[watchID]
+	s.watchMu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("watch not found: %s", watchID)
+	}
+
+	sftpClient, err := s.sessionManager.GetOrCreateSFTPClient(sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get SFTP client: %w", err)
+	}
+
+	return sftpClient.StopWatch(watchID)
+}
@@ -0,0 +1,381 @@
+package ssh
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Archive formats supported by CreateArchive
+const (
+	ArchiveFormatTarGz = "tar.gz"
+	ArchiveFormatZip   = "zip"
+)
+
+// CreateArchive compresses remotePaths into archivePath on the remote host using tar or zip.
+// All paths must share the same parent directory; entries are stored relative to it.
+// Progress is reported by entries, counted up front with find.
+func (c *Client) CreateArchive(ctx context.Context, remotePaths []string, archivePath, format string, progressCb func(TransferProgress)) error {
+	if len(remotePaths) == 0 {
+		return fmt.Errorf("no paths to archive")
+	}
+
+	parent, names, err := splitCommonParent(remotePaths)
+	if err != nil {
+		return err
+	}
+	archivePath = normalizePath(archivePath)
+
+	var tool, cmd string
+	switch format {
+	case ArchiveFormatTarGz, "":
+		tool = "tar"
+		cmd = fmt.Sprintf("tar -czvf %s %s", shellQuote(archivePath), shellQuoteAll(names))
+	case ArchiveFormatZip:
+		tool = "zip"
+		cmd = fmt.Sprintf("zip -r %s %s", shellQuote(archivePath), shellQuoteAll(names))
+	default:
+		return fmt.Errorf("unsupported archive format: %s", format)
+	}
+
+	if err := c.requireRemoteTool(ctx, tool); err != nil {
+		return err
+	}
+
+	countCmd := fmt.Sprintf("cd %s && find %s | wc -l", shellQuote(parent), shellQuoteAll(names))
+	total := c.countRemoteLines(ctx, countCmd)
+
+	return c.runArchiveCommand(ctx, tool, fmt.Sprintf("cd %s && %s", shellQuote(parent), cmd), total, progressCb)
+}
+
+// ExtractArchive extracts archivePath into destDir (the archive's own directory when empty)
+// using tar or unzip, chosen by the archive extension
+func (c *Client) ExtractArchive(ctx context.Context, archivePath, destDir string, progressCb func(TransferProgress)) error {
+	archivePath = normalizePath(archivePath)
+	if destDir == "" {
+		destDir = path.Dir(archivePath)
+	}
+	destDir = normalizePath(destDir)
+
+	var tool, cmd, listCmd string
+	switch {
+	case strings.HasSuffix(strings.ToLower(archivePath), ".zip"):
+		tool = "unzip"
+		cmd = fmt.Sprintf("unzip -o %s -d %s", shellQuote(archivePath), shellQuote(destDir))
+		listCmd = fmt.Sprintf("unzip -Z1 %s | wc -l", shellQuote(archivePath))
+	case isTarArchive(archivePath):
+		tool = "tar"
+		cmd = fmt.Sprintf("tar -xvf %s -C %s", shellQuote(archivePath), shellQuote(destDir))
+		listCmd = fmt.Sprintf("tar -tf %s | wc -l", shellQuote(archivePath))
+	default:
+		return fmt.Errorf("unsupported archive type: %s", path.Base(archivePath))
+	}
+
+	if err := c.requireRemoteTool(ctx, tool); err != nil {
+		return err
+	}
+
+	total := c.countRemoteLines(ctx, listCmd)
+	return c.runArchiveCommand(ctx, tool, cmd, total, progressCb)
+}
+
+// runArchiveCommand runs a verbose tar/zip/unzip command, counting one entry per output line
+func (c *Client) runArchiveCommand(ctx context.Context, tool, cmd string, total int64, progressCb func(TransferProgress)) error {
+	var done int64
+	var stderr bytes.Buffer
+	lastEmit := time.Now()
+
+	stdout := &lineWriter{fn: func(line string) {
+		done++
+		if progressCb == nil || time.Since(lastEmit) < 200*time.Millisecond {
+			return
+		}
+		lastEmit = time.Now()
+		progressCb(TransferProgress{
+			Filename:     strings.TrimSpace(line),
+			Percentage:   entryPercentage(done, total),
+			EntriesDone:  done,
+			EntriesTotal: total,
+			Status:       "running",
+		})
+	}}
+
+	if err := c.RunCommandStream(ctx, cmd, nil, stdout, &stderr); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s failed: %s", tool, msg)
+		}
+		return fmt.Errorf("%s failed: %w", tool, err)
+	}
+
+	if progressCb != nil {
+		progressCb(TransferProgress{
+			Percentage:   100.0,
+			EntriesDone:  done,
+			EntriesTotal: max(total, done),
+			Status:       "completed",
+		})
+	}
+
+	return nil
+}
+
+// ToolNotFoundError is returned when a command required for an operation is missing on the remote host
+type ToolNotFoundError struct {
+	Tool string
+}
+
+func (e *ToolNotFoundError) Error() string {
+	return fmt.Sprintf("%s is not installed on the remote host", e.Tool)
+}
+
+// requireRemoteTool returns a *ToolNotFoundError when a command is not installed remotely
+func (c *Client) requireRemoteTool(ctx context.Context, tool string) error {
+	err := c.RunCommandStream(ctx, "command -v "+shellQuote(tool), nil, nil, nil)
+	if err == nil {
+		return nil
+	}
+
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return &ToolNotFoundError{Tool: tool}
+	}
+	return fmt.Errorf("failed to check for %s: %w", tool, err)
+}
+
+// countRemoteLines runs a command printing a single number, returning 0 when it fails
+func (c *Client) countRemoteLines(ctx context.Context, cmd string) int64 {
+	var stdout bytes.Buffer
+	if err := c.RunCommandStream(ctx, cmd, nil, &stdout, nil); err != nil {
+		return 0
+	}
+	count, _ := strconv.ParseInt(strings.TrimSpace(stdout.String()), 10, 64)
+	return count
+}
+
+// DownloadDirectoryArchive downloads a remote directory as a single local tar.gz file,
+// reading every file over SFTP. Used when the remote host has no usable tar.
+func (sc *SFTPClient) DownloadDirectoryArchive(ctx context.Context, remoteDir, localArchivePath string, progressCb func(TransferProgress), limiters ...*RateLimiter) error {
+	remoteDir = normalizePath(remoteDir)
+
+	entries, totalBytes, err := sc.collectRelayEntries(remoteDir, path.Base(remoteDir))
+	if err != nil {
+		return err
+	}
+
+	localFile, err := os.Create(localArchivePath)
+	if err != nil {
+		return fmt.Errorf("failed to create local file: %w", err)
+	}
+	defer localFile.Close()
+
+	gzipWriter := gzip.NewWriter(localFile)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	var bytesDone int64
+	for i, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := sc.writeTarEntry(ctx, tarWriter, entry, limiters, func(progress TransferProgress) {
+			progress.BytesSent += bytesDone
+			progress.TotalBytes = totalBytes
+			progress.Percentage = relayPercentage(progress.BytesSent, totalBytes)
+			progress.EntriesDone = int64(i)
+			progress.EntriesTotal = int64(len(entries))
+			progress.Status = "running"
+			if progressCb != nil {
+				progressCb(progress)
+			}
+		}); err != nil {
+			return err
+		}
+		bytesDone += entry.size
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+
+	if progressCb != nil {
+		progressCb(TransferProgress{
+			BytesSent:    bytesDone,
+			TotalBytes:   totalBytes,
+			Percentage:   100.0,
+			EntriesDone:  int64(len(entries)),
+			EntriesTotal: int64(len(entries)),
+			Status:       "completed",
+		})
+	}
+
+	return nil
+}
+
+// writeTarEntry appends a single file or directory read over SFTP to the tar stream
+func (sc *SFTPClient) writeTarEntry(ctx context.Context, tw *tar.Writer, entry relayEntry, limiters []*RateLimiter, progressCb func(TransferProgress)) error {
+	header := &tar.Header{
+		Name:    entry.dstPath,
+		Mode:    int64(entry.mode.Perm()),
+		ModTime: time.Now(),
+	}
+
+	if entry.isDir {
+		header.Typeflag = tar.TypeDir
+		header.Name += "/"
+		return tw.WriteHeader(header)
+	}
+
+	sc.mu.Lock()
+	remoteFile, err := sc.client.Open(entry.srcPath)
+	sc.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to open remote file %s: %w", entry.srcPath, err)
+	}
+	defer remoteFile.Close()
+
+	if stat, err := remoteFile.Stat(); err == nil {
+		header.ModTime = stat.ModTime()
+	}
+	header.Typeflag = tar.TypeReg
+	header.Size = entry.size
+
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write archive header: %w", err)
+	}
+
+	// The size was recorded in the header, so the copy must not exceed it even if the file grew
+	if err := streamWithProgress(ctx, io.LimitReader(remoteFile, entry.size), tw, entry.size, progressCb, limiters...); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", entry.srcPath, err)
+	}
+
+	return nil
+}
+
+// splitCommonParent returns the shared parent directory and the "./name" form of each path
+func splitCommonParent(remotePaths []string) (string, []string, error) {
+	parent := path.Dir(normalizePath(remotePaths[0]))
+	names := make([]string, 0, len(remotePaths))
+
+	for _, p := range remotePaths {
+		p = normalizePath(p)
+		if path.Dir(p) != parent {
+			return "", nil, fmt.Errorf("all paths must be in the same directory")
+		}
+		if p == "/" {
+			return "", nil, fmt.Errorf("cannot archive the root directory")
+		}
+		// The ./ prefix keeps names starting with "-" from being parsed as options
+		names = append(names, "./"+path.Base(p))
+	}
+
+	return parent, names, nil
+}
+
+// isTarArchive reports whether a file name has a tar-family extension
+func isTarArchive(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".tar", ".tar.gz", ".tgz", ".tar.bz2", ".tbz2", ".tar.xz", ".txz", ".tar.zst"} {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// entryPercentage returns done/total as a percentage, or 0 when the total is unknown
+func entryPercentage(done, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	percentage := float64(done) / float64(total) * 100
+	if percentage > 100 {
+		percentage = 100
+	}
+	return percentage
+}
+
+// StreamDirectoryArchive runs "tar -czf -" on the remote host and copies the compressed
+// stream of remoteDir to w. Progress reports compressed bytes; the total is unknown.
+func (c *Client) StreamDirectoryArchive(ctx context.Context, remoteDir string, w io.Writer, progressCb func(TransferProgress), limiters ...*RateLimiter) error {
+	remoteDir = normalizePath(remoteDir)
+	if remoteDir == "/" {
+		return fmt.Errorf("cannot archive the root directory")
+	}
+
+	if err := c.requireRemoteTool(ctx, "tar"); err != nil {
+		return err
+	}
+
+	cmd := fmt.Sprintf("tar -czf - -C %s %s", shellQuote(path.Dir(remoteDir)), shellQuote(path.Base(remoteDir)))
+
+	var stderr bytes.Buffer
+	counter := &progressWriter{w: limitWriter(ctx, w, limiters), progressCb: progressCb, lastTime: time.Now()}
+	if err := c.RunCommandStream(ctx, cmd, nil, counter, &stderr); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("tar failed: %s", msg)
+		}
+		return fmt.Errorf("tar failed: %w", err)
+	}
+
+	if progressCb != nil {
+		progressCb(TransferProgress{
+			BytesSent:  counter.written,
+			TotalBytes: counter.written,
+			Percentage: 100.0,
+			Status:     "completed",
+		})
+	}
+
+	return nil
+}
+
+// progressWriter counts bytes written through it and reports them about every 1MB
+type progressWriter struct {
+	w          io.Writer
+	progressCb func(TransferProgress)
+	written    int64
+	reported   int64
+	lastTime   time.Time
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	pw.written += int64(n)
+
+	if pw.progressCb != nil && pw.written-pw.reported >= 1024*1024 {
+		now := time.Now()
+		var speed int64
+		if elapsed := now.Sub(pw.lastTime).Seconds(); elapsed > 0 {
+			speed = int64(float64(pw.written-pw.reported) / elapsed)
+		}
+		pw.progressCb(TransferProgress{
+			BytesSent: pw.written,
+			Speed:     speed,
+			Status:    "running",
+		})
+		pw.reported = pw.written
+		pw.lastTime = now
+	}
+
+	return n, err
+}
@@ -0,0 +1,154 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultTransferChunks is the number of ranges a large file is split into
+	DefaultTransferChunks = 4
+	maxTransferChunks     = 32
+	// Files are only split when every range gets at least this much data
+	minChunkSize     = 4 * 1024 * 1024
+	chunkBufferSize  = 64 * 1024
+	chunkReportEvery = 200 * time.Millisecond
+)
+
+// SetTransferChunks sets how many ranges large files are split into for concurrent
+// transfer; 1 transfers sequentially, 0 restores DefaultTransferChunks
+func (sc *SFTPClient) SetTransferChunks(chunks int) {
+	sc.chunks.Store(int32(max(min(int64(chunks), maxTransferChunks), 0)))
+}
+
+// transferChunks returns the number of ranges to use for a file of totalBytes
+func (sc *SFTPClient) transferChunks(totalBytes int64) int {
+	chunks := int64(sc.chunks.Load())
+	if chunks == 0 {
+		chunks = DefaultTransferChunks
+	}
+	return int(max(min(chunks, totalBytes/minChunkSize), 1))
+}
+
+// streamChunked copies totalBytes from src to dst as chunks ranges transferred
+// concurrently with ReadAt/WriteAt, so several SFTP requests are in flight at once.
+// Progress is aggregated over all ranges.
+func (sc *SFTPClient) streamChunked(ctx context.Context, src io.ReaderAt, dst io.WriterAt, totalBytes int64, chunks int, progressCb func(TransferProgress), limiters ...*RateLimiter) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var transferred atomic.Int64
+	var firstErr error
+	var errOnce sync.Once
+	var wg sync.WaitGroup
+
+	chunkSize := (totalBytes + int64(chunks) - 1) / int64(chunks)
+	for start := int64(0); start < totalBytes; start += chunkSize {
+		end := start + chunkSize
+		if end > totalBytes {
+			end = totalBytes
+		}
+
+		wg.Add(1)
+		go func(start, end int64) {
+			defer wg.Done()
+			if err := copyRange(ctx, src, dst, start, end, &transferred, limiters); err != nil {
+				// The first failure stops the other ranges
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(start, end)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	ticker := time.NewTicker(chunkReportEvery)
+	defer ticker.Stop()
+
+	var lastBytes int64
+	var speed int64
+	lastTime := time.Now()
+
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		case now := <-ticker.C:
+			bytesTransferred := transferred.Load()
+			if elapsed := now.Sub(lastTime).Seconds(); elapsed > 0 {
+				speed = int64(float64(bytesTransferred-lastBytes) / elapsed)
+			}
+			lastBytes = bytesTransferred
+			lastTime = now
+
+			if progressCb != nil {
+				progressCb(TransferProgress{
+					BytesSent:  bytesTransferred,
+					TotalBytes: totalBytes,
+					Percentage: float64(bytesTransferred) / float64(totalBytes) * 100,
+					Speed:      speed,
+					Status:     "running",
+				})
+			}
+		}
+	}
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	if progressCb != nil {
+		progressCb(TransferProgress{
+			BytesSent:  totalBytes,
+			TotalBytes: totalBytes,
+			Percentage: 100.0,
+			Speed:      speed,
+			Status:     "completed",
+		})
+	}
+
+	return nil
+}
+
+// copyRange copies the bytes in [start, end) from src to dst at the same offsets
+func copyRange(ctx context.Context, src io.ReaderAt, dst io.WriterAt, start, end int64, transferred *atomic.Int64, limiters []*RateLimiter) error {
+	buffer := make([]byte, chunkBufferSize)
+
+	for offset := start; offset < end; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n := min(int64(len(buffer)), end-offset)
+		read, err := src.ReadAt(buffer[:n], offset)
+		if read > 0 {
+			if waitErr := waitAll(ctx, limiters, read); waitErr != nil {
+				return waitErr
+			}
+			if _, writeErr := dst.WriteAt(buffer[:read], offset); writeErr != nil {
+				return fmt.Errorf("write error at offset %d: %w", offset, writeErr)
+			}
+			offset += int64(read)
+			transferred.Add(int64(read))
+		}
+
+		if err == io.EOF && offset < end {
+			return fmt.Errorf("read error at offset %d: file shrank during transfer", offset)
+		}
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("read error at offset %d: %w", offset, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,219 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+const (
+	benchFileSize = 32 * 1024 * 1024
+	benchLatency  = 2 * time.Millisecond
+)
+
+// latencyPipe is an in-memory pipe that delivers every write after a fixed delay,
+// without blocking the writer, to mimic a high-latency link
+type latencyPipe struct {
+	delay   time.Duration
+	packets chan latencyPacket
+	pending []byte
+
+	mu     sync.Mutex
+	closed bool
+}
+
+type latencyPacket struct {
+	data []byte
+	at   time.Time
+}
+
+func newLatencyPipe(delay time.Duration) *latencyPipe {
+	return &latencyPipe{delay: delay, packets: make(chan latencyPacket, 4096)}
+}
+
+func (lp *latencyPipe) Write(p []byte) (int, error) {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	if lp.closed {
+		return 0, io.ErrClosedPipe
+	}
+	lp.packets <- latencyPacket{data: append([]byte(nil), p...), at: time.Now().Add(lp.delay)}
+	return len(p), nil
+}
+
+func (lp *latencyPipe) Read(p []byte) (int, error) {
+	if len(lp.pending) == 0 {
+		packet, ok := <-lp.packets
+		if !ok {
+			return 0, io.EOF
+		}
+		time.Sleep(time.Until(packet.at))
+		lp.pending = packet.data
+	}
+
+	n := copy(p, lp.pending)
+	lp.pending = lp.pending[n:]
+	return n, nil
+}
+
+func (lp *latencyPipe) Close() error {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	if !lp.closed {
+		lp.closed = true
+		close(lp.packets)
+	}
+	return nil
+}
+
+// newTestSFTPClient connects an SFTPClient to an in-process SFTP server serving the local filesystem
+func newTestSFTPClient(tb testing.TB, latency time.Duration) *SFTPClient {
+	tb.Helper()
+
+	toServer := newLatencyPipe(latency)
+	toClient := newLatencyPipe(latency)
+
+	server, err := sftp.NewServer(struct {
+		io.Reader
+		io.WriteCloser
+	}{toServer, toClient})
+	if err != nil {
+		tb.Fatalf("failed to create SFTP server: %v", err)
+	}
+	go server.Serve()
+
+	client, err := sftp.NewClientPipe(toClient, toServer)
+	if err != nil {
+		tb.Fatalf("failed to create SFTP client: %v", err)
+	}
+
+	// Closing the server side first ends the client's receive loop
+	tb.Cleanup(func() {
+		server.Close()
+		client.Close()
+	})
+
+	return &SFTPClient{client: client}
+}
+
+func writeRandomFile(tb testing.TB, filePath string, size int) []byte {
+	tb.Helper()
+
+	data := make([]byte, size)
+	rand.Read(data)
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		tb.Fatalf("failed to write test file: %v", err)
+	}
+	return data
+}
+
+func TestChunkedTransferRoundTrip(t *testing.T) {
+	sc := newTestSFTPClient(t, 0)
+	sc.SetTransferChunks(4)
+
+	dir := t.TempDir()
+	data := writeRandomFile(t, filepath.Join(dir, "source"), 3*minChunkSize+12345)
+
+	var last TransferProgress
+	progressCb := func(progress TransferProgress) { last = progress }
+
+	remotePath := filepath.ToSlash(filepath.Join(dir, "remote"))
+	if err := sc.UploadFile(context.Background(), filepath.Join(dir, "source"), remotePath, progressCb); err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	if last.Status != "completed" || last.BytesSent != int64(len(data)) {
+		t.Fatalf("unexpected final upload progress: %+v", last)
+	}
+
+	localPath := filepath.Join(dir, "download")
+	if err := sc.DownloadFile(context.Background(), remotePath, localPath, progressCb); err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+
+	got, err := os.ReadFile(localPath)
+	if err != nil {
+		t.Fatalf("failed to read downloaded file: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("downloaded file differs from source")
+	}
+}
+
+func TestTransferChunks(t *testing.T) {
+	sc := &SFTPClient{}
+
+	tests := []struct {
+		chunks int
+		size   int64
+		want   int
+	}{
+		{0, 100 * minChunkSize, DefaultTransferChunks},
+		{8, 100 * minChunkSize, 8},
+		{8, 3 * minChunkSize, 3},
+		{8, minChunkSize - 1, 1},
+		{1, 100 * minChunkSize, 1},
+		{1000, 1000 * minChunkSize, maxTransferChunks},
+	}
+
+	for _, tt := range tests {
+		sc.SetTransferChunks(tt.chunks)
+		if got := sc.transferChunks(tt.size); got != tt.want {
+			t.Errorf("chunks=%d size=%d: got %d, want %d", tt.chunks, tt.size, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkDownloadFile(b *testing.B) {
+	dir := b.TempDir()
+	remotePath := filepath.Join(dir, "source")
+	writeRandomFile(b, remotePath, benchFileSize)
+
+	for _, chunks := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("chunks-%d", chunks), func(b *testing.B) {
+			sc := newTestSFTPClient(b, benchLatency)
+			sc.SetTransferChunks(chunks)
+			localPath := filepath.Join(dir, fmt.Sprintf("download-%d", chunks))
+
+			b.SetBytes(benchFileSize)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := sc.DownloadFile(context.Background(), filepath.ToSlash(remotePath), localPath, nil); err != nil {
+					b.Fatalf("download failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUploadFile(b *testing.B) {
+	dir := b.TempDir()
+	localPath := filepath.Join(dir, "source")
+	writeRandomFile(b, localPath, benchFileSize)
+
+	for _, chunks := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("chunks-%d", chunks), func(b *testing.B) {
+			sc := newTestSFTPClient(b, benchLatency)
+			sc.SetTransferChunks(chunks)
+			remotePath := filepath.ToSlash(filepath.Join(dir, fmt.Sprintf("upload-%d", chunks)))
+
+			b.SetBytes(benchFileSize)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := sc.UploadFile(context.Background(), localPath, remotePath, nil); err != nil {
+					b.Fatalf("upload failed: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -3,9 +3,11 @@ package ssh
 import (
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
 )
 
 // Client represents an SSH client
@@ -13,6 +15,9 @@ type Client struct {
 	config  *ssh.ClientConfig
 	client  *ssh.Client
 	address string
+
+	agentOnce sync.Once
+	agentErr  error
 }
 
 // Config holds SSH connection configuration
@@ -100,6 +105,39 @@ func (c *Client) IsConnected() bool {
 	return c.client != nil
 }
 
+// Address returns the host:port the client connects to
+func (c *Client) Address() string {
+	return c.address
+}
+
+// User returns the login user of the client
+func (c *Client) User() string {
+	return c.config.User
+}
+
+// agentForwarder registers the local SSH agent (SSH_AUTH_SOCK) with the connection and
+// returns a hook that requests agent forwarding on a session
+func (c *Client) agentForwarder() (func(*ssh.Session) error, error) {
+	if !c.IsConnected() {
+		return nil, fmt.Errorf("client not connected")
+	}
+
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return nil, fmt.Errorf("agent forwarding requires a running local SSH agent (SSH_AUTH_SOCK is not set)")
+	}
+
+	// The channel handler can only be registered once per connection
+	c.agentOnce.Do(func() {
+		c.agentErr = agent.ForwardToRemote(c.client, sock)
+	})
+	if c.agentErr != nil {
+		return nil, fmt.Errorf("failed to set up agent forwarding: %w", c.agentErr)
+	}
+
+	return agent.RequestAgentForwarding, nil
+}
+
 // getKeyAuth loads a private key file and returns an SSH auth method
 func getKeyAuth(keyPath, passphrase string) (ssh.AuthMethod, error) {
 	// Read the private key file
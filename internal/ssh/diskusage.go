@@ -0,0 +1,355 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DiskUsageOptions controls a disk usage scan
+type DiskUsageOptions struct {
+	Path          string `json:"path"`
+	MaxDepth      int    `json:"max_depth"`       // Levels of the tree to return, 0 = default (3)
+	TopN          int    `json:"top_n"`           // Largest children kept per level, 0 = default (10)
+	OneFileSystem bool   `json:"one_file_system"` // Do not descend into other mounts (du -x)
+}
+
+// DiskUsageNode is a file or directory with its total size on disk
+type DiskUsageNode struct {
+	Path      string           `json:"path"`
+	Name      string           `json:"name"`
+	Size      int64            `json:"size"` // bytes
+	IsDir     bool             `json:"is_dir"`
+	Children  []*DiskUsageNode `json:"children,omitempty"`
+	Omitted   int              `json:"omitted,omitempty"`    // Children dropped by TopN
+	OtherSize int64            `json:"other_size,omitempty"` // Total size of the omitted children
+}
+
+// DiskUsageProgress reports the state of a running scan; Result is set on the final update
+type DiskUsageProgress struct {
+	ScanID  string         `json:"scan_id"`
+	Path    string         `json:"path"` // Entry currently being scanned
+	Entries int64          `json:"entries"`
+	Bytes   int64          `json:"bytes"`
+	Done    bool           `json:"done"`
+	Error   string         `json:"error,omitempty"`
+	Result  *DiskUsageNode `json:"result,omitempty"`
+}
+
+const (
+	defaultDiskUsageDepth = 3
+	defaultDiskUsageTopN  = 10
+)
+
+// ScanDiskUsage builds a size tree below opts.Path, keeping the TopN largest children
+// per level. It runs du on the server and falls back to walking the tree over SFTP,
+// where sizes are apparent file sizes rather than allocated blocks.
+func (sc *SFTPClient) ScanDiskUsage(ctx context.Context, opts DiskUsageOptions, progressCb func(DiskUsageProgress)) (*DiskUsageNode, error) {
+	opts.Path = normalizePath(opts.Path)
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = defaultDiskUsageDepth
+	}
+	if opts.TopN <= 0 {
+		opts.TopN = defaultDiskUsageTopN
+	}
+
+	var nodes map[string]*DiskUsageNode
+	var err error
+	if sc.sshClient != nil && sc.sshClient.requireRemoteTool(ctx, "du") == nil {
+		nodes, err = sc.scanDiskUsageRemote(ctx, opts, progressCb)
+	} else {
+		nodes, err = sc.scanDiskUsageSFTP(ctx, opts, progressCb)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	root := buildDiskUsageTree(nodes, opts.Path)
+	if root == nil {
+		return nil, fmt.Errorf("no disk usage reported for %s", opts.Path)
+	}
+	pruneDiskUsageTree(root, opts.TopN)
+
+	return root, nil
+}
+
+// scanDiskUsageRemote runs "du -a" limited to MaxDepth, then lists directories so
+// files and directories can be told apart
+func (sc *SFTPClient) scanDiskUsageRemote(ctx context.Context, opts DiskUsageOptions, progressCb func(DiskUsageProgress)) (map[string]*DiskUsageNode, error) {
+	flags := "-a -k"
+	findFlags := ""
+	if opts.OneFileSystem {
+		flags += " -x"
+		findFlags = " -xdev"
+	}
+	cmd := fmt.Sprintf("du %s -d %d %s", flags, opts.MaxDepth, shellQuote(opts.Path))
+
+	nodes := make(map[string]*DiskUsageNode)
+	var entries int64
+	lastEmit := time.Now()
+
+	var stderr bytes.Buffer
+	stdout := &lineWriter{fn: func(line string) {
+		node, ok := parseDuLine(line)
+		if !ok {
+			return
+		}
+		nodes[node.Path] = node
+		entries++
+
+		if progressCb != nil && time.Since(lastEmit) >= 200*time.Millisecond {
+			lastEmit = time.Now()
+			progressCb(DiskUsageProgress{Path: node.Path, Entries: entries})
+		}
+	}}
+
+	// du exits non-zero when some entries were unreadable but still prints the rest
+	err := sc.sshClient.RunCommandStream(ctx, cmd, nil, stdout, &stderr)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil && len(nodes) == 0 {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("du failed: %s", msg)
+		}
+		return nil, fmt.Errorf("du failed: %w", err)
+	}
+
+	var dirs bytes.Buffer
+	findCmd := fmt.Sprintf("find %s%s -maxdepth %d -type d 2>/dev/null", shellQuote(opts.Path), findFlags, opts.MaxDepth)
+	if err := sc.sshClient.RunCommandStream(ctx, findCmd, nil, &dirs, nil); err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	for _, dir := range strings.Split(dirs.String(), "\n") {
+		if node, ok := nodes[normalizePath(dir)]; ok && dir != "" {
+			node.IsDir = true
+		}
+	}
+
+	return nodes, nil
+}
+
+// parseDuLine parses a "<kilobytes>\t<path>" line printed by du -k
+func parseDuLine(line string) (*DiskUsageNode, bool) {
+	sizeStr, filePath, ok := strings.Cut(line, "\t")
+	if !ok {
+		return nil, false
+	}
+	kb, err := strconv.ParseInt(strings.TrimSpace(sizeStr), 10, 64)
+	if err != nil {
+		return nil, false
+	}
+
+	filePath = normalizePath(filePath)
+	return &DiskUsageNode{
+		Path: filePath,
+		Name: path.Base(filePath),
+		Size: kb * 1024,
+	}, true
+}
+
+// scanDiskUsageSFTP walks the whole tree over SFTP, adding each file's size to every
+// ancestor down to MaxDepth
+func (sc *SFTPClient) scanDiskUsageSFTP(ctx context.Context, opts DiskUsageOptions, progressCb func(DiskUsageProgress)) (map[string]*DiskUsageNode, error) {
+	nodes := make(map[string]*DiskUsageNode)
+	root := opts.Path
+	rootDepth := pathDepth(root)
+
+	var entries, totalBytes int64
+	lastEmit := time.Now()
+
+	// SFTP reports no device numbers, so other filesystems are recognised by their mount points
+	var mounts map[string]bool
+	if opts.OneFileSystem {
+		mounts = sc.remoteMountPoints(ctx)
+	}
+
+	sc.mu.Lock()
+	walker := sc.client.Walk(root)
+	sc.mu.Unlock()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// Lock per step so other file operations can interleave with a long scan
+		sc.mu.Lock()
+		more := walker.Step()
+		sc.mu.Unlock()
+		if !more {
+			break
+		}
+		if walker.Err() != nil {
+			if walker.Path() == root {
+				return nil, fmt.Errorf("failed to read %s: %w", root, walker.Err())
+			}
+			continue
+		}
+
+		info := walker.Stat()
+		filePath := walker.Path()
+		isDir := info.IsDir()
+		if isDir && filePath != root && mounts[filePath] {
+			walker.SkipDir()
+			continue
+		}
+		size := info.Size()
+		if isDir || info.Mode()&os.ModeSymlink != 0 {
+			size = 0
+		}
+
+		depth := pathDepth(filePath) - rootDepth
+		if depth <= opts.MaxDepth {
+			nodes[filePath] = &DiskUsageNode{
+				Path:  filePath,
+				Name:  path.Base(filePath),
+				IsDir: isDir,
+			}
+		}
+
+		// Add the size to the entry itself (when tracked) and all of its tracked ancestors
+		if size > 0 {
+			for p := filePath; ; p = path.Dir(p) {
+				if node, ok := nodes[p]; ok {
+					node.Size += size
+				}
+				if p == root || p == "/" {
+					break
+				}
+			}
+		}
+
+		entries++
+		totalBytes += size
+		if progressCb != nil && time.Since(lastEmit) >= 200*time.Millisecond {
+			lastEmit = time.Now()
+			progressCb(DiskUsageProgress{Path: filePath, Entries: entries, Bytes: totalBytes})
+		}
+	}
+
+	return nodes, nil
+}
+
+// remoteMountPoints returns the server's mount points, from /proc/self/mounts on Linux or
+// the output of mount elsewhere; nil when neither can be read
+func (sc *SFTPClient) remoteMountPoints(ctx context.Context) map[string]bool {
+	var output []byte
+	sc.mu.Lock()
+	file, err := sc.client.Open("/proc/self/mounts")
+	if err == nil {
+		output, err = io.ReadAll(file)
+		file.Close()
+	}
+	sc.mu.Unlock()
+
+	if err == nil {
+		return parseProcMounts(string(output))
+	}
+	if sc.sshClient != nil {
+		var stdout bytes.Buffer
+		if sc.sshClient.RunCommandStream(ctx, "LC_ALL=C mount", nil, &stdout, nil) == nil {
+			return parseMountOutput(stdout.String())
+		}
+	}
+	return nil
+}
+
+// parseProcMounts reads the mount points of /proc/mounts, where spaces and other
+// special characters are octal escapes such as \040
+func parseProcMounts(output string) map[string]bool {
+	mounts := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		var mountPoint strings.Builder
+		for i := 0; i < len(fields[1]); i++ {
+			if fields[1][i] == '\\' && i+4 <= len(fields[1]) {
+				if code, err := strconv.ParseUint(fields[1][i+1:i+4], 8, 8); err == nil {
+					mountPoint.WriteByte(byte(code))
+					i += 3
+					continue
+				}
+			}
+			mountPoint.WriteByte(fields[1][i])
+		}
+		mounts[mountPoint.String()] = true
+	}
+	return mounts
+}
+
+// parseMountOutput reads the mount points of BSD-style mount output:
+// "device on /mount/point (type, options)" or "device on /mount/point type ext4 (options)"
+func parseMountOutput(output string) map[string]bool {
+	mounts := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		_, rest, ok := strings.Cut(line, " on ")
+		if !ok {
+			continue
+		}
+		if end := strings.LastIndex(rest, " ("); end >= 0 {
+			rest = rest[:end]
+		}
+		if end := strings.LastIndex(rest, " type "); end >= 0 {
+			rest = rest[:end]
+		}
+		mounts[rest] = true
+	}
+	return mounts
+}
+
+// buildDiskUsageTree links flat nodes into a tree rooted at root
+func buildDiskUsageTree(nodes map[string]*DiskUsageNode, root string) *DiskUsageNode {
+	rootNode, ok := nodes[root]
+	if !ok {
+		return nil
+	}
+
+	for p, node := range nodes {
+		if p == root {
+			continue
+		}
+		if parent, ok := nodes[path.Dir(p)]; ok {
+			parent.Children = append(parent.Children, node)
+			parent.IsDir = true
+		}
+	}
+
+	return rootNode
+}
+
+// pruneDiskUsageTree sorts children by size and keeps the topN largest on every level
+func pruneDiskUsageTree(node *DiskUsageNode, topN int) {
+	sort.Slice(node.Children, func(i, j int) bool {
+		return node.Children[i].Size > node.Children[j].Size
+	})
+
+	if len(node.Children) > topN {
+		for _, child := range node.Children[topN:] {
+			node.OtherSize += child.Size
+		}
+		node.Omitted = len(node.Children) - topN
+		node.Children = node.Children[:topN]
+	}
+
+	for _, child := range node.Children {
+		pruneDiskUsageTree(child, topN)
+	}
+}
+
+// pathDepth returns the number of components in a normalized absolute path
+func pathDepth(p string) int {
+	if p == "/" {
+		return 0
+	}
+	return strings.Count(p, "/")
+}
@@ -0,0 +1,75 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// RunCommandStream runs a command on a new non-PTY channel, wiring stdin/stdout/stderr
+// to the given streams. The command is killed when ctx is cancelled.
+func (c *Client) RunCommandStream(ctx context.Context, cmd string, stdin io.Reader, stdout, stderr io.Writer) error {
+	return c.runCommandStream(ctx, cmd, stdin, stdout, stderr, nil)
+}
+
+// runCommandStream is RunCommandStream with an optional hook to prepare the session
+// (e.g. request agent forwarding) before the command starts
+func (c *Client) runCommandStream(ctx context.Context, cmd string, stdin io.Reader, stdout, stderr io.Writer, prepare func(*ssh.Session) error) error {
+	if !c.IsConnected() {
+		return fmt.Errorf("client not connected")
+	}
+
+	session, err := c.client.NewSession()
+	if err != nil {
+		return fmt.Errorf("failed to create command session: %w", err)
+	}
+	defer session.Close()
+
+	session.Stdin = stdin
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	if prepare != nil {
+		if err := prepare(session); err != nil {
+			return err
+		}
+	}
+
+	if err := session.Start(cmd); err != nil {
+		return fmt.Errorf("failed to start command: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		session.Signal(ssh.SIGKILL)
+		session.Close()
+		return ctx.Err()
+	}
+}
+
+// shellQuote quotes a string for safe use as a single POSIX shell word
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellQuoteAll quotes each argument and joins them with spaces
+func shellQuoteAll(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,203 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FileSystem is a file tree the file manager can browse and transfer between.
+// It is implemented by SFTPClient, FTPClient and LocalFileSystem; paths use the
+// backend's own syntax, and "/" separators work for all of them.
+type FileSystem interface {
+	ListDirectory(dirPath string) ([]FileInfo, error)
+	GetFileInfo(filePath string) (*FileInfo, error)
+	OpenFile(filePath string) (io.ReadCloser, error)
+	CreateFile(filePath string) (io.WriteCloser, error)
+	RenameFile(oldPath, newPath string) error
+	DeleteFile(filePath string) error
+	DeleteDirectory(dirPath string) error // Recursive
+	CreateDirectory(dirPath string) error
+	Close() error
+}
+
+// fileSystemTransferer moves files between the local disk and a FileSystem with CopyPath
+type fileSystemTransferer struct {
+	fs FileSystem
+}
+
+// NewFileSystemTransferer adapts a FileSystem, such as an FTP connection or the local
+// disk, to the FileTransferer used for uploads and downloads
+func NewFileSystemTransferer(fs FileSystem) FileTransferer {
+	return &fileSystemTransferer{fs: fs}
+}
+
+// UploadFile copies a local file or directory to remotePath
+func (t *fileSystemTransferer) UploadFile(ctx context.Context, localPath, remotePath string, progressCb func(TransferProgress), limiters ...*RateLimiter) error {
+	return CopyPath(ctx, NewLocalFileSystem(), t.fs, localPath, remotePath, progressCb, limiters...)
+}
+
+// DownloadFile copies remotePath to a local file or directory
+func (t *fileSystemTransferer) DownloadFile(ctx context.Context, remotePath, localPath string, progressCb func(TransferProgress), limiters ...*RateLimiter) error {
+	return CopyPath(ctx, t.fs, NewLocalFileSystem(), remotePath, localPath, progressCb, limiters...)
+}
+
+// copyEntry is a single file or directory to be copied by CopyPath
+type copyEntry struct {
+	srcPath string
+	dstPath string
+	size    int64
+	isDir   bool
+}
+
+// CopyPath copies a file or directory between two filesystems of any kind, streaming
+// the data through this machine. src and dst may be the same filesystem, but a path
+// can't be copied onto itself or a directory into its own subtree.
+func CopyPath(ctx context.Context, src, dst FileSystem, srcPath, dstPath string, progressCb func(TransferProgress), limiters ...*RateLimiter) error {
+	if sameFileSystem(src, dst) {
+		if err := checkCopyInto(srcPath, dstPath); err != nil {
+			return err
+		}
+	}
+
+	entries, totalBytes, err := collectCopyEntries(src, srcPath, dstPath)
+	if err != nil {
+		return err
+	}
+
+	var bytesDone int64
+	var lastSpeed int64
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if entry.isDir {
+			// The directory may already exist, which is fine when merging into it
+			if err := dst.CreateDirectory(entry.dstPath); err != nil {
+				if info, statErr := dst.GetFileInfo(entry.dstPath); statErr != nil || !info.IsDir {
+					return fmt.Errorf("failed to create directory %s: %w", entry.dstPath, err)
+				}
+			}
+			continue
+		}
+
+		base := bytesDone
+		err := copyFile(ctx, src, dst, entry, limiters, func(progress TransferProgress) {
+			// Report aggregated progress across all files of the transfer
+			progress.BytesSent += base
+			progress.TotalBytes = totalBytes
+			progress.Percentage = relayPercentage(progress.BytesSent, totalBytes)
+			progress.Status = "running"
+			lastSpeed = progress.Speed
+			if progressCb != nil {
+				progressCb(progress)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		bytesDone += entry.size
+	}
+
+	if progressCb != nil {
+		progressCb(TransferProgress{
+			BytesSent:  bytesDone,
+			TotalBytes: totalBytes,
+			Percentage: 100.0,
+			Speed:      lastSpeed,
+			Status:     "completed",
+		})
+	}
+
+	return nil
+}
+
+// copyFile streams a single regular file from src to dst
+func copyFile(ctx context.Context, src, dst FileSystem, entry copyEntry, limiters []*RateLimiter, progressCb func(TransferProgress)) error {
+	srcFile, err := src.OpenFile(entry.srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to open source file %s: %w", entry.srcPath, err)
+	}
+	defer srcFile.Close()
+
+	dstFile, err := dst.CreateFile(entry.dstPath)
+	if err != nil {
+		return fmt.Errorf("failed to create target file %s: %w", entry.dstPath, err)
+	}
+
+	if err := streamWithProgress(ctx, srcFile, dstFile, entry.size, progressCb, limiters...); err != nil {
+		dstFile.Close()
+		return fmt.Errorf("failed to copy %s: %w", entry.srcPath, err)
+	}
+
+	// Some backends only report write failures when the file is closed
+	if err := dstFile.Close(); err != nil {
+		return fmt.Errorf("failed to finish %s: %w", entry.dstPath, err)
+	}
+	return nil
+}
+
+// sameFileSystem reports whether src and dst are the same file tree; every
+// LocalFileSystem is the local disk
+func sameFileSystem(src, dst FileSystem) bool {
+	_, srcLocal := src.(*LocalFileSystem)
+	_, dstLocal := dst.(*LocalFileSystem)
+	return src == dst || (srcLocal && dstLocal)
+}
+
+// checkCopyInto refuses copies within one filesystem that would overwrite the
+// source while reading it
+func checkCopyInto(srcPath, dstPath string) error {
+	from := path.Clean(filepath.ToSlash(srcPath))
+	to := path.Clean(filepath.ToSlash(dstPath))
+	if from == to {
+		return fmt.Errorf("cannot copy %s onto itself", srcPath)
+	}
+	if strings.HasPrefix(to, strings.TrimSuffix(from, "/")+"/") {
+		return fmt.Errorf("cannot copy %s into itself", srcPath)
+	}
+	return nil
+}
+
+// collectCopyEntries walks srcPath and maps every entry to its destination path.
+// Directories come before their contents so they can be created in order.
+func collectCopyEntries(fs FileSystem, srcPath, dstPath string) ([]copyEntry, int64, error) {
+	info, err := fs.GetFileInfo(srcPath)
+	if err != nil {
+		return nil, 0, err
+	}
+	return collectCopyTree(fs, *info, srcPath, dstPath)
+}
+
+func collectCopyTree(fs FileSystem, info FileInfo, srcPath, dstPath string) ([]copyEntry, int64, error) {
+	if !info.IsDir {
+		return []copyEntry{{srcPath: srcPath, dstPath: dstPath, size: info.Size}}, info.Size, nil
+	}
+
+	entries := []copyEntry{{srcPath: srcPath, dstPath: dstPath, isDir: true}}
+	var totalBytes int64
+
+	files, err := fs.ListDirectory(srcPath)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, file := range files {
+		// Links are skipped rather than followed, so cycles cannot recurse forever
+		if file.IsSymlink {
+			continue
+		}
+
+		children, size, err := collectCopyTree(fs, file, file.Path, path.Join(dstPath, file.Name))
+		if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, children...)
+		totalBytes += size
+	}
+
+	return entries, totalBytes, nil
+}
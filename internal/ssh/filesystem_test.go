@@ -0,0 +1,72 @@
+package ssh
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCopyPath_RefusesCopyIntoSource(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(file, []byte("keep me"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := NewLocalFileSystem()
+	for _, test := range []struct{ src, dst string }{
+		{file, file},
+		{file, dir + "/./notes.txt"},
+		{dir, filepath.Join(dir, "copy")},
+		{dir, filepath.Join(sub, "dir")},
+		{sub, sub},
+	} {
+		if err := CopyPath(context.Background(), fs, fs, test.src, test.dst, nil); err == nil {
+			t.Errorf("CopyPath(%q, %q) succeeded, want an error", test.src, test.dst)
+		}
+	}
+
+	if data, err := os.ReadFile(file); err != nil || string(data) != "keep me" {
+		t.Errorf("source after refused copies = %q, %v", data, err)
+	}
+
+	// Copies next to the source still work
+	if err := CopyPath(context.Background(), fs, fs, file, filepath.Join(sub, "notes.txt"), nil); err != nil {
+		t.Errorf("copy into a sibling directory: %v", err)
+	}
+	if err := CopyPath(context.Background(), fs, fs, sub, filepath.Join(dir, "sub2"), nil); err != nil {
+		t.Errorf("copy a directory next to itself: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "sub2", "notes.txt")); err != nil || string(data) != "keep me" {
+		t.Errorf("copied file = %q, %v", data, err)
+	}
+}
+
+func TestFileSystemTransferer(t *testing.T) {
+	dir := t.TempDir()
+	local := filepath.Join(dir, "local.txt")
+	if err := os.WriteFile(local, []byte("payload"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	transferer := NewFileSystemTransferer(NewLocalFileSystem())
+	remote := filepath.Join(dir, "remote.txt")
+	if err := transferer.UploadFile(context.Background(), local, remote, nil); err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	back := filepath.Join(dir, "back.txt")
+	if err := transferer.DownloadFile(context.Background(), remote, back, nil); err != nil {
+		t.Fatalf("DownloadFile: %v", err)
+	}
+	if data, err := os.ReadFile(back); err != nil || string(data) != "payload" {
+		t.Errorf("downloaded file = %q, %v", data, err)
+	}
+	if err := transferer.DownloadFile(context.Background(), remote, remote, nil); err == nil {
+		t.Error("downloading a local file onto itself succeeded")
+	}
+}
@@ -0,0 +1,658 @@
+package ssh
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FTPConfig holds the settings for an FTP or FTPS server
+type FTPConfig struct {
+	Host               string        `json:"host"`
+	Port               int           `json:"port"` // Default 21, or 990 with implicit TLS
+	User               string        `json:"user"` // Default "anonymous"
+	Password           string        `json:"password"`
+	TLS                string        `json:"tls"` // "" (plain FTP), "explicit" (AUTH TLS) or "implicit"
+	InsecureSkipVerify bool          `json:"insecure_skip_verify"`
+	Timeout            time.Duration `json:"-"`
+}
+
+// FTPClient is a FileSystem on an FTP or FTPS server. Metadata operations share one
+// control connection; every opened file gets its own connection so several
+// transfers, including copies within the same server, can run at once.
+type FTPClient struct {
+	cfg  FTPConfig
+	mu   sync.Mutex
+	conn *ftpConn
+}
+
+// ftpConn is one logged-in control connection
+type ftpConn struct {
+	cfg       *FTPConfig
+	raw       net.Conn
+	text      *textproto.Conn
+	tlsConfig *tls.Config // Set when data connections are encrypted
+	host      string      // Server address for passive data connections
+	mlst      bool        // Server supports MLSD/MLST machine-readable listings
+	home      string
+}
+
+// NewFTPClient connects and logs in to an FTP server
+func NewFTPClient(cfg *FTPConfig) (*FTPClient, error) {
+	fc := &FTPClient{cfg: *cfg}
+	if fc.cfg.Timeout == 0 {
+		fc.cfg.Timeout = 30 * time.Second
+	}
+	if fc.cfg.Port == 0 {
+		fc.cfg.Port = 21
+		if fc.cfg.TLS == "implicit" {
+			fc.cfg.Port = 990
+		}
+	}
+	if fc.cfg.User == "" {
+		fc.cfg.User = "anonymous"
+	}
+
+	conn, err := dialFTP(&fc.cfg)
+	if err != nil {
+		return nil, err
+	}
+	fc.conn = conn
+	return fc, nil
+}
+
+func dialFTP(cfg *FTPConfig) (*ftpConn, error) {
+	address := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	tlsConfig := &tls.Config{
+		ServerName:         cfg.Host,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		// Servers commonly require data connections to resume the control connection's TLS session
+		ClientSessionCache: tls.NewLRUClientSessionCache(4),
+	}
+
+	var raw net.Conn
+	var err error
+	if cfg.TLS == "implicit" {
+		raw, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		raw, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	c := &ftpConn{cfg: cfg, raw: raw, text: textproto.NewConn(raw)}
+	c.host, _, _ = net.SplitHostPort(raw.RemoteAddr().String())
+
+	if err := c.login(tlsConfig); err != nil {
+		raw.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *ftpConn) login(tlsConfig *tls.Config) error {
+	if _, _, err := c.readReply(220); err != nil {
+		return fmt.Errorf("failed to read FTP greeting: %w", err)
+	}
+
+	if c.cfg.TLS == "explicit" {
+		if _, _, err := c.cmd(234, "AUTH TLS"); err != nil {
+			return fmt.Errorf("server does not support TLS: %w", err)
+		}
+		tlsConn := tls.Client(c.raw, tlsConfig)
+		tlsConn.SetDeadline(time.Now().Add(c.cfg.Timeout))
+		if err := tlsConn.Handshake(); err != nil {
+			return fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		c.raw = tlsConn
+		c.text = textproto.NewConn(tlsConn)
+	}
+
+	code, _, err := c.cmd(0, "USER %s", c.cfg.User)
+	if err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+	if code == 331 {
+		code, _, err = c.cmd(2, "PASS %s", c.cfg.Password)
+		if err != nil {
+			return fmt.Errorf("login failed: %w", err)
+		}
+	} else if code/100 != 2 {
+		return fmt.Errorf("login failed: unexpected reply %d", code)
+	}
+
+	if c.cfg.TLS != "" {
+		if _, _, err := c.cmd(200, "PBSZ 0"); err != nil {
+			return fmt.Errorf("failed to set protection buffer: %w", err)
+		}
+		if _, _, err := c.cmd(200, "PROT P"); err != nil {
+			return fmt.Errorf("failed to protect data connections: %w", err)
+		}
+		c.tlsConfig = tlsConfig
+	}
+
+	if _, _, err := c.cmd(200, "TYPE I"); err != nil {
+		return fmt.Errorf("failed to select binary mode: %w", err)
+	}
+
+	// Optional features; older servers reject FEAT
+	if _, features, err := c.cmd(211, "FEAT"); err == nil {
+		for _, line := range strings.Split(features, "\n") {
+			feature := strings.ToUpper(strings.TrimSpace(line))
+			if strings.HasPrefix(feature, "MLST") {
+				c.mlst = true
+			}
+			if feature == "UTF8" {
+				c.cmd(0, "OPTS UTF8 ON")
+			}
+		}
+	}
+
+	c.home = "/"
+	if _, message, err := c.cmd(257, "PWD"); err == nil {
+		if start, end := strings.Index(message, `"`), strings.LastIndex(message, `"`); end > start {
+			c.home = strings.ReplaceAll(message[start+1:end], `""`, `"`)
+		}
+	}
+
+	return nil
+}
+
+// cmd sends a command and reads its reply; expect is a full code, a leading digit or 0 for any
+func (c *ftpConn) cmd(expect int, format string, args ...interface{}) (int, string, error) {
+	c.raw.SetDeadline(time.Now().Add(c.cfg.Timeout))
+	if err := c.text.PrintfLine(format, args...); err != nil {
+		return 0, "", err
+	}
+	return c.readReply(expect)
+}
+
+func (c *ftpConn) readReply(expect int) (int, string, error) {
+	c.raw.SetDeadline(time.Now().Add(c.cfg.Timeout))
+	return c.text.ReadResponse(expect)
+}
+
+func (c *ftpConn) close() error {
+	c.cmd(0, "QUIT")
+	return c.raw.Close()
+}
+
+// epsvPort matches the port in an EPSV reply such as "229 Entering Extended Passive Mode (|||6446|)"
+var epsvPort = regexp.MustCompile(`\(\|\|\|(\d+)\|\)`)
+
+// pasvAddress matches the address in a PASV reply such as "227 Entering Passive Mode (h1,h2,h3,h4,p1,p2)"
+var pasvAddress = regexp.MustCompile(`(\d+),(\d+),(\d+),(\d+),(\d+),(\d+)`)
+
+// dataCommand opens a passive data connection and sends a command that uses it
+func (c *ftpConn) dataCommand(format string, args ...interface{}) (net.Conn, error) {
+	var port int
+	if _, message, err := c.cmd(229, "EPSV"); err == nil {
+		if match := epsvPort.FindStringSubmatch(message); match != nil {
+			port, _ = strconv.Atoi(match[1])
+		}
+	}
+	if port == 0 {
+		_, message, err := c.cmd(227, "PASV")
+		if err != nil {
+			return nil, fmt.Errorf("failed to enter passive mode: %w", err)
+		}
+		match := pasvAddress.FindStringSubmatch(message)
+		if match == nil {
+			return nil, fmt.Errorf("invalid passive mode reply: %s", message)
+		}
+		high, _ := strconv.Atoi(match[5])
+		low, _ := strconv.Atoi(match[6])
+		// The advertised IP is ignored; behind NAT it is often a private address
+		port = high<<8 | low
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(c.host, strconv.Itoa(port)), c.cfg.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open data connection: %w", err)
+	}
+
+	if _, _, err := c.cmd(1, format, args...); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if c.tlsConfig != nil {
+		conn = tls.Client(conn, c.tlsConfig)
+	}
+	return conn, nil
+}
+
+// readAll runs a command whose data connection carries text, such as a listing
+func (c *ftpConn) readAll(format string, args ...interface{}) (string, error) {
+	conn, err := c.dataCommand(format, args...)
+	if err != nil {
+		return "", err
+	}
+
+	conn.SetDeadline(time.Now().Add(c.cfg.Timeout))
+	data, readErr := io.ReadAll(conn)
+	conn.Close()
+
+	if _, _, err := c.readReply(2); err != nil {
+		return "", err
+	}
+	if readErr != nil {
+		return "", readErr
+	}
+	return string(data), nil
+}
+
+// do runs a read-only fn on the shared control connection, running it again on a new
+// connection if the server dropped the old one
+func (fc *FTPClient) do(fn func(c *ftpConn) error) error {
+	return fc.run(fn, true)
+}
+
+// doOnce runs fn, which changes files on the server, on the shared control connection.
+// It is never repeated since it may have taken effect before the connection failed, so
+// an idle connection is checked with NOOP first.
+func (fc *FTPClient) doOnce(fn func(c *ftpConn) error) error {
+	return fc.run(fn, false)
+}
+
+// run runs fn on the shared control connection; retry allows running it again after a
+// connection-level failure
+func (fc *FTPClient) run(fn func(c *ftpConn) error, retry bool) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	if fc.conn != nil && !retry {
+		if _, _, err := fc.conn.cmd(200, "NOOP"); err != nil {
+			fc.conn.raw.Close()
+			fc.conn = nil
+		}
+	}
+	if fc.conn == nil {
+		conn, err := dialFTP(&fc.cfg)
+		if err != nil {
+			return err
+		}
+		fc.conn = conn
+	}
+
+	err := fn(fc.conn)
+	if err == nil || !isFTPConnError(err) {
+		return err
+	}
+
+	// Idle connections are commonly closed by the server (421 or a reset)
+	fc.conn.raw.Close()
+	fc.conn = nil
+	if !retry {
+		return err
+	}
+	conn, dialErr := dialFTP(&fc.cfg)
+	if dialErr != nil {
+		return err
+	}
+	fc.conn = conn
+	return fn(fc.conn)
+}
+
+// isFTPConnError reports whether err means the control connection is gone, as opposed
+// to the server refusing a command or a reply that could not be parsed
+func isFTPConnError(err error) bool {
+	var protocolErr *textproto.Error
+	if errors.As(err, &protocolErr) {
+		return protocolErr.Code == 421
+	}
+	var netErr net.Error
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) || errors.As(err, &netErr)
+}
+
+// ftpPath resolves an empty path to the login directory and cleans the rest
+func (fc *FTPClient) ftpPath(c *ftpConn, p string) string {
+	if p == "" {
+		return c.home
+	}
+	if !strings.HasPrefix(p, "/") {
+		p = path.Join(c.home, p)
+	}
+	return path.Clean(p)
+}
+
+// ListDirectory lists files in a directory
+func (fc *FTPClient) ListDirectory(dirPath string) ([]FileInfo, error) {
+	var result []FileInfo
+	err := fc.do(func(c *ftpConn) error {
+		dirPath := fc.ftpPath(c, dirPath)
+
+		var listing string
+		var err error
+		if c.mlst {
+			listing, err = c.readAll("MLSD %s", dirPath)
+		} else {
+			listing, err = c.readAll("LIST -a %s", dirPath)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read directory: %w", err)
+		}
+
+		result = make([]FileInfo, 0)
+		for _, line := range strings.Split(listing, "\n") {
+			line = strings.TrimRight(line, "\r")
+			var info *FileInfo
+			if c.mlst {
+				info = parseMLSxLine(line)
+			} else {
+				info = parseListLine(line, time.Now())
+			}
+			if info == nil || info.Name == "." || info.Name == ".." {
+				continue
+			}
+			info.Path = path.Join(dirPath, info.Name)
+			result = append(result, *info)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// GetFileInfo gets information about a specific file
+func (fc *FTPClient) GetFileInfo(filePath string) (*FileInfo, error) {
+	var result *FileInfo
+	err := fc.do(func(c *ftpConn) error {
+		filePath := fc.ftpPath(c, filePath)
+
+		if filePath == "/" {
+			result = &FileInfo{Name: "/", Path: "/", IsDir: true, Mode: (os.ModeDir | 0755).String()}
+			return nil
+		}
+
+		if c.mlst {
+			_, message, err := c.cmd(250, "MLST %s", filePath)
+			if err != nil {
+				return fmt.Errorf("failed to stat file: %w", err)
+			}
+			for _, line := range strings.Split(message, "\n") {
+				if info := parseMLSxLine(strings.TrimSpace(line)); info != nil {
+					info.Name = path.Base(filePath)
+					info.Path = filePath
+					result = info
+					return nil
+				}
+			}
+			return fmt.Errorf("failed to stat file: invalid MLST reply")
+		}
+
+		// Without MLST the entry is looked up in its parent's listing
+		listing, err := c.readAll("LIST -a %s", path.Dir(filePath))
+		if err != nil {
+			return fmt.Errorf("failed to stat file: %w", err)
+		}
+		for _, line := range strings.Split(listing, "\n") {
+			if info := parseListLine(strings.TrimRight(line, "\r"), time.Now()); info != nil && info.Name == path.Base(filePath) {
+				info.Path = filePath
+				result = info
+				return nil
+			}
+		}
+		return fmt.Errorf("failed to stat file: %w", os.ErrNotExist)
+	})
+	return result, err
+}
+
+// ftpTransfer is an open file: a data connection on its own control connection
+type ftpTransfer struct {
+	ctrl   *ftpConn
+	data   net.Conn
+	closed bool
+}
+
+func (t *ftpTransfer) Read(p []byte) (int, error) {
+	t.data.SetDeadline(time.Now().Add(t.ctrl.cfg.Timeout))
+	return t.data.Read(p)
+}
+
+func (t *ftpTransfer) Write(p []byte) (int, error) {
+	t.data.SetDeadline(time.Now().Add(t.ctrl.cfg.Timeout))
+	return t.data.Write(p)
+}
+
+// Close ends the data connection and waits for the server to confirm the transfer
+func (t *ftpTransfer) Close() error {
+	if t.closed {
+		return nil
+	}
+	t.closed = true
+
+	err := t.data.Close()
+	if _, _, replyErr := t.ctrl.readReply(2); replyErr != nil {
+		err = replyErr
+	}
+	t.ctrl.close()
+	return err
+}
+
+// openTransfer starts RETR or STOR on a dedicated connection
+func (fc *FTPClient) openTransfer(command, filePath string) (*ftpTransfer, error) {
+	ctrl, err := dialFTP(&fc.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ctrl.dataCommand("%s %s", command, fc.ftpPath(ctrl, filePath))
+	if err != nil {
+		ctrl.close()
+		return nil, err
+	}
+	return &ftpTransfer{ctrl: ctrl, data: data}, nil
+}
+
+// OpenFile opens a file for reading
+func (fc *FTPClient) OpenFile(filePath string) (io.ReadCloser, error) {
+	transfer, err := fc.openTransfer("RETR", filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open remote file: %w", err)
+	}
+	return transfer, nil
+}
+
+// CreateFile creates or truncates a file for writing
+func (fc *FTPClient) CreateFile(filePath string) (io.WriteCloser, error) {
+	transfer, err := fc.openTransfer("STOR", filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create remote file: %w", err)
+	}
+	return transfer, nil
+}
+
+// RenameFile renames a file or directory
+func (fc *FTPClient) RenameFile(oldPath, newPath string) error {
+	return fc.doOnce(func(c *ftpConn) error {
+		if _, _, err := c.cmd(350, "RNFR %s", fc.ftpPath(c, oldPath)); err != nil {
+			return fmt.Errorf("failed to rename: %w", err)
+		}
+		if _, _, err := c.cmd(250, "RNTO %s", fc.ftpPath(c, newPath)); err != nil {
+			return fmt.Errorf("failed to rename: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteFile deletes a file
+func (fc *FTPClient) DeleteFile(filePath string) error {
+	return fc.doOnce(func(c *ftpConn) error {
+		if _, _, err := c.cmd(250, "DELE %s", fc.ftpPath(c, filePath)); err != nil {
+			return fmt.Errorf("failed to delete file: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteDirectory deletes a directory recursively
+func (fc *FTPClient) DeleteDirectory(dirPath string) error {
+	files, err := fc.ListDirectory(dirPath)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if file.IsDir && !file.IsSymlink {
+			err = fc.DeleteDirectory(file.Path)
+		} else {
+			err = fc.DeleteFile(file.Path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return fc.doOnce(func(c *ftpConn) error {
+		dirPath := fc.ftpPath(c, dirPath)
+		if _, _, err := c.cmd(250, "RMD %s", dirPath); err != nil {
+			return fmt.Errorf("failed to delete directory %s: %w", dirPath, err)
+		}
+		return nil
+	})
+}
+
+// CreateDirectory creates a directory
+func (fc *FTPClient) CreateDirectory(dirPath string) error {
+	return fc.doOnce(func(c *ftpConn) error {
+		if _, _, err := c.cmd(257, "MKD %s", fc.ftpPath(c, dirPath)); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		return nil
+	})
+}
+
+// Close logs out and closes the control connection
+func (fc *FTPClient) Close() error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	if fc.conn == nil {
+		return nil
+	}
+	err := fc.conn.close()
+	fc.conn = nil
+	return err
+}
+
+// parseMLSxLine parses an MLSD/MLST entry such as "type=file;size=42;modify=20240102150405; name"
+func parseMLSxLine(line string) *FileInfo {
+	facts, name, ok := strings.Cut(line, " ")
+	if !ok || name == "" || !strings.Contains(facts, "=") {
+		return nil
+	}
+
+	info := &FileInfo{Name: name}
+	perm := os.FileMode(0644)
+	for _, fact := range strings.Split(facts, ";") {
+		key, value, _ := strings.Cut(fact, "=")
+		switch strings.ToLower(key) {
+		case "type":
+			switch strings.ToLower(value) {
+			case "dir", "cdir", "pdir":
+				info.IsDir = true
+				if strings.ToLower(value) != "dir" {
+					// The directory itself or its parent
+					info.Name = "."
+				}
+			case "os.unix=symlink", "os.unix=slink":
+				info.IsSymlink = true
+			}
+			if target, found := strings.CutPrefix(strings.ToLower(value), "os.unix=slink:"); found {
+				info.IsSymlink = true
+				info.LinkTarget = value[len(value)-len(target):]
+			}
+		case "size", "sizd":
+			info.Size, _ = strconv.ParseInt(value, 10, 64)
+		case "modify":
+			if modTime, err := time.Parse("20060102150405", value[:min(int64(len(value)), 14)]); err == nil {
+				info.ModTime = modTime
+			}
+		case "unix.mode":
+			if mode, err := strconv.ParseUint(value, 8, 32); err == nil {
+				perm = os.FileMode(mode).Perm()
+			}
+		}
+	}
+
+	mode := perm
+	if info.IsDir {
+		mode |= os.ModeDir
+		if perm == 0644 {
+			mode = os.ModeDir | 0755
+		}
+	}
+	if info.IsSymlink {
+		mode |= os.ModeSymlink
+	}
+	info.Mode = mode.String()
+	return info
+}
+
+// unixListLine matches "ls -l" style LIST output, e.g.
+// "drwxr-xr-x 2 user group 4096 Jan  2 15:04 name" or "... Jan  2  2006 name"
+var unixListLine = regexp.MustCompile(`^([-dlbcps][-rwxsStT]{9})[+@.]?\s+\d+\s+\S+\s+\S+\s+(\d+)\s+(\w{3})\s+(\d{1,2})\s+(\d{1,2}:\d{2}|\d{4})\s(.+)$`)
+
+// dosListLine matches IIS style LIST output, e.g. "01-02-06  03:04PM  <DIR>  name"
+var dosListLine = regexp.MustCompile(`^(\d{2}-\d{2}-\d{2,4})\s+(\d{1,2}:\d{2}[AP]M)\s+(<DIR>|\d+)\s+(.+)$`)
+
+// parseListLine parses a line of LIST output; now resolves dates without a year
+func parseListLine(line string, now time.Time) *FileInfo {
+	if match := unixListLine.FindStringSubmatch(line); match != nil {
+		info := &FileInfo{Name: match[6], Mode: match[1]}
+		info.Size, _ = strconv.ParseInt(match[2], 10, 64)
+		info.IsDir = match[1][0] == 'd'
+		if match[1][0] == 'l' {
+			info.IsSymlink = true
+			if name, target, found := strings.Cut(info.Name, " -> "); found {
+				info.Name, info.LinkTarget = name, target
+			}
+		}
+
+		stamp := match[3] + " " + match[4] + " " + match[5]
+		if strings.Contains(match[5], ":") {
+			// Recent files show the time instead of the year
+			if modTime, err := time.Parse("Jan 2 15:04", stamp); err == nil {
+				modTime = modTime.AddDate(now.Year(), 0, 0)
+				if modTime.After(now.AddDate(0, 0, 1)) {
+					modTime = modTime.AddDate(-1, 0, 0)
+				}
+				info.ModTime = modTime
+			}
+		} else if modTime, err := time.Parse("Jan 2 2006", stamp); err == nil {
+			info.ModTime = modTime
+		}
+		return info
+	}
+
+	if match := dosListLine.FindStringSubmatch(line); match != nil {
+		info := &FileInfo{Name: match[4], Mode: os.FileMode(0644).String()}
+		if match[3] == "<DIR>" {
+			info.IsDir = true
+			info.Mode = (os.ModeDir | 0755).String()
+		} else {
+			info.Size, _ = strconv.ParseInt(match[3], 10, 64)
+		}
+		for _, layout := range []string{"01-02-06 03:04PM", "01-02-2006 03:04PM"} {
+			if modTime, err := time.Parse(layout, match[1]+" "+match[2]); err == nil {
+				info.ModTime = modTime
+				break
+			}
+		}
+		return info
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseMLSxLine(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		line string
+		want *FileInfo
+	}{
+		{"type=file;size=42;modify=20240102150405; notes.txt",
+			&FileInfo{Name: "notes.txt", Size: 42, Mode: "-rw-r--r--", ModTime: modTime}},
+		{"type=file;size=7;modify=20240102150405.123;UNIX.mode=0755; run me.sh",
+			&FileInfo{Name: "run me.sh", Size: 7, Mode: "-rwxr-xr-x", ModTime: modTime}},
+		{"type=dir;sizd=4096;modify=20240102150405; src",
+			&FileInfo{Name: "src", Size: 4096, Mode: "drwxr-xr-x", ModTime: modTime, IsDir: true}},
+		{"type=dir;unix.mode=0700; private",
+			&FileInfo{Name: "private", Mode: "drwx------", IsDir: true}},
+		{"type=cdir;modify=20240102150405; /home/user",
+			&FileInfo{Name: ".", Mode: "drwxr-xr-x", ModTime: modTime, IsDir: true}},
+		{"type=pdir; ..",
+			&FileInfo{Name: ".", Mode: "drwxr-xr-x", IsDir: true}},
+		{"type=OS.unix=slink:/etc/Target;size=11; link",
+			&FileInfo{Name: "link", Size: 11, Mode: "Lrw-r--r--", IsSymlink: true, LinkTarget: "/etc/Target"}},
+		{"type=os.unix=symlink; link", &FileInfo{Name: "link", Mode: "Lrw-r--r--", IsSymlink: true}},
+		{"", nil},
+		{"notes.txt", nil},
+		{"type=file;size=1;", nil},
+		{"no facts here", nil},
+	}
+	for _, test := range tests {
+		if got := parseMLSxLine(test.line); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseMLSxLine(%q) = %+v, want %+v", test.line, got, test.want)
+		}
+	}
+}
+
+func TestParseListLine(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		line string
+		want *FileInfo
+	}{
+		{"-rw-r--r--   1 user  group     1234 Mar  9 15:04 notes.txt",
+			&FileInfo{Name: "notes.txt", Size: 1234, Mode: "-rw-r--r--", ModTime: time.Date(2024, 3, 9, 15, 4, 0, 0, time.UTC)}},
+		// A time later than tomorrow belongs to last year
+		{"-rw-r--r--   1 user  group       10 Dec 24 08:30 old.txt",
+			&FileInfo{Name: "old.txt", Size: 10, Mode: "-rw-r--r--", ModTime: time.Date(2023, 12, 24, 8, 30, 0, 0, time.UTC)}},
+		{"drwxr-xr-x+  2 user  group     4096 Jan  2  2006 my dir",
+			&FileInfo{Name: "my dir", Size: 4096, Mode: "drwxr-xr-x", ModTime: time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC), IsDir: true}},
+		{"lrwxrwxrwx   1 root  root        11 Feb 29 23:59 link -> /etc/target",
+			&FileInfo{Name: "link", Mode: "lrwxrwxrwx", ModTime: time.Date(2024, 2, 29, 23, 59, 0, 0, time.UTC), Size: 11, IsSymlink: true, LinkTarget: "/etc/target"}},
+		{"01-02-06  03:04PM       <DIR>          Program Files",
+			&FileInfo{Name: "Program Files", Mode: "drwxr-xr-x", ModTime: time.Date(2006, 1, 2, 15, 4, 0, 0, time.UTC), IsDir: true}},
+		{"12-31-2023  09:15AM              512 report.doc",
+			&FileInfo{Name: "report.doc", Size: 512, Mode: "-rw-r--r--", ModTime: time.Date(2023, 12, 31, 9, 15, 0, 0, time.UTC)}},
+		{"total 12", nil},
+		{"", nil},
+		{"-rw-r--r-- user group 1234 notes.txt", nil},
+	}
+	for _, test := range tests {
+		if got := parseListLine(test.line, now); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseListLine(%q) = %+v, want %+v", test.line, got, test.want)
+		}
+	}
+}
+
+func TestIsFTPConnError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{io.EOF, true},
+		{fmt.Errorf("failed to rename: %w", io.ErrUnexpectedEOF), true},
+		{&net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}, true},
+		{&textproto.Error{Code: 421, Msg: "Timeout"}, true},
+		{fmt.Errorf("failed to delete file: %w", &textproto.Error{Code: 550, Msg: "No such file"}), false},
+		{fmt.Errorf("failed to stat file: %w", os.ErrNotExist), false},
+		{errors.New("failed to stat file: invalid MLST reply"), false},
+	}
+	for _, test := range tests {
+		if got := isFTPConnError(test.err); got != test.want {
+			t.Errorf("isFTPConnError(%v) = %v, want %v", test.err, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package ssh
+
+import (
+	"sort"
+	"sync"
+)
+
+// MetricsPoint holds the headline figures of a snapshot, as plotted in history charts.
+// Points can be averaged together, so one may stand for many snapshots.
+type MetricsPoint struct {
+	Timestamp  int64   `json:"timestamp"`
+	CPU        float64 `json:"cpu"`         // Overall CPU %
+	Memory     float64 `json:"memory"`      // Used memory %
+	MemoryUsed uint64  `json:"memory_used"` // bytes
+	SwapUsed   uint64  `json:"swap_used"`   // bytes
+	Load       float64 `json:"load"`        // 1 min load average
+	RxRate     float64 `json:"rx_rate"`     // bytes/sec
+	TxRate     float64 `json:"tx_rate"`     // bytes/sec
+	Disk       float64 `json:"disk"`        // Used % of the fullest partition
+	Samples    int     `json:"samples"`     // Snapshots averaged into this point
+}
+
+// NewMetricsPoint extracts the charted figures from a snapshot
+func NewMetricsPoint(data *MonitoringData) MetricsPoint {
+	point := MetricsPoint{
+		Timestamp:  data.Timestamp,
+		CPU:        data.CPU.Overall,
+		Memory:     data.Memory.UsedPercent,
+		MemoryUsed: data.Memory.Used,
+		SwapUsed:   data.Memory.SwapUsed,
+		RxRate:     data.Network.RxRate,
+		TxRate:     data.Network.TxRate,
+		Samples:    1,
+	}
+	if len(data.CPU.LoadAverage) > 0 {
+		point.Load = data.CPU.LoadAverage[0]
+	}
+	for _, partition := range data.Disk.Partitions {
+		point.Disk = max(point.Disk, partition.UsedPercent)
+	}
+	return point
+}
+
+// MetricsHistory keeps the most recent snapshots of a session in a fixed-size ring.
+// It is safe for concurrent use.
+type MetricsHistory struct {
+	mu    sync.Mutex
+	items []*MonitoringData
+	next  int // Index the next snapshot is written to
+	full  bool
+}
+
+// NewMetricsHistory creates a history holding up to capacity snapshots
+func NewMetricsHistory(capacity int) *MetricsHistory {
+	return &MetricsHistory{items: make([]*MonitoringData, max(capacity, 1))}
+}
+
+// Add records a snapshot, replacing the oldest once the ring is full
+func (h *MetricsHistory) Add(data *MonitoringData) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.items[h.next] = data
+	h.next = (h.next + 1) % len(h.items)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// Snapshots returns the snapshots taken between from and to (Unix seconds, inclusive),
+// oldest first
+func (h *MetricsHistory) Snapshots(from, to int64) []*MonitoringData {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ordered := h.items[:h.next]
+	if h.full {
+		ordered = append(append([]*MonitoringData{}, h.items[h.next:]...), h.items[:h.next]...)
+	}
+
+	result := make([]*MonitoringData, 0, len(ordered))
+	for _, data := range ordered {
+		if data.Timestamp >= from && data.Timestamp <= to {
+			result = append(result, data)
+		}
+	}
+	return result
+}
+
+// Oldest returns the timestamp of the oldest snapshot held, or 0 when empty
+func (h *MetricsHistory) Oldest() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.full {
+		return h.items[h.next].Timestamp
+	}
+	if h.next == 0 {
+		return 0
+	}
+	return h.items[0].Timestamp
+}
+
+// Latest returns the most recent snapshot, or nil when empty
+func (h *MetricsHistory) Latest() *MonitoringData {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.items[(h.next+len(h.items)-1)%len(h.items)]
+}
+
+// DownsamplePoints averages points into buckets of step seconds aligned to multiples of
+// step, each weighted by the snapshots it already stands for. The result is sorted.
+func DownsamplePoints(points []MetricsPoint, step int64) []MetricsPoint {
+	if step <= 1 {
+		sorted := append([]MetricsPoint{}, points...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].Timestamp < sorted[j].Timestamp })
+		return sorted
+	}
+
+	type bucket struct {
+		sum     MetricsPoint
+		memory  float64 // Weighted sums of the integer fields, kept as floats
+		swap    float64
+		samples int
+	}
+	buckets := make(map[int64]*bucket)
+	for _, p := range points {
+		key := p.Timestamp - p.Timestamp%step
+		b, ok := buckets[key]
+		if !ok {
+			b = &bucket{}
+			buckets[key] = b
+		}
+
+		weight := float64(max(p.Samples, 1))
+		b.sum.CPU += p.CPU * weight
+		b.sum.Memory += p.Memory * weight
+		b.sum.Load += p.Load * weight
+		b.sum.RxRate += p.RxRate * weight
+		b.sum.TxRate += p.TxRate * weight
+		b.sum.Disk += p.Disk * weight
+		b.memory += float64(p.MemoryUsed) * weight
+		b.swap += float64(p.SwapUsed) * weight
+		b.samples += max(p.Samples, 1)
+	}
+
+	result := make([]MetricsPoint, 0, len(buckets))
+	for key, b := range buckets {
+		n := float64(b.samples)
+		result = append(result, MetricsPoint{
+			Timestamp:  key,
+			CPU:        roundTo2Decimals(b.sum.CPU / n),
+			Memory:     roundTo2Decimals(b.sum.Memory / n),
+			MemoryUsed: uint64(b.memory / n),
+			SwapUsed:   uint64(b.swap / n),
+			Load:       roundTo2Decimals(b.sum.Load / n),
+			RxRate:     roundTo2Decimals(b.sum.RxRate / n),
+			TxRate:     roundTo2Decimals(b.sum.TxRate / n),
+			Disk:       roundTo2Decimals(b.sum.Disk / n),
+			Samples:    b.samples,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Timestamp < result[j].Timestamp })
+	return result
+}
+
+// MetricsRange is a stretch of history at a fixed resolution
+type MetricsRange struct {
+	From   int64          `json:"from"`
+	To     int64          `json:"to"`
+	Step   int64          `json:"step"`   // Seconds between points
+	Points []MetricsPoint `json:"points"` // Buckets without snapshots are left out
+}
@@ -0,0 +1,46 @@
+package ssh
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMetricsHistory(t *testing.T) {
+	history := NewMetricsHistory(3)
+	if history.Oldest() != 0 || len(history.Snapshots(0, 100)) != 0 {
+		t.Fatal("new history is not empty")
+	}
+
+	for ts := int64(1); ts <= 5; ts++ {
+		history.Add(&MonitoringData{Timestamp: ts})
+	}
+
+	// The ring keeps the last three snapshots, oldest first
+	var got []int64
+	for _, data := range history.Snapshots(0, 100) {
+		got = append(got, data.Timestamp)
+	}
+	if !reflect.DeepEqual(got, []int64{3, 4, 5}) || history.Oldest() != 3 {
+		t.Errorf("snapshots = %v, oldest = %d", got, history.Oldest())
+	}
+	if snapshots := history.Snapshots(4, 4); len(snapshots) != 1 || snapshots[0].Timestamp != 4 {
+		t.Errorf("range 4-4 = %v", snapshots)
+	}
+}
+
+func TestDownsamplePoints(t *testing.T) {
+	points := []MetricsPoint{
+		{Timestamp: 125, CPU: 30, MemoryUsed: 300, Samples: 1},
+		{Timestamp: 61, CPU: 10, MemoryUsed: 100, Samples: 1},
+		{Timestamp: 119, CPU: 40, MemoryUsed: 400, Samples: 3}, // Already an average of three snapshots
+	}
+
+	got := DownsamplePoints(points, 60)
+	want := []MetricsPoint{
+		{Timestamp: 60, CPU: 32.5, MemoryUsed: 325, Samples: 4},
+		{Timestamp: 120, CPU: 30, MemoryUsed: 300, Samples: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DownsamplePoints = %+v, want %+v", got, want)
+	}
+}
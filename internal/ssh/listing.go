@@ -0,0 +1,263 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// listingCacheTTL is how long a directory listing is reused; our own writes invalidate it sooner
+	listingCacheTTL = 10 * time.Second
+
+	// symlinkBatchSize is how many ReadLink requests are in flight at once
+	symlinkBatchSize = 32
+)
+
+// ListOptions selects, sorts and pages a directory listing
+type ListOptions struct {
+	Offset     int    `json:"offset"`
+	Limit      int    `json:"limit"`       // 0 = all remaining entries
+	SortBy     string `json:"sort_by"`     // "name" (default), "size", "mod_time" or "type"
+	SortOrder  string `json:"sort_order"`  // "asc" (default) or "desc"
+	DirsFirst  bool   `json:"dirs_first"`  // List directories before files regardless of order
+	HideHidden bool   `json:"hide_hidden"` // Leave out dotfiles
+	Refresh    bool   `json:"refresh"`     // Re-read the directory instead of using the cache
+}
+
+// DirectoryPage is one page of a sorted directory listing
+type DirectoryPage struct {
+	Path    string     `json:"path"`
+	Files   []FileInfo `json:"files"`
+	Offset  int        `json:"offset"`
+	Total   int        `json:"total"` // Entries matching the options across all pages
+	HasMore bool       `json:"has_more"`
+}
+
+// cachedListing is a directory read from the server, with sorted views built on demand
+type cachedListing struct {
+	files  []FileInfo
+	readAt time.Time
+	sorted map[string][]FileInfo // sort key -> view
+}
+
+// readDirectory reads a directory into the cache without resolving symlink targets.
+// The client mutex is not held, so a huge directory does not block other operations.
+func (sc *SFTPClient) readDirectory(ctx context.Context, dirPath string) (*cachedListing, error) {
+	sc.listMu.Lock()
+	gen := sc.listGen
+	sc.listMu.Unlock()
+
+	files, err := sc.client.ReadDirContext(ctx, dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	result := make([]FileInfo, 0, len(files))
+	for _, file := range files {
+		result = append(result, FileInfo{
+			Name:      file.Name(),
+			Path:      path.Join(dirPath, file.Name()),
+			Size:      file.Size(),
+			Mode:      file.Mode().String(),
+			ModTime:   file.ModTime(),
+			IsDir:     file.IsDir(),
+			IsSymlink: file.Mode()&os.ModeSymlink != 0,
+		})
+	}
+
+	listing := &cachedListing{files: result, readAt: time.Now(), sorted: make(map[string][]FileInfo)}
+
+	// A listing read while something was invalidated may predate that change, so it is
+	// returned but not cached
+	sc.listMu.Lock()
+	if sc.listGen == gen {
+		if sc.listings == nil {
+			sc.listings = make(map[string]*cachedListing)
+		}
+		sc.listings[dirPath] = listing
+	}
+	sc.listMu.Unlock()
+
+	return listing, nil
+}
+
+// ListDirectoryPage returns one page of a directory, sorted on this side so the frontend
+// only receives what it shows. Listings are cached briefly, so paging through a huge
+// directory reads it once; symlink targets are only resolved for the returned page.
+func (sc *SFTPClient) ListDirectoryPage(ctx context.Context, dirPath string, opts ListOptions) (*DirectoryPage, error) {
+	if dirPath == "" {
+		dirPath = sc.GetCurrentPath()
+	}
+	dirPath = normalizePath(dirPath)
+
+	view, err := sc.sortedListing(ctx, dirPath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	page := pageListing(view, opts)
+	page.Path = dirPath
+	sc.resolveSymlinks(ctx, page.Files)
+	return page, nil
+}
+
+// sortedListing returns the cached listing sorted and filtered for opts, reading it if needed
+func (sc *SFTPClient) sortedListing(ctx context.Context, dirPath string, opts ListOptions) ([]FileInfo, error) {
+	key := listingSortKey(opts)
+
+	sc.listMu.Lock()
+	listing, ok := sc.listings[dirPath]
+	if ok && !opts.Refresh && time.Since(listing.readAt) < listingCacheTTL {
+		if view, ok := listing.sorted[key]; ok {
+			sc.listMu.Unlock()
+			return view, nil
+		}
+	} else {
+		listing = nil
+	}
+	sc.listMu.Unlock()
+
+	if listing == nil {
+		var err error
+		if listing, err = sc.readDirectory(ctx, dirPath); err != nil {
+			return nil, err
+		}
+	}
+
+	view := sortListing(listing.files, opts)
+
+	sc.listMu.Lock()
+	listing.sorted[key] = view
+	sc.listMu.Unlock()
+
+	return view, nil
+}
+
+// InvalidateListings drops cached listings affected by a change to the given paths:
+// their parent directories, and the paths themselves with everything below them
+func (sc *SFTPClient) InvalidateListings(paths ...string) {
+	sc.listMu.Lock()
+	defer sc.listMu.Unlock()
+
+	sc.listGen++
+	for _, p := range paths {
+		p = normalizePath(p)
+		delete(sc.listings, path.Dir(p))
+		for dirPath := range sc.listings {
+			if dirPath == p || strings.HasPrefix(dirPath, p+"/") || p == "/" {
+				delete(sc.listings, dirPath)
+			}
+		}
+	}
+}
+
+// resolveSymlinks fills in link targets, keeping a batch of requests in flight at once
+func (sc *SFTPClient) resolveSymlinks(ctx context.Context, files []FileInfo) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, symlinkBatchSize)
+
+	for i := range files {
+		if !files[i].IsSymlink || files[i].LinkTarget != "" {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(file *FileInfo) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if target, err := sc.client.ReadLink(file.Path); err == nil {
+				file.LinkTarget = target
+			}
+		}(&files[i])
+	}
+
+	wg.Wait()
+}
+
+// listingSortKey identifies a sorted, filtered view of a listing
+func listingSortKey(opts ListOptions) string {
+	return fmt.Sprintf("%s/%s/%t/%t", opts.SortBy, opts.SortOrder, opts.DirsFirst, opts.HideHidden)
+}
+
+// sortListing returns a sorted copy of files, without hidden entries if requested
+func sortListing(files []FileInfo, opts ListOptions) []FileInfo {
+	view := make([]FileInfo, 0, len(files))
+	for _, file := range files {
+		if opts.HideHidden && strings.HasPrefix(file.Name, ".") {
+			continue
+		}
+		view = append(view, file)
+	}
+
+	less := func(a, b *FileInfo) bool {
+		switch opts.SortBy {
+		case "size":
+			if a.Size != b.Size {
+				return a.Size < b.Size
+			}
+		case "mod_time":
+			if !a.ModTime.Equal(b.ModTime) {
+				return a.ModTime.Before(b.ModTime)
+			}
+		case "type":
+			if extA, extB := strings.ToLower(path.Ext(a.Name)), strings.ToLower(path.Ext(b.Name)); extA != extB {
+				return extA < extB
+			}
+		}
+		// Names break ties, case-insensitively and then exactly for a stable order
+		if nameA, nameB := strings.ToLower(a.Name), strings.ToLower(b.Name); nameA != nameB {
+			return nameA < nameB
+		}
+		return a.Name < b.Name
+	}
+
+	descending := opts.SortOrder == "desc"
+	sort.Slice(view, func(i, j int) bool {
+		a, b := &view[i], &view[j]
+		if opts.DirsFirst && a.IsDir != b.IsDir {
+			return a.IsDir
+		}
+		if descending {
+			return less(b, a)
+		}
+		return less(a, b)
+	})
+
+	return view
+}
+
+// pageListing cuts a page out of a sorted listing. The page is a copy, so resolving
+// symlinks on it does not race with other readers of the cached view.
+func pageListing(view []FileInfo, opts ListOptions) *DirectoryPage {
+	start := min(int64(max(opts.Offset, 0)), int64(len(view)))
+	end := int64(len(view))
+	if opts.Limit > 0 {
+		end = min(start+int64(opts.Limit), end)
+	}
+
+	return &DirectoryPage{
+		Files:   append([]FileInfo{}, view[start:end]...),
+		Offset:  int(start),
+		Total:   len(view),
+		HasMore: end < int64(len(view)),
+	}
+}
+
+// PageFiles sorts and pages a complete listing, for backends without their own paging
+func PageFiles(dirPath string, files []FileInfo, opts ListOptions) *DirectoryPage {
+	page := pageListing(sortListing(files, opts), opts)
+	page.Path = dirPath
+	return page
+}
@@ -0,0 +1,150 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// LocalFileSystemID identifies the local disk wherever a session ID selects a filesystem
+const LocalFileSystemID = "local"
+
+// LocalFileSystem exposes the local disk as a FileSystem
+type LocalFileSystem struct{}
+
+// NewLocalFileSystem creates a local filesystem backend
+func NewLocalFileSystem() *LocalFileSystem {
+	return &LocalFileSystem{}
+}
+
+// localPath converts a path that may use "/" separators to the native form; "" is the home directory
+func localPath(p string) string {
+	if p == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			return home
+		}
+		return string(filepath.Separator)
+	}
+	return filepath.Clean(filepath.FromSlash(p))
+}
+
+func localFileInfo(filePath string, stat os.FileInfo) FileInfo {
+	info := FileInfo{
+		Name:      stat.Name(),
+		Path:      filePath,
+		Size:      stat.Size(),
+		Mode:      stat.Mode().String(),
+		ModTime:   stat.ModTime(),
+		IsDir:     stat.IsDir(),
+		IsSymlink: stat.Mode()&os.ModeSymlink != 0,
+	}
+
+	if info.IsSymlink {
+		if target, err := os.Readlink(filePath); err == nil {
+			info.LinkTarget = target
+		}
+		// Links to directories are browsed like directories, as over SFTP
+		if targetStat, err := os.Stat(filePath); err == nil {
+			info.IsDir = targetStat.IsDir()
+		}
+	}
+
+	return info
+}
+
+// ListDirectory lists files in a local directory
+func (lfs *LocalFileSystem) ListDirectory(dirPath string) ([]FileInfo, error) {
+	dirPath = localPath(dirPath)
+
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	result := make([]FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		stat, err := entry.Info()
+		if err != nil {
+			// The entry disappeared while listing
+			continue
+		}
+		result = append(result, localFileInfo(filepath.Join(dirPath, entry.Name()), stat))
+	}
+
+	return result, nil
+}
+
+// GetFileInfo gets information about a local file
+func (lfs *LocalFileSystem) GetFileInfo(filePath string) (*FileInfo, error) {
+	filePath = localPath(filePath)
+
+	stat, err := os.Lstat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	info := localFileInfo(filePath, stat)
+	return &info, nil
+}
+
+// OpenFile opens a local file for reading
+func (lfs *LocalFileSystem) OpenFile(filePath string) (io.ReadCloser, error) {
+	file, err := os.Open(localPath(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open local file: %w", err)
+	}
+	return file, nil
+}
+
+// CreateFile creates or truncates a local file for writing
+func (lfs *LocalFileSystem) CreateFile(filePath string) (io.WriteCloser, error) {
+	file, err := os.Create(localPath(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create local file: %w", err)
+	}
+	return file, nil
+}
+
+// RenameFile renames a local file or directory
+func (lfs *LocalFileSystem) RenameFile(oldPath, newPath string) error {
+	if err := os.Rename(localPath(oldPath), localPath(newPath)); err != nil {
+		return fmt.Errorf("failed to rename: %w", err)
+	}
+	return nil
+}
+
+// DeleteFile deletes a local file
+func (lfs *LocalFileSystem) DeleteFile(filePath string) error {
+	if err := os.Remove(localPath(filePath)); err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+// DeleteDirectory deletes a local directory recursively
+func (lfs *LocalFileSystem) DeleteDirectory(dirPath string) error {
+	dirPath = localPath(dirPath)
+
+	// Refuse to wipe a filesystem root by accident
+	if filepath.Dir(dirPath) == dirPath {
+		return fmt.Errorf("refusing to delete root directory %s", dirPath)
+	}
+	if err := os.RemoveAll(dirPath); err != nil {
+		return fmt.Errorf("failed to delete directory %s: %w", dirPath, err)
+	}
+	return nil
+}
+
+// CreateDirectory creates a local directory
+func (lfs *LocalFileSystem) CreateDirectory(dirPath string) error {
+	if err := os.Mkdir(localPath(dirPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	return nil
+}
+
+// Close is a no-op for the local disk
+func (lfs *LocalFileSystem) Close() error {
+	return nil
+}
@@ -6,14 +6,23 @@ import (
 	"path"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // SessionManager manages multiple SSH sessions
 type SessionManager struct {
-	mu          sync.RWMutex
-	sessions    map[string]*ManagedSession
-	sftpClients map[string]*SFTPClient
+	mu             sync.RWMutex
+	sessions       map[string]*ManagedSession
+	sftpClients    map[string]*SFTPClient
+	transferChunks int // Applied to every SFTP client, 0 = DefaultTransferChunks
+
+	zmodemHandler   *ZmodemHandler
+	trzszHandler    *TrzszHandler
+	transferManager *TransferManager
+
+	forwardsMu sync.Mutex
+	forwards   map[string]*portForward // Port forward ID -> forward
 }
 
 // SessionType represents the type of session (SSH or local)
@@ -38,6 +47,7 @@ type ManagedSession struct {
 	prevCwd  string
 	homeCwd  string
 	inputBuf string
+	transfer atomic.Pointer[terminalStream] // Running rz/sz or trzsz exchange, if any
 }
 
 // NewSessionManager creates a new session manager
@@ -45,6 +55,7 @@ func NewSessionManager() *SessionManager {
 	return &SessionManager{
 		sessions:    make(map[string]*ManagedSession),
 		sftpClients: make(map[string]*SFTPClient),
+		forwards:    make(map[string]*portForward),
 	}
 }
 
@@ -110,6 +121,8 @@ func (sm *SessionManager) StartShell(sessionID string, cols, rows int, onOutput
 
 	managed.Running = true
 
+	output := newTerminalOutput(sm, managed, managed.Session, onOutput)
+
 	// Start reading output
 	go func() {
 		buf := make([]byte, 1024)
@@ -125,8 +138,8 @@ func (sm *SessionManager) StartShell(sessionID string, cols, rows int, onOutput
 					}
 					return
 				}
-				if n > 0 && onOutput != nil {
-					onOutput(buf[:n])
+				if n > 0 {
+					output.write(buf[:n])
 				}
 			}
 		}
@@ -145,6 +158,10 @@ func (sm *SessionManager) WriteToSession(sessionID string, data []byte) error {
 		return fmt.Errorf("session not found: %s", sessionID)
 	}
 
+	if interceptInput(managed, data) {
+		return nil
+	}
+
 	_, err := managed.Session.Write(data)
 	if err != nil {
 		return err
@@ -178,8 +195,10 @@ func (sm *SessionManager) CloseSession(sessionID string) error {
 	}
 
 	close(managed.stopChan)
+	sm.stopPortForwards(sessionID)
 
 	if sftpClient, exists := sm.sftpClients[sessionID]; exists {
+		sftpClient.StopWatches()
 		sftpClient.Close()
 		delete(sm.sftpClients, sessionID)
 	}
@@ -635,12 +654,78 @@ func (sm *SessionManager) GetOrCreateSFTPClient(sessionID string) (*SFTPClient,
 		return nil, fmt.Errorf("failed to create SFTP client: %w", err)
 	}
 
+	sftpClient.SetTransferChunks(sm.transferChunks)
+
 	// Store SFTP client
 	sm.sftpClients[sessionID] = sftpClient
 
 	return sftpClient, nil
 }
 
+// GetFileTransferer returns the session's SFTP client, or an SCP client when the
+// server has no SFTP subsystem
+func (sm *SessionManager) GetFileTransferer(sessionID string) (FileTransferer, error) {
+	sftpClient, sftpErr := sm.GetOrCreateSFTPClient(sessionID)
+	if sftpErr == nil {
+		return sftpClient, nil
+	}
+
+	sm.mu.RLock()
+	managed, exists := sm.sessions[sessionID]
+	sm.mu.RUnlock()
+
+	if !exists || managed.Client == nil || !managed.Client.IsConnected() {
+		return nil, sftpErr
+	}
+	return NewSCPClient(managed.Client), nil
+}
+
+// GetClient returns the SSH client of a connected session, for running commands
+func (sm *SessionManager) GetClient(sessionID string) (*Client, error) {
+	sm.mu.RLock()
+	managed, exists := sm.sessions[sessionID]
+	sm.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("session not found: %s", sessionID)
+	}
+	if managed.Client == nil || !managed.Client.IsConnected() {
+		return nil, fmt.Errorf("session not connected: %s", sessionID)
+	}
+	return managed.Client, nil
+}
+
+// SetTransferChunks sets how many ranges large files are split into on all SFTP clients
+func (sm *SessionManager) SetTransferChunks(chunks int) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.transferChunks = chunks
+	for _, sftpClient := range sm.sftpClients {
+		sftpClient.SetTransferChunks(chunks)
+	}
+}
+
+// SetZmodemHandler enables handling rz/sz in terminal output; files are tracked in transferManager.
+// A nil handler leaves ZMODEM sequences to the terminal.
+func (sm *SessionManager) SetZmodemHandler(transferManager *TransferManager, handler *ZmodemHandler) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.zmodemHandler = handler
+	sm.transferManager = transferManager
+}
+
+// SetTrzszHandler enables handling trz/tsz in terminal output; files are tracked in transferManager.
+// A nil handler leaves trzsz triggers to the terminal.
+func (sm *SessionManager) SetTrzszHandler(transferManager *TransferManager, handler *TrzszHandler) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.trzszHandler = handler
+	sm.transferManager = transferManager
+}
+
 // CloseSFTPClient closes an SFTP client for a session
 func (sm *SessionManager) CloseSFTPClient(sessionID string) error {
 	sm.mu.Lock()
@@ -700,6 +785,7 @@ func (sm *SessionManager) StartLocalShell(sessionID string, cols, rows int, onOu
 	}
 
 	managed.Running = true
+	output := newTerminalOutput(sm, managed, managed.Local, onOutput)
 
 	go func() {
 		buf := make([]byte, 1024)
@@ -715,8 +801,8 @@ func (sm *SessionManager) StartLocalShell(sessionID string, cols, rows int, onOu
 					}
 					return
 				}
-				if n > 0 && onOutput != nil {
-					onOutput(buf[:n])
+				if n > 0 {
+					output.write(buf[:n])
 				}
 			}
 		}
@@ -752,6 +838,10 @@ func (sm *SessionManager) WriteToLocalSession(sessionID string, data []byte) err
 		return fmt.Errorf("session is not a local session: %s", sessionID)
 	}
 
+	if interceptInput(managed, data) {
+		return nil
+	}
+
 	_, err := managed.Local.Write(data)
 	return err
 }
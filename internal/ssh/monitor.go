@@ -1,10 +1,13 @@
 package ssh
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"regexp"
+	"math"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -24,13 +27,24 @@ type SystemInfo struct {
 
 // CPUMetrics contains CPU usage information
 type CPUMetrics struct {
-	Overall     float64   `json:"overall"`      // Overall CPU %
-	User        float64   `json:"user"`         // User mode %
-	System      float64   `json:"system"`       // System mode %
-	IOWait      float64   `json:"iowait"`       // IO wait %
-	Idle        float64   `json:"idle"`         // Idle %
-	PerCore     []float64 `json:"per_core"`     // Per-core usage
-	LoadAverage []float64 `json:"load_average"` // 1, 5, 15 min
+	Overall     float64     `json:"overall"`      // Overall CPU %
+	User        float64     `json:"user"`         // User mode %
+	System      float64     `json:"system"`       // System mode %
+	IOWait      float64     `json:"iowait"`       // IO wait %
+	Steal       float64     `json:"steal"`        // Time taken by the hypervisor %
+	Idle        float64     `json:"idle"`         // Idle %
+	PerCore     []float64   `json:"per_core"`     // Per-core usage
+	Cores       []CoreUsage `json:"cores"`        // Per-core breakdown, in the order of PerCore
+	LoadAverage []float64   `json:"load_average"` // 1, 5, 15 min
+}
+
+// CoreUsage breaks down the usage of a single core
+type CoreUsage struct {
+	Overall float64 `json:"overall"`
+	User    float64 `json:"user"`
+	System  float64 `json:"system"`
+	IOWait  float64 `json:"iowait"`
+	Steal   float64 `json:"steal"`
 }
 
 // MemoryMetrics contains memory usage information
@@ -47,24 +61,60 @@ type MemoryMetrics struct {
 
 // NetworkMetrics contains network statistics
 type NetworkMetrics struct {
-	TotalRxBytes uint64  `json:"total_rx_bytes"` // Total received
-	TotalTxBytes uint64  `json:"total_tx_bytes"` // Total sent
-	RxRate       float64 `json:"rx_rate"`        // RX rate (bytes/sec)
-	TxRate       float64 `json:"tx_rate"`        // TX rate (bytes/sec)
+	TotalRxBytes uint64             `json:"total_rx_bytes"` // Total received
+	TotalTxBytes uint64             `json:"total_tx_bytes"` // Total sent
+	RxRate       float64            `json:"rx_rate"`        // RX rate (bytes/sec)
+	TxRate       float64            `json:"tx_rate"`        // TX rate (bytes/sec)
+	Interfaces   []InterfaceMetrics `json:"interfaces"`     // Every interface but loopback
+}
+
+// InterfaceMetrics contains the statistics of a network interface. Counters are
+// cumulative since boot; rates cover the time since the previous sample.
+type InterfaceMetrics struct {
+	Name      string  `json:"name"`
+	RxBytes   uint64  `json:"rx_bytes"`
+	TxBytes   uint64  `json:"tx_bytes"`
+	RxRate    float64 `json:"rx_rate"` // bytes/sec
+	TxRate    float64 `json:"tx_rate"` // bytes/sec
+	RxErrors  uint64  `json:"rx_errors"`
+	TxErrors  uint64  `json:"tx_errors"`
+	RxDropped uint64  `json:"rx_dropped"`
+	TxDropped uint64  `json:"tx_dropped"`
 }
 
 // PartitionInfo contains disk partition information
 type PartitionInfo struct {
-	MountPoint  string  `json:"mount_point"`
-	Total       uint64  `json:"total"`
-	Used        uint64  `json:"used"`
-	Free        uint64  `json:"free"`
-	UsedPercent float64 `json:"used_percent"`
+	MountPoint        string  `json:"mount_point"`
+	Total             uint64  `json:"total"`
+	Used              uint64  `json:"used"`
+	Free              uint64  `json:"free"`
+	UsedPercent       float64 `json:"used_percent"`
+	InodesTotal       uint64  `json:"inodes_total"` // 0 when the filesystem has no fixed inode table
+	InodesUsed        uint64  `json:"inodes_used"`
+	InodesFree        uint64  `json:"inodes_free"`
+	InodesUsedPercent float64 `json:"inodes_used_percent"`
 }
 
 // DiskMetrics contains disk usage information
 type DiskMetrics struct {
 	Partitions []PartitionInfo `json:"partitions"`
+	Devices    []DiskIOMetrics `json:"devices"` // Whole block devices, without partitions
+}
+
+// DiskIOMetrics contains the I/O statistics of a block device. Counters are cumulative
+// since boot; rates cover the time since the previous sample.
+type DiskIOMetrics struct {
+	Name        string  `json:"name"`
+	ReadBytes   uint64  `json:"read_bytes"`
+	WriteBytes  uint64  `json:"write_bytes"`
+	Reads       uint64  `json:"reads"`      // Completed read requests
+	Writes      uint64  `json:"writes"`     // Completed write requests
+	BusyTime    uint64  `json:"busy_ms"`    // Time spent doing I/O
+	ReadRate    float64 `json:"read_rate"`  // bytes/sec
+	WriteRate   float64 `json:"write_rate"` // bytes/sec
+	ReadIOPS    float64 `json:"read_iops"`
+	WriteIOPS   float64 `json:"write_iops"`
+	Utilization float64 `json:"utilization"` // % of the time the device was busy
 }
 
 // MonitoringData aggregates all monitoring metrics
@@ -77,257 +127,598 @@ type MonitoringData struct {
 	Disk      DiskMetrics    `json:"disk"`
 }
 
-// MonitorCollector collects system metrics via SSH
+// MonitorCollector collects system metrics via SSH. It is safe for concurrent use.
 type MonitorCollector struct {
 	sessionManager *SessionManager
-	prevNetStats   map[string]*NetworkMetrics // sessionID -> previous stats
-	prevTimestamp  map[string]int64           // sessionID -> timestamp
+
+	mu           sync.Mutex
+	remoteOS     map[string]string         // sessionID -> "uname -s" output
+	prevCounters map[string]*counterSample // sessionID -> previous network and disk counters
+	prevCPUTimes map[string][]cpuTimes     // sessionID -> previous CPU counters
+}
+
+// counterSample keeps the cumulative counters of a sample, to derive rates from the next
+type counterSample struct {
+	at      time.Time
+	network NetworkMetrics
+	disks   []DiskIOMetrics
 }
 
 // NewMonitorCollector creates a new monitor collector
 func NewMonitorCollector(sm *SessionManager) *MonitorCollector {
 	return &MonitorCollector{
 		sessionManager: sm,
-		prevNetStats:   make(map[string]*NetworkMetrics),
-		prevTimestamp:  make(map[string]int64),
+		remoteOS:       make(map[string]string),
+		prevCounters:   make(map[string]*counterSample),
+		prevCPUTimes:   make(map[string][]cpuTimes),
 	}
 }
 
-// CollectMetrics collects all metrics for a session
+// Forget drops the samples kept for a session, once it is no longer monitored
+func (mc *MonitorCollector) Forget(sessionID string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	delete(mc.remoteOS, sessionID)
+	delete(mc.prevCounters, sessionID)
+	delete(mc.prevCPUTimes, sessionID)
+}
+
+// metricsSectionMarker starts each section of the metrics script's output
+const metricsSectionMarker = "@@ahassh:"
+
+// metricsScriptPrelude starts every metrics script; s prints a section header
+const metricsScriptPrelude = `export LC_ALL=C
+s() { printf '\n` + metricsSectionMarker + `%s\n' "$1"; }
+`
+
+// metricsScript prints everything CollectMetrics needs from a Linux host in one run, as
+// sections headed by metricsSectionMarker. It runs under sh whatever the login shell is,
+// and sticks to /proc and POSIX options so busybox systems work too.
+const metricsScript = metricsScriptPrelude + `s hostname; hostname 2>/dev/null || cat /proc/sys/kernel/hostname
+s uptime; cat /proc/uptime
+s os; cat /etc/os-release 2>/dev/null
+s kernel; uname -r
+s user; id -un
+s processes; set -- /proc/[0-9]*; echo $#
+s stat; grep '^cpu' /proc/stat
+s loadavg; cat /proc/loadavg
+s meminfo; cat /proc/meminfo
+s netdev; cat /proc/net/dev
+s df; df -Pk 2>/dev/null
+s dfi; df -Pi 2>/dev/null
+s block; ls /sys/block 2>/dev/null
+s diskstats; cat /proc/diskstats 2>/dev/null
+exit 0`
+
+// metricsTimeout bounds a run of the metrics script
+const metricsTimeout = 5 * time.Second
+
+// osCollector collects metrics on one family of operating systems, in the same shape
+type osCollector struct {
+	script string
+	// parse parses the script's output. Where the OS exposes cumulative CPU counters they
+	// are returned for usage to be derived between samples; otherwise parse fills in usage.
+	parse func(output string) (*MonitoringData, []cpuTimes)
+}
+
+// osCollectors are keyed by "uname -s" output; other systems are treated as Linux
+var osCollectors = map[string]osCollector{
+	"Linux":   {script: metricsScript, parse: parseMetrics},
+	"FreeBSD": {script: freeBSDMetricsScript, parse: parseFreeBSDMetrics},
+	"Darwin":  {script: macOSMetricsScript, parse: parseMacOSMetrics},
+}
+
+// CollectMetrics collects all metrics for a session with a single command
 func (mc *MonitorCollector) CollectMetrics(sessionID string) (*MonitoringData, error) {
-	data := &MonitoringData{
-		Timestamp: time.Now().Unix(),
+	return mc.collect(sessionID, func(cmd string) (string, error) {
+		stdout, _, err := mc.sessionManager.ExecuteCommand(sessionID, cmd, metricsTimeout)
+		return stdout, err
+	})
+}
+
+// CollectClientMetrics collects metrics over a client without an interactive session, such
+// as a headless monitoring connection. key keeps the host's samples apart as a session ID does.
+func (mc *MonitorCollector) CollectClientMetrics(key string, client *Client) (*MonitoringData, error) {
+	return mc.collect(key, func(cmd string) (string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), metricsTimeout)
+		defer cancel()
+
+		var stdout bytes.Buffer
+		err := client.RunCommandStream(ctx, cmd, nil, &stdout, nil)
+		return stdout.String(), err
+	})
+}
+
+// collect runs the metrics script for the host's OS, detecting the OS on first use
+func (mc *MonitorCollector) collect(key string, run func(cmd string) (string, error)) (*MonitoringData, error) {
+	mc.mu.Lock()
+	remoteOS, known := mc.remoteOS[key]
+	mc.mu.Unlock()
+
+	if !known {
+		output, err := run("uname -s")
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect remote OS: %w", err)
+		}
+		remoteOS = strings.TrimSpace(output)
+
+		mc.mu.Lock()
+		mc.remoteOS[key] = remoteOS
+		mc.mu.Unlock()
 	}
 
-	timeout := 5 * time.Second
+	collector, ok := osCollectors[remoteOS]
+	if !ok {
+		collector = osCollectors["Linux"]
+	}
 
-	// Collect system info
-	sysInfo, err := mc.collectSystemInfo(sessionID, timeout)
+	output, err := run("sh -c " + shellQuote(collector.script))
 	if err != nil {
-		return nil, fmt.Errorf("failed to collect system info: %w", err)
+		return nil, fmt.Errorf("failed to collect metrics: %w", err)
 	}
-	data.System = *sysInfo
 
-	// Collect CPU metrics
-	cpuMetrics, err := mc.collectCPUMetrics(sessionID, timeout)
-	if err != nil {
-		return nil, fmt.Errorf("failed to collect CPU metrics: %w", err)
+	return mc.processMetrics(key, collector, output), nil
+}
+
+// processMetrics parses the script output and derives rates from the previous sample
+func (mc *MonitorCollector) processMetrics(key string, collector osCollector, output string) *MonitoringData {
+	data, cpu := collector.parse(output)
+	data.Timestamp = time.Now().Unix()
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if len(cpu) > 0 {
+		loadAverage := data.CPU.LoadAverage
+		data.CPU = cpuUsage(mc.prevCPUTimes[key], cpu)
+		data.CPU.LoadAverage = loadAverage
+		mc.prevCPUTimes[key] = cpu
 	}
-	data.CPU = *cpuMetrics
+	now := time.Now()
+	if prev := mc.prevCounters[key]; prev != nil {
+		updateRates(prev, data, now.Sub(prev.at).Seconds())
+	}
+	mc.prevCounters[key] = &counterSample{at: now, network: data.Network, disks: data.Disk.Devices}
 
-	// Collect memory metrics
-	memMetrics, err := mc.collectMemoryMetrics(sessionID, timeout)
-	if err != nil {
-		return nil, fmt.Errorf("failed to collect memory metrics: %w", err)
+	return data
+}
+
+// parseMetrics parses the output of metricsScript; missing sections leave their fields empty.
+// CPU usage needs two samples, so the raw /proc/stat counters are returned alongside.
+func parseMetrics(output string) (*MonitoringData, []cpuTimes) {
+	sections := splitMetricsSections(output)
+
+	data := &MonitoringData{CPU: CPUMetrics{PerCore: []float64{}, Cores: []CoreUsage{}}}
+	data.System = SystemInfo{
+		Hostname: strings.TrimSpace(sections["hostname"]),
+		Uptime:   parseUptime(sections["uptime"]),
+		OS:       parseOSRelease(sections["os"]),
+		Kernel:   strings.TrimSpace(sections["kernel"]),
+		Username: strings.TrimSpace(sections["user"]),
+	}
+	data.System.Processes, _ = strconv.Atoi(strings.TrimSpace(sections["processes"]))
+
+	data.CPU.LoadAverage = parseLoadAverage(sections["loadavg"])
+	data.Memory = parseMeminfo(sections["meminfo"])
+	data.Network.Interfaces = parseNetDev(sections["netdev"])
+	for _, iface := range data.Network.Interfaces {
+		data.Network.TotalRxBytes += iface.RxBytes
+		data.Network.TotalTxBytes += iface.TxBytes
 	}
-	data.Memory = *memMetrics
+	data.Disk.Partitions = parseDF(sections["df"])
+	addInodeUsage(data.Disk.Partitions, sections["dfi"])
+	data.Disk.Devices = parseDiskstats(sections["diskstats"], sections["block"])
 
-	// Collect network metrics
-	netMetrics, err := mc.collectNetworkMetrics(sessionID, timeout)
-	if err != nil {
-		return nil, fmt.Errorf("failed to collect network metrics: %w", err)
+	return data, parseProcStat(sections["stat"])
+}
+
+// splitMetricsSections splits script output into sections by name
+func splitMetricsSections(output string) map[string]string {
+	sections := make(map[string]string)
+
+	var name string
+	var body strings.Builder
+	for _, line := range strings.Split(output, "\n") {
+		if section, ok := strings.CutPrefix(line, metricsSectionMarker); ok {
+			if name != "" {
+				sections[name] = body.String()
+			}
+			name = strings.TrimSpace(section)
+			body.Reset()
+			continue
+		}
+		if name != "" {
+			body.WriteString(line)
+			body.WriteString("\n")
+		}
 	}
-	data.Network = *netMetrics
+	if name != "" {
+		sections[name] = body.String()
+	}
+
+	return sections
+}
 
-	// Collect disk metrics
-	diskMetrics, err := mc.collectDiskMetrics(sessionID, timeout)
+// parseUptime formats /proc/uptime like "uptime -p", e.g. "up 3 days, 4 hours, 5 minutes"
+func parseUptime(procUptime string) string {
+	fields := strings.Fields(procUptime)
+	if len(fields) == 0 {
+		return ""
+	}
+	seconds, err := strconv.ParseFloat(fields[0], 64)
 	if err != nil {
-		return nil, fmt.Errorf("failed to collect disk metrics: %w", err)
+		return ""
+	}
+
+	total := int64(seconds) / 60
+	units := []struct {
+		name    string
+		minutes int64
+	}{
+		{"day", 24 * 60},
+		{"hour", 60},
+		{"minute", 1},
+	}
+
+	var parts []string
+	for _, unit := range units {
+		count := total / unit.minutes
+		total %= unit.minutes
+		if count == 0 && !(unit.minutes == 1 && len(parts) == 0) {
+			continue
+		}
+		part := fmt.Sprintf("%d %s", count, unit.name)
+		if count != 1 {
+			part += "s"
+		}
+		parts = append(parts, part)
+	}
+
+	return "up " + strings.Join(parts, ", ")
+}
+
+// parseOSRelease returns PRETTY_NAME from /etc/os-release
+func parseOSRelease(osRelease string) string {
+	for _, line := range strings.Split(osRelease, "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), "PRETTY_NAME="); ok {
+			return strings.Trim(value, `"'`)
+		}
 	}
-	data.Disk = *diskMetrics
+	return ""
+}
 
-	return data, nil
+// cpuTimes holds the cumulative counters of one "cpu" line of /proc/stat, in clock ticks
+type cpuTimes struct {
+	user, nice, system, idle, iowait, irq, softirq, steal uint64
 }
 
-// collectSystemInfo gathers basic system information
-func (mc *MonitorCollector) collectSystemInfo(sessionID string, timeout time.Duration) (*SystemInfo, error) {
-	info := &SystemInfo{}
+func (t cpuTimes) total() uint64 {
+	// guest and guest_nice are already included in user and nice
+	return t.user + t.nice + t.system + t.idle + t.iowait + t.irq + t.softirq + t.steal
+}
 
-	// Hostname
-	stdout, _, err := mc.sessionManager.ExecuteCommand(sessionID, "hostname", timeout)
-	if err == nil {
-		info.Hostname = strings.TrimSpace(stdout)
+// parseProcStat parses the cpu lines of /proc/stat: the aggregate first, then each core.
+// Kernels before 2.6.11 lack the steal column, which then stays zero.
+func parseProcStat(stat string) []cpuTimes {
+	var result []cpuTimes
+	for _, line := range strings.Split(stat, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+
+		var values [8]uint64
+		for i := range values {
+			if i+1 < len(fields) {
+				values[i], _ = strconv.ParseUint(fields[i+1], 10, 64)
+			}
+		}
+		result = append(result, cpuTimes{
+			user: values[0], nice: values[1], system: values[2], idle: values[3],
+			iowait: values[4], irq: values[5], softirq: values[6], steal: values[7],
+		})
 	}
+	return result
+}
 
-	// Uptime
-	stdout, _, err = mc.sessionManager.ExecuteCommand(sessionID, "uptime -p", timeout)
-	if err == nil {
-		info.Uptime = strings.TrimSpace(stdout)
+// cpuUsage computes usage between two /proc/stat samples. Without a usable previous
+// sample the counters since boot are used, so the first reading is an average.
+func cpuUsage(prev, cur []cpuTimes) CPUMetrics {
+	metrics := CPUMetrics{PerCore: []float64{}, Cores: []CoreUsage{}}
+	if len(cur) == 0 {
+		return metrics
 	}
 
-	// OS
-	stdout, _, err = mc.sessionManager.ExecuteCommand(sessionID, `cat /etc/os-release | grep PRETTY_NAME | cut -d '"' -f2`, timeout)
-	if err == nil {
-		info.OS = strings.TrimSpace(stdout)
+	// CPUs going on- or offline change the layout; start over from this sample
+	if len(prev) != len(cur) {
+		prev = make([]cpuTimes, len(cur))
 	}
 
-	// Kernel
-	stdout, _, err = mc.sessionManager.ExecuteCommand(sessionID, "uname -r", timeout)
-	if err == nil {
-		info.Kernel = strings.TrimSpace(stdout)
+	overall := coreUsage(prev[0], cur[0])
+	metrics.Overall = overall.Overall
+	metrics.User = overall.User
+	metrics.System = overall.System
+	metrics.IOWait = overall.IOWait
+	metrics.Steal = overall.Steal
+	// iowait counts as idle time in Overall but is reported on its own
+	metrics.Idle = roundTo2Decimals(max(100.0-overall.Overall-overall.IOWait, 0))
+
+	for i := 1; i < len(cur); i++ {
+		core := coreUsage(prev[i], cur[i])
+		metrics.PerCore = append(metrics.PerCore, core.Overall)
+		metrics.Cores = append(metrics.Cores, core)
 	}
 
-	// Username
-	stdout, _, err = mc.sessionManager.ExecuteCommand(sessionID, "whoami", timeout)
-	if err == nil {
-		info.Username = strings.TrimSpace(stdout)
+	return metrics
+}
+
+func coreUsage(prev, cur cpuTimes) CoreUsage {
+	// Counters only grow; a smaller total means the host rebooted between samples
+	if cur.total() < prev.total() {
+		prev = cpuTimes{}
+	}
+	delta := func(a, b uint64) float64 {
+		if b < a {
+			return 0
+		}
+		return float64(b - a)
 	}
 
-	stdout, _, err = mc.sessionManager.ExecuteCommand(sessionID, `ps aux | wc -l`, timeout)
-	if err == nil {
-		count, _ := strconv.Atoi(strings.TrimSpace(stdout))
-		info.Processes = count
+	total := delta(prev.total(), cur.total())
+	if total == 0 {
+		return CoreUsage{}
+	}
+	percent := func(ticks float64) float64 {
+		return roundTo2Decimals(ticks / total * 100.0)
 	}
 
-	return info, nil
+	idle := delta(prev.idle+prev.iowait, cur.idle+cur.iowait)
+	return CoreUsage{
+		Overall: percent(total - idle),
+		User:    percent(delta(prev.user+prev.nice, cur.user+cur.nice)),
+		System:  percent(delta(prev.system+prev.irq+prev.softirq, cur.system+cur.irq+cur.softirq)),
+		IOWait:  percent(delta(prev.iowait, cur.iowait)),
+		Steal:   percent(delta(prev.steal, cur.steal)),
+	}
 }
 
-// collectCPUMetrics gathers CPU usage statistics
-func (mc *MonitorCollector) collectCPUMetrics(sessionID string, timeout time.Duration) (*CPUMetrics, error) {
-	metrics := &CPUMetrics{}
-
-	// Get overall CPU usage from top
-	stdout, _, err := mc.sessionManager.ExecuteCommand(sessionID, `top -bn1 | grep "Cpu(s)"`, timeout)
-	if err == nil {
-		// Parse: "%Cpu(s):  0.8 us,  0.8 sy,  0.0 ni, 98.5 id,  0.0 wa,  0.0 hi,  0.0 si,  0.0 st"
-		// Use regex to extract each metric value reliably
-		re := regexp.MustCompile(`(\d+(?:\.\d+)?)\s+(\w+),?`)
-		matches := re.FindAllStringSubmatch(stdout, -1)
+// parseLoadAverage parses the 1, 5 and 15 minute averages from /proc/loadavg
+func parseLoadAverage(loadavg string) []float64 {
+	fields := strings.Fields(loadavg)
+	if len(fields) < 3 {
+		return nil
+	}
 
-		for _, match := range matches {
-			if len(match) >= 3 {
-				value, _ := strconv.ParseFloat(match[1], 64)
-				key := match[2]
+	result := make([]float64, 0, 3)
+	for _, field := range fields[:3] {
+		value, _ := strconv.ParseFloat(field, 64)
+		result = append(result, value)
+	}
+	return result
+}
 
-				switch key {
-				case "us":
-					metrics.User = value
-				case "sy":
-					metrics.System = value
-				case "id":
-					metrics.Idle = value
-				case "wa":
-					metrics.IOWait = value
-				}
-			}
+// parseMeminfo parses /proc/meminfo, computing "used" the way free does
+func parseMeminfo(meminfo string) MemoryMetrics {
+	values := make(map[string]uint64)
+	for _, line := range strings.Split(meminfo, "\n") {
+		key, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
 		}
+		value, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			value *= 1024
+		}
+		values[key] = value
+	}
 
-		metrics.Overall = 100.0 - metrics.Idle
+	metrics := MemoryMetrics{
+		Total:     values["MemTotal"],
+		Free:      values["MemFree"],
+		SwapTotal: values["SwapTotal"],
+		SwapFree:  values["SwapFree"],
+	}
 
-		// Round all CPU metrics to 2 decimal places
-		metrics.User = roundTo2Decimals(metrics.User)
-		metrics.System = roundTo2Decimals(metrics.System)
-		metrics.IOWait = roundTo2Decimals(metrics.IOWait)
-		metrics.Idle = roundTo2Decimals(metrics.Idle)
-		metrics.Overall = roundTo2Decimals(metrics.Overall)
+	// Kernels before 3.14 have no MemAvailable
+	metrics.Available = values["MemAvailable"]
+	cache := values["Buffers"] + values["Cached"] + values["SReclaimable"]
+	if _, ok := values["MemAvailable"]; !ok {
+		metrics.Available = metrics.Free + cache
 	}
 
-	// Load average
-	stdout, _, err = mc.sessionManager.ExecuteCommand(sessionID, `uptime | awk -F'load average:' '{print $2}'`, timeout)
-	if err == nil {
-		parts := strings.Split(strings.TrimSpace(stdout), ",")
-		for _, part := range parts {
-			val, _ := strconv.ParseFloat(strings.TrimSpace(part), 64)
-			metrics.LoadAverage = append(metrics.LoadAverage, val)
-		}
+	if used := metrics.Free + cache; metrics.Total > used {
+		metrics.Used = metrics.Total - used
+	}
+	if metrics.SwapTotal > metrics.SwapFree {
+		metrics.SwapUsed = metrics.SwapTotal - metrics.SwapFree
+	}
+	if metrics.Total > 0 {
+		metrics.UsedPercent = float64(metrics.Used) / float64(metrics.Total) * 100.0
 	}
 
-	return metrics, nil
+	return metrics
 }
 
-// collectMemoryMetrics gathers memory usage statistics
-func (mc *MonitorCollector) collectMemoryMetrics(sessionID string, timeout time.Duration) (*MemoryMetrics, error) {
-	metrics := &MemoryMetrics{}
+// parseNetDev parses the counters of every interface but loopback in /proc/net/dev
+func parseNetDev(netdev string) []InterfaceMetrics {
+	interfaces := []InterfaceMetrics{}
+	for _, line := range strings.Split(netdev, "\n") {
+		name, counters, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "lo" {
+			continue
+		}
+		fields := strings.Fields(counters)
+		if len(fields) < 12 {
+			continue
+		}
 
-	// Physical memory
-	stdout, _, err := mc.sessionManager.ExecuteCommand(sessionID, `free -b | grep Mem`, timeout)
-	if err == nil {
-		fields := strings.Fields(stdout)
-		if len(fields) >= 7 {
-			metrics.Total, _ = strconv.ParseUint(fields[1], 10, 64)
-			metrics.Used, _ = strconv.ParseUint(fields[2], 10, 64)
-			metrics.Free, _ = strconv.ParseUint(fields[3], 10, 64)
-			metrics.Available, _ = strconv.ParseUint(fields[6], 10, 64)
-			if metrics.Total > 0 {
-				metrics.UsedPercent = float64(metrics.Used) / float64(metrics.Total) * 100.0
-			}
+		value := func(i int) uint64 {
+			v, _ := strconv.ParseUint(fields[i], 10, 64)
+			return v
 		}
+		interfaces = append(interfaces, InterfaceMetrics{
+			Name:      name,
+			RxBytes:   value(0),
+			RxErrors:  value(2),
+			RxDropped: value(3),
+			TxBytes:   value(8),
+			TxErrors:  value(10),
+			TxDropped: value(11),
+		})
 	}
+	return interfaces
+}
+
+// parseDF parses "df -Pk" output, keeping filesystems backed by a device
+func parseDF(df string) []PartitionInfo {
+	partitions := []PartitionInfo{}
+
+	for _, line := range strings.Split(df, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 || !strings.HasPrefix(fields[0], "/dev") {
+			continue
+		}
 
-	// Swap
-	stdout, _, err = mc.sessionManager.ExecuteCommand(sessionID, `free -b | grep Swap`, timeout)
-	if err == nil {
-		fields := strings.Fields(stdout)
-		if len(fields) >= 4 {
-			metrics.SwapTotal, _ = strconv.ParseUint(fields[1], 10, 64)
-			metrics.SwapUsed, _ = strconv.ParseUint(fields[2], 10, 64)
-			metrics.SwapFree, _ = strconv.ParseUint(fields[3], 10, 64)
+		// Mount points may contain spaces; the first five columns cannot
+		partition := PartitionInfo{
+			MountPoint: strings.Join(fields[5:], " "),
 		}
+		total, _ := strconv.ParseUint(fields[1], 10, 64)
+		used, _ := strconv.ParseUint(fields[2], 10, 64)
+		free, _ := strconv.ParseUint(fields[3], 10, 64)
+		partition.Total = total * 1024
+		partition.Used = used * 1024
+		partition.Free = free * 1024
+		partition.UsedPercent, _ = strconv.ParseFloat(strings.TrimSuffix(fields[4], "%"), 64)
+
+		partitions = append(partitions, partition)
 	}
 
-	return metrics, nil
+	return partitions
 }
 
-// collectNetworkMetrics gathers network statistics
-func (mc *MonitorCollector) collectNetworkMetrics(sessionID string, timeout time.Duration) (*NetworkMetrics, error) {
-	metrics := &NetworkMetrics{}
+// addInodeUsage fills in inode usage from "df -Pi" output, matching partitions by mount point
+func addInodeUsage(partitions []PartitionInfo, dfi string) {
+	for _, line := range strings.Split(dfi, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 {
+			continue
+		}
+		mountPoint := strings.Join(fields[5:], " ")
 
-	// Get current network stats
-	stdout, _, err := mc.sessionManager.ExecuteCommand(sessionID, `cat /proc/net/dev | grep -v "lo:" | awk 'NR>2 {rx+=$2; tx+=$10} END {print rx, tx}'`, timeout)
-	if err == nil {
-		fields := strings.Fields(stdout)
-		if len(fields) >= 2 {
-			metrics.TotalRxBytes, _ = strconv.ParseUint(fields[0], 10, 64)
-			metrics.TotalTxBytes, _ = strconv.ParseUint(fields[1], 10, 64)
+		for i := range partitions {
+			if partitions[i].MountPoint != mountPoint {
+				continue
+			}
+			partitions[i].InodesTotal, _ = strconv.ParseUint(fields[1], 10, 64)
+			partitions[i].InodesUsed, _ = strconv.ParseUint(fields[2], 10, 64)
+			partitions[i].InodesFree, _ = strconv.ParseUint(fields[3], 10, 64)
+			// Filesystems allocating inodes on demand, such as btrfs, report "-"
+			partitions[i].InodesUsedPercent, _ = strconv.ParseFloat(strings.TrimSuffix(fields[4], "%"), 64)
 		}
 	}
+}
 
-	// Calculate rate if we have previous data
-	now := time.Now().Unix()
-	if prev, exists := mc.prevNetStats[sessionID]; exists {
-		prevTime := mc.prevTimestamp[sessionID]
-		timeDelta := float64(now - prevTime)
-		if timeDelta > 0 {
-			metrics.RxRate = float64(metrics.TotalRxBytes-prev.TotalRxBytes) / timeDelta
-			metrics.TxRate = float64(metrics.TotalTxBytes-prev.TotalTxBytes) / timeDelta
-		}
+// diskSectorSize is the unit of the sector counts in /proc/diskstats, whatever the device's
+const diskSectorSize = 512
+
+// parseDiskstats parses /proc/diskstats. Only devices listed in /sys/block are kept, which
+// leaves out partitions; loop and RAM disks and devices that never did I/O are skipped too.
+func parseDiskstats(diskstats, block string) []DiskIOMetrics {
+	devices := []DiskIOMetrics{}
+
+	// /sys/block spells a "/" in a device name as "!", e.g. cciss!c0d0
+	wholeDisks := make(map[string]bool)
+	for _, name := range strings.Fields(block) {
+		wholeDisks[strings.ReplaceAll(name, "!", "/")] = true
 	}
 
-	// Save current stats for next calculation
-	mc.prevNetStats[sessionID] = &NetworkMetrics{
-		TotalRxBytes: metrics.TotalRxBytes,
-		TotalTxBytes: metrics.TotalTxBytes,
+	for _, line := range strings.Split(diskstats, "\n") {
+		// Partitions of 2.6 kernels have fewer columns, and are skipped with them
+		fields := strings.Fields(line)
+		if len(fields) < 14 {
+			continue
+		}
+		name := fields[2]
+		if len(wholeDisks) > 0 && !wholeDisks[name] {
+			continue
+		}
+		if strings.HasPrefix(name, "loop") || strings.HasPrefix(name, "ram") {
+			continue
+		}
+
+		value := func(i int) uint64 {
+			v, _ := strconv.ParseUint(fields[i], 10, 64)
+			return v
+		}
+		device := DiskIOMetrics{
+			Name:       name,
+			Reads:      value(3),
+			ReadBytes:  value(5) * diskSectorSize,
+			Writes:     value(7),
+			WriteBytes: value(9) * diskSectorSize,
+			BusyTime:   value(12),
+		}
+		if device.Reads == 0 && device.Writes == 0 {
+			continue
+		}
+		devices = append(devices, device)
 	}
-	mc.prevTimestamp[sessionID] = now
 
-	return metrics, nil
+	return devices
 }
 
-// collectDiskMetrics gathers disk usage statistics
-func (mc *MonitorCollector) collectDiskMetrics(sessionID string, timeout time.Duration) (*DiskMetrics, error) {
-	metrics := &DiskMetrics{
-		Partitions: []PartitionInfo{},
+// updateRates derives network and disk rates from the counters of the previous sample,
+// taken the given number of seconds earlier
+func updateRates(prev *counterSample, data *MonitoringData, seconds float64) {
+	if seconds <= 0 {
+		return
 	}
 
-	stdout, _, err := mc.sessionManager.ExecuteCommand(sessionID, `df -B1 | grep "^/dev" | awk '{print $6, $2, $3, $4, $5}'`, timeout)
-	if err == nil {
-		lines := strings.Split(strings.TrimSpace(stdout), "\n")
-		for _, line := range lines {
-			fields := strings.Fields(line)
-			if len(fields) >= 5 {
-				partition := PartitionInfo{
-					MountPoint: fields[0],
-				}
-				partition.Total, _ = strconv.ParseUint(fields[1], 10, 64)
-				partition.Used, _ = strconv.ParseUint(fields[2], 10, 64)
-				partition.Free, _ = strconv.ParseUint(fields[3], 10, 64)
-				percentStr := strings.TrimSuffix(fields[4], "%")
-				partition.UsedPercent, _ = strconv.ParseFloat(percentStr, 64)
+	data.Network.RxRate = counterRate(prev.network.TotalRxBytes, data.Network.TotalRxBytes, seconds)
+	data.Network.TxRate = counterRate(prev.network.TotalTxBytes, data.Network.TotalTxBytes, seconds)
 
-				metrics.Partitions = append(metrics.Partitions, partition)
+	for i := range data.Network.Interfaces {
+		iface := &data.Network.Interfaces[i]
+		for _, old := range prev.network.Interfaces {
+			if old.Name == iface.Name {
+				iface.RxRate = counterRate(old.RxBytes, iface.RxBytes, seconds)
+				iface.TxRate = counterRate(old.TxBytes, iface.TxBytes, seconds)
+				break
 			}
 		}
 	}
 
-	return metrics, nil
+	for i := range data.Disk.Devices {
+		device := &data.Disk.Devices[i]
+		for _, old := range prev.disks {
+			if old.Name == device.Name {
+				device.ReadRate = counterRate(old.ReadBytes, device.ReadBytes, seconds)
+				device.WriteRate = counterRate(old.WriteBytes, device.WriteBytes, seconds)
+				device.ReadIOPS = counterRate(old.Reads, device.Reads, seconds)
+				device.WriteIOPS = counterRate(old.Writes, device.Writes, seconds)
+				// BusyTime is in milliseconds, so its rate per second is a tenth of a percent
+				device.Utilization = math.Min(counterRate(old.BusyTime, device.BusyTime, seconds)/10, 100)
+				break
+			}
+		}
+	}
+}
+
+// counterRate is the change of a cumulative counter per second. A counter that went
+// backwards was reset, by a reboot or an interface coming back, so no rate is known.
+func counterRate(prev, cur uint64, seconds float64) float64 {
+	if cur < prev {
+		return 0
+	}
+	return roundTo2Decimals(float64(cur-prev) / seconds)
 }
@@ -0,0 +1,268 @@
+package ssh
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// freeBSDMetricsScript prints the metrics of a FreeBSD host in the sections parseFreeBSDMetrics
+// reads. There is no /proc, so everything comes from sysctl and the base system's tools.
+const freeBSDMetricsScript = metricsScriptPrelude + `s hostname; hostname
+s now; date +%s
+s boottime; sysctl -n kern.boottime
+s os; echo "$(uname -s) $(freebsd-version 2>/dev/null || uname -r)"
+s kernel; uname -r
+s user; id -un
+s processes; ps -ax -o pid= | wc -l
+s cptime; sysctl -n kern.cp_time kern.cp_times
+s loadavg; sysctl -n vm.loadavg
+s memory; sysctl hw.physmem hw.pagesize vm.stats.vm.v_free_count vm.stats.vm.v_inactive_count vm.stats.vm.v_cache_count 2>/dev/null
+s swap; swapinfo -k
+s netstat; netstat -ibdn
+s df; df -ki -t ufs,zfs,msdosfs,ext2fs
+s iostat; iostat -xId
+exit 0`
+
+// parseFreeBSDMetrics parses the output of freeBSDMetricsScript
+func parseFreeBSDMetrics(output string) (*MonitoringData, []cpuTimes) {
+	sections := splitMetricsSections(output)
+
+	data := &MonitoringData{CPU: CPUMetrics{PerCore: []float64{}, Cores: []CoreUsage{}}}
+	data.System = parseBSDSystemInfo(sections)
+	data.CPU.LoadAverage = parseLoadAverage(strings.Trim(strings.TrimSpace(sections["loadavg"]), "{}"))
+	data.Memory = parseFreeBSDMemory(sections["memory"], sections["swap"])
+	data.Network.Interfaces = parseNetstatInterfaces(sections["netstat"])
+	for _, iface := range data.Network.Interfaces {
+		data.Network.TotalRxBytes += iface.RxBytes
+		data.Network.TotalTxBytes += iface.TxBytes
+	}
+	data.Disk.Partitions = parseBSDDF(sections["df"])
+	data.Disk.Devices = parseFreeBSDIostat(sections["iostat"])
+
+	return data, parseCPTime(sections["cptime"])
+}
+
+// parseBSDSystemInfo reads the system sections the BSD scripts share. Uptime is derived
+// from kern.boottime, "{ sec = 1700000000, usec = 0 } Tue Nov 14 ...", and the host's clock.
+func parseBSDSystemInfo(sections map[string]string) SystemInfo {
+	info := SystemInfo{
+		Hostname: strings.TrimSpace(sections["hostname"]),
+		OS:       strings.TrimSpace(sections["os"]),
+		Kernel:   strings.TrimSpace(sections["kernel"]),
+		Username: strings.TrimSpace(sections["user"]),
+	}
+	info.Processes, _ = strconv.Atoi(strings.TrimSpace(sections["processes"]))
+
+	now, nowErr := strconv.ParseInt(strings.TrimSpace(sections["now"]), 10, 64)
+	_, rest, found := strings.Cut(sections["boottime"], "sec =")
+	if nowErr == nil && found {
+		value, _, _ := strings.Cut(rest, ",")
+		if boot, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil && now >= boot {
+			info.Uptime = parseUptime(strconv.FormatInt(now-boot, 10))
+		}
+	}
+
+	return info
+}
+
+// parseCPTime parses kern.cp_time and kern.cp_times, the aggregate and per-CPU tick counters
+// in the order user, nice, sys, intr, idle
+func parseCPTime(cptime string) []cpuTimes {
+	var result []cpuTimes
+	for _, line := range strings.Split(cptime, "\n") {
+		fields := strings.Fields(line)
+		for len(fields) >= 5 {
+			var values [5]uint64
+			for i := range values {
+				values[i], _ = strconv.ParseUint(fields[i], 10, 64)
+			}
+			result = append(result, cpuTimes{
+				user: values[0], nice: values[1], system: values[2], irq: values[3], idle: values[4],
+			})
+			fields = fields[5:]
+		}
+	}
+
+	// Without kern.cp_times only the aggregate is known; cpuUsage expects it first either way
+	return result
+}
+
+// parseFreeBSDMemory computes memory usage from page counts, treating free, inactive and
+// cached pages as available, and sums swap devices from "swapinfo -k"
+func parseFreeBSDMemory(memory, swap string) MemoryMetrics {
+	values := make(map[string]uint64)
+	for _, line := range strings.Split(memory, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		values[strings.TrimSpace(key)], _ = strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+	}
+
+	pageSize := values["hw.pagesize"]
+	metrics := MemoryMetrics{
+		Total: values["hw.physmem"],
+		Free:  values["vm.stats.vm.v_free_count"] * pageSize,
+	}
+	// v_cache_count is gone since FreeBSD 12, and then reads as zero
+	metrics.Available = metrics.Free + (values["vm.stats.vm.v_inactive_count"]+values["vm.stats.vm.v_cache_count"])*pageSize
+	if metrics.Total > metrics.Available {
+		metrics.Used = metrics.Total - metrics.Available
+	}
+	if metrics.Total > 0 {
+		metrics.UsedPercent = float64(metrics.Used) / float64(metrics.Total) * 100.0
+	}
+
+	for _, line := range strings.Split(swap, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] == "Total" {
+			continue
+		}
+		total, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue // Header
+		}
+		used, _ := strconv.ParseUint(fields[2], 10, 64)
+		metrics.SwapTotal += total * 1024
+		metrics.SwapUsed += used * 1024
+	}
+	metrics.SwapFree = metrics.SwapTotal - metrics.SwapUsed
+
+	return metrics
+}
+
+// parseNetstatInterfaces parses "netstat -ibdn" as printed by FreeBSD and macOS, using the
+// link-level row of each interface but loopback. Columns are looked up by header, aligned
+// from the right, since interfaces without a link address leave the Address column empty.
+func parseNetstatInterfaces(netstat string) []InterfaceMetrics {
+	interfaces := []InterfaceMetrics{}
+
+	lines := strings.Split(strings.TrimLeft(netstat, "\n"), "\n")
+	header := strings.Fields(lines[0])
+	column := make(map[string]int)
+	for i, name := range header {
+		column[name] = i
+	}
+	if _, ok := column["Ibytes"]; !ok {
+		return interfaces
+	}
+
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !strings.HasPrefix(fields[2], "<Link") {
+			continue
+		}
+		name := strings.TrimSuffix(fields[0], "*") // Marks interfaces that are down
+		if strings.HasPrefix(name, "lo") {
+			continue
+		}
+
+		shift := len(header) - len(fields)
+		value := func(col string) uint64 {
+			i, ok := column[col]
+			if !ok || i-shift < 0 || i-shift >= len(fields) {
+				return 0
+			}
+			v, _ := strconv.ParseUint(fields[i-shift], 10, 64)
+			return v
+		}
+		interfaces = append(interfaces, InterfaceMetrics{
+			Name:      name,
+			RxBytes:   value("Ibytes"),
+			TxBytes:   value("Obytes"),
+			RxErrors:  value("Ierrs"),
+			TxErrors:  value("Oerrs"),
+			RxDropped: value("Idrop"), // FreeBSD only
+			TxDropped: value("Drop"),
+		})
+	}
+	return interfaces
+}
+
+// parseBSDDF parses "df -ki", which BSDs print with inode columns after the space columns.
+// The script selects the filesystem types to show, so every row is kept.
+func parseBSDDF(df string) []PartitionInfo {
+	partitions := []PartitionInfo{}
+
+	for _, line := range strings.Split(df, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 9 {
+			continue
+		}
+		total, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue // Header
+		}
+
+		partition := PartitionInfo{
+			MountPoint: strings.Join(fields[8:], " "),
+			Total:      total * 1024,
+		}
+		used, _ := strconv.ParseUint(fields[2], 10, 64)
+		free, _ := strconv.ParseUint(fields[3], 10, 64)
+		partition.Used = used * 1024
+		partition.Free = free * 1024
+		partition.UsedPercent, _ = strconv.ParseFloat(strings.TrimSuffix(fields[4], "%"), 64)
+		partition.InodesUsed, _ = strconv.ParseUint(fields[5], 10, 64)
+		partition.InodesFree, _ = strconv.ParseUint(fields[6], 10, 64)
+		partition.InodesTotal = partition.InodesUsed + partition.InodesFree
+		partition.InodesUsedPercent, _ = strconv.ParseFloat(strings.TrimSuffix(fields[7], "%"), 64)
+
+		partitions = append(partitions, partition)
+	}
+
+	return partitions
+}
+
+// parseFreeBSDIostat parses "iostat -xId", whose columns are totals since boot: requests,
+// kilobytes transferred and seconds busy. Optical drives and pass-through devices are skipped.
+func parseFreeBSDIostat(iostat string) []DiskIOMetrics {
+	devices := []DiskIOMetrics{}
+
+	var column map[string]int
+	for _, line := range strings.Split(iostat, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "device" {
+			column = make(map[string]int)
+			for i, name := range fields {
+				column[name] = i
+			}
+			continue
+		}
+		if column == nil || len(fields) != len(column) {
+			continue
+		}
+		name := fields[0]
+		if strings.HasPrefix(name, "cd") || strings.HasPrefix(name, "pass") {
+			continue
+		}
+
+		// Totals are printed with a fraction, so they are scaled before rounding
+		value := func(col string, scale float64) float64 {
+			i, ok := column[col]
+			if !ok {
+				return 0
+			}
+			v, _ := strconv.ParseFloat(fields[i], 64)
+			return math.Round(v * scale)
+		}
+		device := DiskIOMetrics{
+			Name:       name,
+			Reads:      uint64(value("r/i", 1)),
+			Writes:     uint64(value("w/i", 1)),
+			ReadBytes:  uint64(value("kr/i", 1024)),
+			WriteBytes: uint64(value("kw/i", 1024)),
+			BusyTime:   uint64(value("sb/i", 1000)),
+		}
+		if device.Reads == 0 && device.Writes == 0 {
+			continue
+		}
+		devices = append(devices, device)
+	}
+
+	return devices
+}
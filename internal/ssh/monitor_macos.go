@@ -0,0 +1,201 @@
+package ssh
+
+import (
+	"strconv"
+	"strings"
+)
+
+// macOSMetricsScript prints the metrics of a macOS host in the sections parseMacOSMetrics
+// reads. macOS exposes no cumulative CPU counters to the shell, so iostat samples CPU usage
+// over a second; per-disk counters come from the I/O Kit registry.
+const macOSMetricsScript = metricsScriptPrelude + `s hostname; hostname
+s now; date +%s
+s boottime; sysctl -n kern.boottime
+s os; echo "$(sw_vers -productName) $(sw_vers -productVersion)"
+s kernel; uname -r
+s user; id -un
+s processes; ps -ax -o pid= | wc -l
+s iostat; iostat -n 0 -c 2 -w 1
+s loadavg; sysctl -n vm.loadavg
+s memsize; sysctl -n hw.memsize
+s vmstat; vm_stat
+s swap; sysctl -n vm.swapusage
+s netstat; netstat -ibdn
+s df; df -ki -T apfs,hfs,msdos,exfat
+s ioreg; ioreg -r -c IOBlockStorageDriver -d 2 -l -w 0
+exit 0`
+
+// parseMacOSMetrics parses the output of macOSMetricsScript. CPU usage is filled in
+// directly, without per-core figures, so no counters are returned.
+func parseMacOSMetrics(output string) (*MonitoringData, []cpuTimes) {
+	sections := splitMetricsSections(output)
+
+	data := &MonitoringData{}
+	data.System = parseBSDSystemInfo(sections)
+	data.CPU = parseMacOSIostat(sections["iostat"])
+	data.CPU.LoadAverage = parseLoadAverage(strings.Trim(strings.TrimSpace(sections["loadavg"]), "{}"))
+	data.Memory = parseVMStat(sections["memsize"], sections["vmstat"], sections["swap"])
+	data.Network.Interfaces = parseNetstatInterfaces(sections["netstat"])
+	for _, iface := range data.Network.Interfaces {
+		data.Network.TotalRxBytes += iface.RxBytes
+		data.Network.TotalTxBytes += iface.TxBytes
+	}
+	data.Disk.Partitions = parseBSDDF(sections["df"])
+	data.Disk.Devices = parseIORegStatistics(sections["ioreg"])
+
+	return data, nil
+}
+
+// parseMacOSIostat reads CPU usage from the last line of iostat, the one covering the
+// sampling interval rather than the time since boot
+func parseMacOSIostat(iostat string) CPUMetrics {
+	metrics := CPUMetrics{PerCore: []float64{}, Cores: []CoreUsage{}}
+
+	column := make(map[string]int)
+	var last []string
+	for _, line := range strings.Split(iostat, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if _, err := strconv.ParseFloat(fields[0], 64); err != nil {
+			for i, name := range fields {
+				column[name] = i
+			}
+			continue
+		}
+		last = fields
+	}
+
+	value := func(col string) float64 {
+		i, ok := column[col]
+		if !ok || i >= len(last) {
+			return 0
+		}
+		v, _ := strconv.ParseFloat(last[i], 64)
+		return v
+	}
+	if _, ok := column["id"]; !ok || last == nil {
+		return metrics
+	}
+	metrics.User = value("us")
+	metrics.System = value("sy")
+	metrics.Idle = value("id")
+	metrics.Overall = roundTo2Decimals(max(100-metrics.Idle, 0))
+	return metrics
+}
+
+// parseVMStat computes memory usage the way Activity Monitor does: app memory, wired and
+// compressed pages are used, the rest is available. Swap comes from vm.swapusage,
+// "total = 2048.00M  used = 1021.25M  free = 1026.75M  (encrypted)".
+func parseVMStat(memsize, vmstat, swapusage string) MemoryMetrics {
+	metrics := MemoryMetrics{}
+	metrics.Total, _ = strconv.ParseUint(strings.TrimSpace(memsize), 10, 64)
+
+	pageSize := uint64(4096)
+	pages := make(map[string]uint64)
+	for _, line := range strings.Split(vmstat, "\n") {
+		if _, rest, ok := strings.Cut(line, "page size of "); ok {
+			size, _, _ := strings.Cut(rest, " ")
+			if value, err := strconv.ParseUint(size, 10, 64); err == nil {
+				pageSize = value
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		pages[strings.Trim(key, `"`)], _ = strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(value), "."), 10, 64)
+	}
+
+	// Anonymous pages are counted since OS X 10.9; older systems count active pages instead
+	app := pages["Pages active"]
+	if anonymous, ok := pages["Anonymous pages"]; ok && anonymous > pages["Pages purgeable"] {
+		app = anonymous - pages["Pages purgeable"]
+	}
+	metrics.Used = (app + pages["Pages wired down"] + pages["Pages occupied by compressor"]) * pageSize
+	if metrics.Used > metrics.Total {
+		metrics.Used = metrics.Total
+	}
+	metrics.Free = (pages["Pages free"] + pages["Pages speculative"]) * pageSize
+	metrics.Available = metrics.Total - metrics.Used
+	if metrics.Total > 0 {
+		metrics.UsedPercent = float64(metrics.Used) / float64(metrics.Total) * 100.0
+	}
+
+	fields := strings.Fields(swapusage)
+	for i := 0; i+2 < len(fields); i++ {
+		if fields[i+1] != "=" {
+			continue
+		}
+		size := parseSwapSize(fields[i+2])
+		switch fields[i] {
+		case "total":
+			metrics.SwapTotal = size
+		case "used":
+			metrics.SwapUsed = size
+		case "free":
+			metrics.SwapFree = size
+		}
+	}
+
+	return metrics
+}
+
+// parseSwapSize parses a size such as "1021.25M" from vm.swapusage
+func parseSwapSize(size string) uint64 {
+	units := map[byte]float64{'K': 1 << 10, 'M': 1 << 20, 'G': 1 << 30, 'T': 1 << 40}
+	if size == "" {
+		return 0
+	}
+	multiplier, ok := units[size[len(size)-1]]
+	if !ok {
+		multiplier = 1
+	} else {
+		size = size[:len(size)-1]
+	}
+	value, err := strconv.ParseFloat(size, 64)
+	if err != nil {
+		return 0
+	}
+	return uint64(value * multiplier)
+}
+
+// parseIORegStatistics reads the cumulative statistics of each block storage driver from
+// ioreg, naming them after the BSD name of the whole-disk media right below. Busy time is
+// the total service time of requests, so it overstates utilisation when requests overlap.
+func parseIORegStatistics(ioreg string) []DiskIOMetrics {
+	devices := []DiskIOMetrics{}
+
+	var pending *DiskIOMetrics
+	for _, line := range strings.Split(ioreg, "\n") {
+		if _, rest, ok := strings.Cut(line, `"Statistics" = {`); ok {
+			stats := make(map[string]uint64)
+			for _, pair := range strings.Split(strings.TrimSuffix(strings.TrimSpace(rest), "}"), ",") {
+				key, value, ok := strings.Cut(pair, "=")
+				if ok {
+					stats[strings.Trim(key, `"`)], _ = strconv.ParseUint(value, 10, 64)
+				}
+			}
+			pending = &DiskIOMetrics{
+				Reads:      stats["Operations (Read)"],
+				Writes:     stats["Operations (Write)"],
+				ReadBytes:  stats["Bytes (Read)"],
+				WriteBytes: stats["Bytes (Write)"],
+				BusyTime:   (stats["Total Time (Read)"] + stats["Total Time (Write)"]) / 1e6, // ns
+			}
+			continue
+		}
+
+		if _, rest, ok := strings.Cut(line, `"BSD Name" = "`); ok && pending != nil {
+			pending.Name, _, _ = strings.Cut(rest, `"`)
+			if pending.Reads > 0 || pending.Writes > 0 {
+				devices = append(devices, *pending)
+			}
+			pending = nil
+		}
+	}
+
+	return devices
+}
@@ -0,0 +1,534 @@
+package ssh
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// loadMetricsFixture parses captured script output, taking CPU usage as a first sample would
+func loadMetricsFixture(t *testing.T, name string) *MonitoringData {
+	t.Helper()
+
+	output, err := os.ReadFile(filepath.Join("testdata", "metrics", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	data, cpu := parseMetrics(string(output))
+	loadAverage := data.CPU.LoadAverage
+	data.CPU = cpuUsage(nil, cpu)
+	data.CPU.LoadAverage = loadAverage
+	return data
+}
+
+// usedPercent computes a percentage at run time, as the parser does
+func usedPercent(used, total uint64) float64 {
+	return float64(used) / float64(total) * 100.0
+}
+
+func TestParseMetrics(t *testing.T) {
+	tests := []struct {
+		fixture    string
+		system     SystemInfo
+		cpu        CPUMetrics
+		memory     MemoryMetrics
+		rx, tx     uint64
+		interfaces []InterfaceMetrics
+		partitions []PartitionInfo
+		devices    []DiskIOMetrics
+	}{
+		{
+			// Synthetic output in the layout of a Debian 12 VM, not captured from a real host
+			fixture: "debian-12.txt",
+			system: SystemInfo{
+				Hostname:  "vm",
+				Uptime:    "up 1 hour, 5 minutes",
+				OS:        "Debian GNU/Linux 12 (bookworm)",
+				Kernel:    "6.1.0-18-amd64",
+				Username:  "root",
+				Processes: 59,
+			},
+			cpu: CPUMetrics{
+				Overall: 18.7, User: 15.97, System: 2.34, IOWait: 0.1, Steal: 0.38, Idle: 81.2,
+				PerCore:     []float64{18.7},
+				Cores:       []CoreUsage{{Overall: 18.7, User: 15.97, System: 2.34, IOWait: 0.1, Steal: 0.38}},
+				LoadAverage: []float64{0.40, 0.42, 0.24},
+			},
+			memory: MemoryMetrics{
+				Total:       6147400 * 1024,
+				Used:        340380 * 1024,
+				Free:        3877676 * 1024,
+				Available:   5542124 * 1024,
+				UsedPercent: usedPercent(340380*1024, 6147400*1024),
+			},
+			rx: 6335838,
+			tx: 53926,
+			interfaces: []InterfaceMetrics{
+				{Name: "ifb0"},
+				{Name: "ifb1"},
+				{Name: "eth0", RxBytes: 6335838, TxBytes: 53926},
+			},
+			partitions: []PartitionInfo{
+				{
+					MountPoint: "/", Total: 264212084 * 1024, Used: 18573768 * 1024, Free: 82873972 * 1024, UsedPercent: 19,
+					InodesTotal: 16777216, InodesUsed: 764699, InodesFree: 16012517, InodesUsedPercent: 5,
+				},
+				{
+					MountPoint: "/srv/data", Total: 459936 * 1024, Used: 370908 * 1024, Free: 53408 * 1024, UsedPercent: 88,
+					InodesTotal: 127232, InodesUsed: 15101, InodesFree: 112131, InodesUsedPercent: 12,
+				},
+			},
+			// Loop devices and the unused zram0 are left out
+			devices: []DiskIOMetrics{
+				{Name: "vda", Reads: 11746, ReadBytes: 1560866 * 512, Writes: 26902, WriteBytes: 5056368 * 512, BusyTime: 6580},
+				{Name: "vdb", Reads: 6, ReadBytes: 290 * 512},
+			},
+		},
+		{
+			fixture: "alpine-busybox.txt",
+			system: SystemInfo{
+				Hostname:  "alpine-edge",
+				Uptime:    "up 3 days, 2 hours, 1 minute",
+				OS:        "Alpine Linux v3.19",
+				Kernel:    "6.6.14-0-lts",
+				Username:  "admin",
+				Processes: 23,
+			},
+			cpu: CPUMetrics{
+				Overall: 0.9, User: 0.72, System: 0.18, IOWait: 0.04, Idle: 99.06,
+				PerCore: []float64{0.9, 0.89},
+				Cores: []CoreUsage{
+					{Overall: 0.9, User: 0.72, System: 0.18, IOWait: 0.04},
+					{Overall: 0.89, User: 0.72, System: 0.17, IOWait: 0.04},
+				},
+				LoadAverage: []float64{0.08, 0.03, 0.01},
+			},
+			memory: MemoryMetrics{
+				Total:       1009248 * 1024,
+				Used:        161736 * 1024,
+				Free:        611736 * 1024,
+				Available:   823112 * 1024,
+				UsedPercent: usedPercent(161736*1024, 1009248*1024),
+				SwapTotal:   524284 * 1024,
+				SwapUsed:    4096 * 1024,
+				SwapFree:    520188 * 1024,
+			},
+			rx: 98231764,
+			tx: 7354012,
+			interfaces: []InterfaceMetrics{
+				{Name: "eth0", RxBytes: 98231764, TxBytes: 7354012, RxDropped: 12},
+			},
+			// Busybox df without inode support prints nothing for -i
+			partitions: []PartitionInfo{
+				{MountPoint: "/", Total: 19480400 * 1024, Used: 1346232 * 1024, Free: 17119112 * 1024, UsedPercent: 7},
+				{MountPoint: "/boot", Total: 95054 * 1024, Used: 26428 * 1024, Free: 61458 * 1024, UsedPercent: 30},
+			},
+			devices: []DiskIOMetrics{
+				{Name: "sda", Reads: 48211, ReadBytes: 3301884 * 512, Writes: 91233, WriteBytes: 2210416 * 512, BusyTime: 112340},
+			},
+		},
+		{
+			// Kernel without MemAvailable, no os-release and a mount point with a space
+			fixture: "centos-6.txt",
+			system: SystemInfo{
+				Hostname:  "legacy-db01.example.com",
+				Uptime:    "up 0 minutes",
+				Kernel:    "2.6.32-754.35.1.el6.x86_64",
+				Username:  "oracle",
+				Processes: 412,
+			},
+			cpu: CPUMetrics{
+				Overall: 9.87, User: 7.61, System: 2.26, IOWait: 1.63, Idle: 88.5,
+				PerCore: []float64{11.18, 9.47, 9.47, 9.34},
+				Cores: []CoreUsage{
+					{Overall: 11.18, User: 8.52, System: 2.66, IOWait: 1.68},
+					{Overall: 9.47, User: 7.37, System: 2.1, IOWait: 1.62},
+					{Overall: 9.47, User: 7.33, System: 2.13, IOWait: 1.61},
+					{Overall: 9.34, User: 7.2, System: 2.14, IOWait: 1.62},
+				},
+				LoadAverage: []float64{2.41, 2.17, 1.98},
+			},
+			memory: MemoryMetrics{
+				Total:       16333864 * 1024,
+				Used:        4953068 * 1024,
+				Free:        1203432 * 1024,
+				Available:   11380796 * 1024,
+				UsedPercent: usedPercent(4953068*1024, 16333864*1024),
+				SwapTotal:   8388604 * 1024,
+				SwapUsed:    198592 * 1024,
+				SwapFree:    8190012 * 1024,
+			},
+			rx: 887362121301,
+			tx: 413298161223,
+			interfaces: []InterfaceMetrics{
+				{Name: "eth0", RxBytes: 887362018901, TxBytes: 413298110023},
+				{Name: "eth1", RxBytes: 102400, TxBytes: 51200},
+			},
+			partitions: []PartitionInfo{
+				{
+					MountPoint: "/", Total: 51475068 * 1024, Used: 20448332 * 1024, Free: 28405296 * 1024, UsedPercent: 42,
+					InodesTotal: 3276800, InodesUsed: 212331, InodesFree: 3064469, InodesUsedPercent: 7,
+				},
+				{
+					MountPoint: "/boot", Total: 495844 * 1024, Used: 108213 * 1024, Free: 361975 * 1024, UsedPercent: 24,
+					InodesTotal: 128016, InodesUsed: 52, InodesFree: 127964, InodesUsedPercent: 1,
+				},
+				{
+					MountPoint: "/u01/oracle data", Total: 1031992064 * 1024, Used: 774401172 * 1024, Free: 205163292 * 1024, UsedPercent: 80,
+					InodesTotal: 65536000, InodesUsed: 64880640, InodesFree: 655360, InodesUsedPercent: 99,
+				},
+			},
+			devices: []DiskIOMetrics{
+				{Name: "sda", Reads: 2204112, ReadBytes: 190332210 * 512, Writes: 8812231, WriteBytes: 310221872 * 512, BusyTime: 10231120},
+				{Name: "sdb", Reads: 9921031, ReadBytes: 2211303120 * 512, Writes: 31203120, WriteBytes: 1910231211 * 512, BusyTime: 80212310},
+				{Name: "dm-0", Reads: 2270011, ReadBytes: 190300210 * 512, Writes: 15721231, WriteBytes: 310220112 * 512, BusyTime: 10231002},
+				{Name: "dm-1", Reads: 9921010, ReadBytes: 2211302112 * 512, Writes: 31203011, WriteBytes: 1910230012 * 512, BusyTime: 80212301},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			data := loadMetricsFixture(t, tt.fixture)
+
+			if data.System != tt.system {
+				t.Errorf("system = %+v, want %+v", data.System, tt.system)
+			}
+			if !reflect.DeepEqual(data.CPU, tt.cpu) {
+				t.Errorf("cpu = %+v, want %+v", data.CPU, tt.cpu)
+			}
+			if data.Memory != tt.memory {
+				t.Errorf("memory = %+v, want %+v", data.Memory, tt.memory)
+			}
+			if data.Network.TotalRxBytes != tt.rx || data.Network.TotalTxBytes != tt.tx {
+				t.Errorf("network = %d/%d, want %d/%d", data.Network.TotalRxBytes, data.Network.TotalTxBytes, tt.rx, tt.tx)
+			}
+			if !reflect.DeepEqual(data.Network.Interfaces, tt.interfaces) {
+				t.Errorf("interfaces = %+v, want %+v", data.Network.Interfaces, tt.interfaces)
+			}
+			if !reflect.DeepEqual(data.Disk.Partitions, tt.partitions) {
+				t.Errorf("partitions = %+v, want %+v", data.Disk.Partitions, tt.partitions)
+			}
+			if !reflect.DeepEqual(data.Disk.Devices, tt.devices) {
+				t.Errorf("devices = %+v, want %+v", data.Disk.Devices, tt.devices)
+			}
+		})
+	}
+}
+
+func TestParseFreeBSDMetrics(t *testing.T) {
+	output, err := os.ReadFile(filepath.Join("testdata", "metrics", "freebsd-14.txt"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	data, cpu := parseFreeBSDMetrics(string(output))
+
+	system := SystemInfo{
+		Hostname:  "bsd-nas.example.net",
+		Uptime:    "up 3 days, 1 hour, 10 minutes",
+		OS:        "FreeBSD 14.0-RELEASE-p6",
+		Kernel:    "14.0-RELEASE-p5",
+		Username:  "backup",
+		Processes: 87,
+	}
+	if data.System != system {
+		t.Errorf("system = %+v, want %+v", data.System, system)
+	}
+
+	wantCPU := CPUMetrics{
+		Overall: 16, User: 12, System: 4, Idle: 84,
+		PerCore: []float64{17, 15},
+		Cores:   []CoreUsage{{Overall: 17, User: 12, System: 5}, {Overall: 15, User: 12, System: 3}},
+	}
+	if got := cpuUsage(nil, cpu); !reflect.DeepEqual(got, wantCPU) {
+		t.Errorf("cpu = %+v, want %+v", got, wantCPU)
+	}
+	if !reflect.DeepEqual(data.CPU.LoadAverage, []float64{0.52, 0.41, 0.38}) {
+		t.Errorf("load average = %v", data.CPU.LoadAverage)
+	}
+
+	memory := MemoryMetrics{
+		Total:       8547110912,
+		Used:        8547110912 - (1048576+262144)*4096,
+		Free:        1048576 * 4096,
+		Available:   (1048576 + 262144) * 4096,
+		UsedPercent: usedPercent(8547110912-(1048576+262144)*4096, 8547110912),
+		SwapTotal:   4194304 * 1024,
+		SwapUsed:    10240 * 1024,
+		SwapFree:    4184064 * 1024,
+	}
+	if data.Memory != memory {
+		t.Errorf("memory = %+v, want %+v", data.Memory, memory)
+	}
+
+	// Loopback and address rows are left out; tun0 has no link address
+	interfaces := []InterfaceMetrics{
+		{Name: "em0", RxBytes: 9123456789, TxBytes: 512345678, RxErrors: 3, RxDropped: 7, TxDropped: 2},
+		{Name: "tun0"},
+	}
+	if !reflect.DeepEqual(data.Network.Interfaces, interfaces) {
+		t.Errorf("interfaces = %+v, want %+v", data.Network.Interfaces, interfaces)
+	}
+	if data.Network.TotalRxBytes != 9123456789 || data.Network.TotalTxBytes != 512345678 {
+		t.Errorf("network = %d/%d", data.Network.TotalRxBytes, data.Network.TotalTxBytes)
+	}
+
+	// ZFS datasets count, and UFS reports negative space available past the reserve
+	partitions := []PartitionInfo{
+		{
+			MountPoint: "/", Total: 210298544 * 1024, Used: 8124904 * 1024, Free: 202173640 * 1024, UsedPercent: 4,
+			InodesTotal: 216521 + 404347280, InodesUsed: 216521, InodesFree: 404347280,
+		},
+		{
+			MountPoint: "/backup/old disks", Total: 30446188 * 1024, Used: 29210136 * 1024, UsedPercent: 104,
+			InodesTotal: 1990112 + 158, InodesUsed: 1990112, InodesFree: 158, InodesUsedPercent: 100,
+		},
+		{
+			MountPoint: "/home", Total: 202212812 * 1024, Used: 39172 * 1024, Free: 202173640 * 1024,
+			InodesTotal: 201 + 404347280, InodesUsed: 201, InodesFree: 404347280,
+		},
+	}
+	if !reflect.DeepEqual(data.Disk.Partitions, partitions) {
+		t.Errorf("partitions = %+v, want %+v", data.Disk.Partitions, partitions)
+	}
+
+	devices := []DiskIOMetrics{
+		{Name: "ada0", Reads: 812345, Writes: 1234567, ReadBytes: 9876543.5 * 1024, WriteBytes: 20480000 * 1024, BusyTime: 3456700},
+	}
+	if !reflect.DeepEqual(data.Disk.Devices, devices) {
+		t.Errorf("devices = %+v, want %+v", data.Disk.Devices, devices)
+	}
+}
+
+func TestParseMacOSMetrics(t *testing.T) {
+	output, err := os.ReadFile(filepath.Join("testdata", "metrics", "macos-14.txt"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	data, cpu := parseMacOSMetrics(string(output))
+
+	system := SystemInfo{
+		Hostname:  "Studio.local",
+		Uptime:    "up 2 hours",
+		OS:        "macOS 14.4.1",
+		Kernel:    "23.4.0",
+		Username:  "dev",
+		Processes: 512,
+	}
+	if data.System != system {
+		t.Errorf("system = %+v, want %+v", data.System, system)
+	}
+
+	// Usage comes from the second iostat line, the first covers the time since boot
+	if len(cpu) != 0 {
+		t.Errorf("cpu counters = %v, want none", cpu)
+	}
+	wantCPU := CPUMetrics{
+		Overall: 18, User: 12, System: 6, Idle: 82,
+		PerCore: []float64{}, Cores: []CoreUsage{},
+		LoadAverage: []float64{2.31, 2.05, 1.98},
+	}
+	if !reflect.DeepEqual(data.CPU, wantCPU) {
+		t.Errorf("cpu = %+v, want %+v", data.CPU, wantCPU)
+	}
+
+	used := uint64(850000-50000+200000+100000) * 16384
+	memory := MemoryMetrics{
+		Total:       34359738368,
+		Used:        used,
+		Free:        (62500 + 37500) * 16384,
+		Available:   34359738368 - used,
+		UsedPercent: usedPercent(used, 34359738368),
+		SwapTotal:   2048 << 20,
+		SwapUsed:    1021.25 * (1 << 20),
+		SwapFree:    1026.75 * (1 << 20),
+	}
+	if data.Memory != memory {
+		t.Errorf("memory = %+v, want %+v", data.Memory, memory)
+	}
+
+	interfaces := []InterfaceMetrics{
+		{Name: "gif0"},
+		{Name: "en0", RxBytes: 31234567890, TxBytes: 2345678901, TxDropped: 41},
+		{Name: "utun0", RxBytes: 45678, TxBytes: 56789},
+	}
+	if !reflect.DeepEqual(data.Network.Interfaces, interfaces) {
+		t.Errorf("interfaces = %+v, want %+v", data.Network.Interfaces, interfaces)
+	}
+
+	partitions := []PartitionInfo{
+		{
+			MountPoint: "/", Total: 971350180 * 1024, Used: 10432616 * 1024, Free: 612345678 * 1024, UsedPercent: 2,
+			InodesTotal: 404167 + 4294562433, InodesUsed: 404167, InodesFree: 4294562433,
+		},
+		{
+			MountPoint: "/System/Volumes/Data", Total: 971350180 * 1024, Used: 341234567 * 1024, Free: 612345678 * 1024, UsedPercent: 36,
+			InodesTotal: 2345678 + 4292620922, InodesUsed: 2345678, InodesFree: 4292620922,
+		},
+		{
+			MountPoint: "/Volumes/Time Machine", Total: 488050672 * 1024, Used: 400123456 * 1024, Free: 87927216 * 1024, UsedPercent: 82,
+			InodesTotal: 1234567 + 4293732712, InodesUsed: 1234567, InodesFree: 4293732712,
+		},
+	}
+	if !reflect.DeepEqual(data.Disk.Partitions, partitions) {
+		t.Errorf("partitions = %+v, want %+v", data.Disk.Partitions, partitions)
+	}
+
+	// The idle disk image is left out
+	devices := []DiskIOMetrics{
+		{Name: "disk0", Reads: 15372103, Writes: 10463771, ReadBytes: 312459546624, WriteBytes: 187345612800, BusyTime: 3382661},
+		{Name: "disk5", Reads: 54321, Writes: 812345, ReadBytes: 4123456512, WriteBytes: 98765432832, BusyTime: 214691},
+	}
+	if !reflect.DeepEqual(data.Disk.Devices, devices) {
+		t.Errorf("devices = %+v, want %+v", data.Disk.Devices, devices)
+	}
+}
+
+func TestCollectDetectsOS(t *testing.T) {
+	mc := NewMonitorCollector(nil)
+
+	var commands []string
+	run := func(cmd string) (string, error) {
+		commands = append(commands, cmd)
+		if cmd == "uname -s" {
+			return "FreeBSD\n", nil
+		}
+		return "\n@@ahassh:hostname\nbsd\n\n@@ahassh:cptime\n10 0 10 0 80\n", nil
+	}
+
+	for range 2 {
+		data, err := mc.collect("session", run)
+		if err != nil {
+			t.Fatalf("collect: %v", err)
+		}
+		if data.System.Hostname != "bsd" {
+			t.Errorf("hostname = %q, want %q", data.System.Hostname, "bsd")
+		}
+	}
+
+	// The OS is detected once, then the FreeBSD script runs on every collection
+	want := []string{"uname -s", "sh -c " + shellQuote(freeBSDMetricsScript), "sh -c " + shellQuote(freeBSDMetricsScript)}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("commands = %q, want %q", commands, want)
+	}
+}
+
+func TestParseMetricsMissingSections(t *testing.T) {
+	// A failed command leaves its section empty; the rest must still parse
+	data, cpu := parseMetrics("\n@@ahassh:hostname\nweb1\n\n@@ahassh:stat\n\n@@ahassh:loadavg\n0.50 0.40 0.30 1/100 200\n")
+
+	if data.System.Hostname != "web1" {
+		t.Errorf("hostname = %q, want %q", data.System.Hostname, "web1")
+	}
+	if len(cpu) != 0 {
+		t.Errorf("cpu samples = %v, want none", cpu)
+	}
+	if !reflect.DeepEqual(data.CPU.LoadAverage, []float64{0.5, 0.4, 0.3}) {
+		t.Errorf("load average = %v", data.CPU.LoadAverage)
+	}
+	if len(data.Disk.Partitions) != 0 {
+		t.Errorf("partitions = %v, want none", data.Disk.Partitions)
+	}
+}
+
+func TestParseUptime(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"59.99 10.00", "up 0 minutes"},
+		{"60.00 10.00", "up 1 minute"},
+		{"3600.5 0", "up 1 hour"},
+		{"90061.0 0", "up 1 day, 1 hour, 1 minute"},
+		{"", ""},
+		{"garbage", ""},
+	}
+
+	for _, tt := range tests {
+		if got := parseUptime(tt.input); got != tt.want {
+			t.Errorf("parseUptime(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestCPUUsage(t *testing.T) {
+	prev := parseProcStat("cpu  1000 0 500 8000 100 0 0 0 0 0\ncpu0 600 0 300 3900 50 0 0 0 0 0\ncpu1 400 0 200 4100 50 0 0 0 0 0\n")
+
+	// 500 ticks pass on each core: cpu0 is busy with some steal, cpu1 half idle with some iowait
+	cur := parseProcStat("cpu  1600 0 600 8200 150 0 0 50 0 0\ncpu0 1000 0 350 3900 50 0 0 50 0 0\ncpu1 600 0 250 4300 100 0 0 0 0 0\n")
+
+	got := cpuUsage(prev, cur)
+	want := CPUMetrics{
+		Overall: 75, User: 60, System: 10, IOWait: 5, Steal: 5, Idle: 20,
+		PerCore: []float64{100, 50},
+		Cores: []CoreUsage{
+			{Overall: 100, User: 80, System: 10, Steal: 10},
+			{Overall: 50, User: 40, System: 10, IOWait: 10},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cpuUsage = %+v, want %+v", got, want)
+	}
+
+	// After a reboot the counters restart, so usage falls back to the new counters
+	rebooted := parseProcStat("cpu  50 0 50 900 0 0 0 0 0 0\ncpu0 25 0 25 450 0 0 0 0 0 0\ncpu1 25 0 25 450 0 0 0 0 0 0\n")
+	if got := cpuUsage(cur, rebooted); got.Overall != 10 || got.PerCore[0] != 10 {
+		t.Errorf("after reboot = %+v, want 10%% overall", got)
+	}
+
+	// A core going offline changes the layout; the new sample stands on its own
+	offline := parseProcStat("cpu  1700 0 650 8300 150 0 0 50 0 0\ncpu0 1100 0 400 3900 50 0 0 50 0 0\n")
+	if got := cpuUsage(cur, offline); len(got.PerCore) != 1 {
+		t.Errorf("per core = %v, want one core", got.PerCore)
+	}
+
+	// Kernels before 2.6.11 have no steal column
+	old := parseProcStat("cpu  100 0 100 800 0 0 0\n")
+	if len(old) != 1 || old[0].steal != 0 || old[0].total() != 1000 {
+		t.Errorf("old format = %+v", old)
+	}
+}
+
+func TestUpdateRates(t *testing.T) {
+	prev := &counterSample{
+		network: NetworkMetrics{
+			TotalRxBytes: 1000, TotalTxBytes: 500,
+			Interfaces: []InterfaceMetrics{{Name: "eth0", RxBytes: 1000, TxBytes: 500}},
+		},
+		disks: []DiskIOMetrics{{Name: "sda", ReadBytes: 4096, WriteBytes: 8192, Reads: 10, Writes: 20, BusyTime: 1000}},
+	}
+
+	data := &MonitoringData{
+		Network: NetworkMetrics{
+			TotalRxBytes: 3000, TotalTxBytes: 300,
+			Interfaces: []InterfaceMetrics{
+				{Name: "eth0", RxBytes: 3000, TxBytes: 300}, // TX counter reset
+				{Name: "wg0", RxBytes: 100},                 // New interface
+			},
+		},
+		Disk: DiskMetrics{
+			Devices: []DiskIOMetrics{{Name: "sda", ReadBytes: 4096 + 2*40960, WriteBytes: 8192, Reads: 30, Writes: 20, BusyTime: 1500}},
+		},
+	}
+	updateRates(prev, data, 2)
+
+	if data.Network.RxRate != 1000 || data.Network.TxRate != 0 {
+		t.Errorf("network rates = %v/%v, want 1000/0", data.Network.RxRate, data.Network.TxRate)
+	}
+	if eth0 := data.Network.Interfaces[0]; eth0.RxRate != 1000 || eth0.TxRate != 0 {
+		t.Errorf("eth0 rates = %v/%v, want 1000/0", eth0.RxRate, eth0.TxRate)
+	}
+	if wg0 := data.Network.Interfaces[1]; wg0.RxRate != 0 {
+		t.Errorf("wg0 rate = %v, want 0 without a previous sample", wg0.RxRate)
+	}
+
+	want := DiskIOMetrics{
+		Name: "sda", ReadBytes: 4096 + 2*40960, WriteBytes: 8192, Reads: 30, Writes: 20, BusyTime: 1500,
+		ReadRate: 40960, ReadIOPS: 10, Utilization: 25,
+	}
+	if got := data.Disk.Devices[0]; got != want {
+		t.Errorf("sda = %+v, want %+v", got, want)
+	}
+}
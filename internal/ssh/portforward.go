@@ -0,0 +1,277 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PortForwardOptions describes a local port forward, as with ssh -L
+type PortForwardOptions struct {
+	RemoteHost string `json:"remote_host"` // As seen from the server; wildcards such as 0.0.0.0 mean the server itself
+	RemotePort int    `json:"remote_port"`
+	LocalPort  int    `json:"local_port"` // 0 = the remote port if free, otherwise any free port
+}
+
+// PortForward is a running local port forward
+type PortForward struct {
+	ID                string `json:"id"`
+	SessionID         string `json:"session_id"`
+	LocalAddress      string `json:"local_address"` // 127.0.0.1:port, where local clients connect
+	LocalPort         int    `json:"local_port"`
+	RemoteHost        string `json:"remote_host"`
+	RemotePort        int    `json:"remote_port"`
+	ActiveConnections int64  `json:"active_connections"`
+	TotalConnections  int64  `json:"total_connections"`
+	CreatedAt         int64  `json:"created_at"`
+}
+
+// portForward is a forward's listener and the connections it is relaying
+type portForward struct {
+	info     PortForward
+	client   *Client
+	listener net.Listener
+	active   atomic.Int64
+	total    atomic.Int64
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+}
+
+// StartPortForward listens on a local port and relays each connection to a host and port
+// reached from a session's server. Passing the local address of a remote listening socket
+// forwards to that socket.
+func (sm *SessionManager) StartPortForward(sessionID string, opts PortForwardOptions) (*PortForward, error) {
+	if opts.RemotePort <= 0 || opts.RemotePort > 65535 {
+		return nil, fmt.Errorf("invalid remote port: %d", opts.RemotePort)
+	}
+	if opts.LocalPort < 0 || opts.LocalPort > 65535 {
+		return nil, fmt.Errorf("invalid local port: %d", opts.LocalPort)
+	}
+
+	client, err := sm.GetClient(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var listener net.Listener
+	if opts.LocalPort == 0 {
+		// Keeping the remote port number is friendlier, but it is often taken locally
+		if listener, err = net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(opts.RemotePort))); err != nil {
+			listener, err = net.Listen("tcp", "127.0.0.1:0")
+		}
+	} else {
+		listener, err = net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(opts.LocalPort)))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on local port: %w", err)
+	}
+
+	// Registering under the session lock keeps CloseSession, which stops the session's
+	// forwards, from running between the check and the registration
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	if managed, exists := sm.sessions[sessionID]; !exists || managed.Client != client {
+		listener.Close()
+		return nil, fmt.Errorf("session not found: %s", sessionID)
+	}
+
+	sm.forwardsMu.Lock()
+	defer sm.forwardsMu.Unlock()
+
+	forward := &portForward{
+		info: PortForward{
+			ID:           fmt.Sprintf("forward_%d_%d", time.Now().UnixNano(), len(sm.forwards)),
+			SessionID:    sessionID,
+			LocalAddress: listener.Addr().String(),
+			LocalPort:    listener.Addr().(*net.TCPAddr).Port,
+			RemoteHost:   forwardHost(opts.RemoteHost),
+			RemotePort:   opts.RemotePort,
+			CreatedAt:    time.Now().Unix(),
+		},
+		client:   client,
+		listener: listener,
+		conns:    make(map[net.Conn]struct{}),
+	}
+	sm.forwards[forward.info.ID] = forward
+
+	go forward.serve()
+
+	info := forward.snapshot()
+	return &info, nil
+}
+
+// StopPortForward stops a port forward of a session, or of any session if sessionID is
+// empty, and closes its connections
+func (sm *SessionManager) StopPortForward(sessionID, forwardID string) error {
+	sm.forwardsMu.Lock()
+	forward, exists := sm.forwards[forwardID]
+	exists = exists && (sessionID == "" || forward.info.SessionID == sessionID)
+	if exists {
+		delete(sm.forwards, forwardID)
+	}
+	sm.forwardsMu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("port forward not found: %s", forwardID)
+	}
+	forward.close()
+	return nil
+}
+
+// ListPortForwards returns the port forwards of a session, or of all sessions if
+// sessionID is empty, oldest first
+func (sm *SessionManager) ListPortForwards(sessionID string) []PortForward {
+	sm.forwardsMu.Lock()
+	defer sm.forwardsMu.Unlock()
+
+	forwards := make([]PortForward, 0, len(sm.forwards))
+	for _, forward := range sm.forwards {
+		if sessionID == "" || forward.info.SessionID == sessionID {
+			forwards = append(forwards, forward.snapshot())
+		}
+	}
+	sort.Slice(forwards, func(i, j int) bool {
+		if forwards[i].CreatedAt != forwards[j].CreatedAt {
+			return forwards[i].CreatedAt < forwards[j].CreatedAt
+		}
+		return forwards[i].LocalPort < forwards[j].LocalPort
+	})
+	return forwards
+}
+
+// stopPortForwards stops all port forwards of a session
+func (sm *SessionManager) stopPortForwards(sessionID string) {
+	sm.forwardsMu.Lock()
+	var stopped []*portForward
+	for id, forward := range sm.forwards {
+		if forward.info.SessionID == sessionID {
+			stopped = append(stopped, forward)
+			delete(sm.forwards, id)
+		}
+	}
+	sm.forwardsMu.Unlock()
+
+	for _, forward := range stopped {
+		forward.close()
+	}
+}
+
+// forwardHost turns a listening socket's wildcard address into a loopback address, since
+// a wildcard cannot be connected to; other hosts are returned unchanged
+func forwardHost(host string) string {
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	switch host {
+	case "", "*", "0.0.0.0":
+		return "127.0.0.1"
+	case "::":
+		return "::1"
+	}
+	return host
+}
+
+// snapshot returns the forward's details with current connection counts
+func (f *portForward) snapshot() PortForward {
+	info := f.info
+	info.ActiveConnections = f.active.Load()
+	info.TotalConnections = f.total.Load()
+	return info
+}
+
+// serve accepts local connections until the listener is closed
+func (f *portForward) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				fmt.Printf("Failed to accept forwarded connection on %s: %v\n", f.info.LocalAddress, err)
+			}
+			return
+		}
+		go f.relay(conn)
+	}
+}
+
+// relay copies data between a local connection and a new connection from the server
+// until either side closes
+func (f *portForward) relay(local net.Conn) {
+	f.total.Add(1)
+	f.active.Add(1)
+	defer f.active.Add(-1)
+
+	if !f.track(local) {
+		return
+	}
+	defer f.untrack(local)
+
+	address := net.JoinHostPort(f.info.RemoteHost, strconv.Itoa(f.info.RemotePort))
+	remote, err := f.client.client.Dial("tcp", address)
+	if err != nil {
+		fmt.Printf("Failed to forward connection to %s: %v\n", address, err)
+		return
+	}
+	if !f.track(remote) {
+		return
+	}
+	defer f.untrack(remote)
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(remote, local)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(local, remote)
+		done <- struct{}{}
+	}()
+
+	// Closing both ends once either is done also ends the other copy
+	<-done
+	local.Close()
+	remote.Close()
+	<-done
+}
+
+// track registers a connection to be closed with the forward; a connection arriving
+// after the forward was stopped is closed right away
+func (f *portForward) track(conn net.Conn) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		conn.Close()
+		return false
+	}
+	f.conns[conn] = struct{}{}
+	return true
+}
+
+// untrack closes a connection and forgets it
+func (f *portForward) untrack(conn net.Conn) {
+	f.mu.Lock()
+	delete(f.conns, conn)
+	f.mu.Unlock()
+
+	conn.Close()
+}
+
+// close stops accepting connections and closes the ones being relayed
+func (f *portForward) close() {
+	f.listener.Close()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.closed = true
+	for conn := range f.conns {
+		conn.Close()
+	}
+}
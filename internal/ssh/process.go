@@ -0,0 +1,280 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrSudoRequired is returned when a process action is not permitted without sudo
+var ErrSudoRequired = errors.New("operation not permitted, retry with sudo")
+
+// ProcessInfo describes a running process
+type ProcessInfo struct {
+	PID        int       `json:"pid"`
+	PPID       int       `json:"ppid"`
+	User       string    `json:"user"`
+	Name       string    `json:"name"`
+	Command    string    `json:"command"` // Full command line; kernel threads show as [name]
+	RSS        uint64    `json:"rss"`     // Resident memory (bytes)
+	CPUPercent float64   `json:"cpu_percent"`
+	MemPercent float64   `json:"mem_percent"`
+	Nice       int       `json:"nice"`
+	State      string    `json:"state"`
+	StartTime  time.Time `json:"start_time" ts_type:"string"`
+}
+
+// ProcessListOptions selects the processes returned by ListProcesses
+type ProcessListOptions struct {
+	SortBy string `json:"sort_by"` // "cpu" (default) or "memory"
+	Limit  int    `json:"limit"`   // 0 = all processes
+}
+
+// ProcessList is the top of the process table
+type ProcessList struct {
+	Processes []ProcessInfo `json:"processes"`
+	Total     int           `json:"total"` // Processes on the host, before the limit
+	Timestamp int64         `json:"timestamp"`
+	Basic     bool          `json:"basic"` // ps had no CPU and memory columns (busybox)
+}
+
+// SudoOptions runs a command through sudo. Without a password, sudo must not ask for one.
+type SudoOptions struct {
+	Enabled  bool   `json:"enabled"`
+	Password string `json:"password,omitempty"`
+}
+
+// processSignals are the signals SignalProcess accepts
+var processSignals = map[string]bool{
+	"TERM": true, "KILL": true, "HUP": true, "INT": true, "QUIT": true,
+	"STOP": true, "CONT": true, "USR1": true, "USR2": true,
+}
+
+// processBasicMarker precedes the output of the fallback ps invocation
+const processBasicMarker = "#basic"
+
+// processScript prints its own PID, so its shell and ps can be left out, then the process
+// table. The user header is padded because procps sizes the column to it, and would
+// otherwise print long user names as UIDs. Busybox ps lacks most columns, so it gets a
+// reduced set.
+const processScript = `export LC_ALL=C
+echo $$
+ps -Ao pid,ppid,user=USER________________________________,nice,rss,pcpu,pmem,etime,stat,args 2>/dev/null && exit 0
+echo '` + processBasicMarker + `'
+ps -o pid,ppid,user,nice,etime,args`
+
+// ListProcesses lists the host's processes, heaviest first
+func (c *Client) ListProcesses(ctx context.Context, opts ProcessListOptions) (*ProcessList, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	if err := c.RunCommandStream(ctx, "sh -c "+shellQuote(processScript), nil, &stdout, &stderr); err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	list := parseProcesses(stdout.String(), time.Now())
+	sortProcesses(list.Processes, opts.SortBy)
+	if opts.Limit > 0 && len(list.Processes) > opts.Limit {
+		list.Processes = list.Processes[:opts.Limit]
+	}
+	return list, nil
+}
+
+// parseProcesses parses the output of processScript; start times are relative to now
+func parseProcesses(output string, now time.Time) *ProcessList {
+	list := &ProcessList{Processes: []ProcessInfo{}, Timestamp: now.Unix()}
+
+	lines := strings.Split(output, "\n")
+	self, _ := strconv.Atoi(strings.TrimSpace(lines[0]))
+
+	header := true
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == processBasicMarker {
+			list.Basic, header = true, true
+			continue
+		}
+		if header {
+			header = false
+			continue
+		}
+
+		columns := 10
+		if list.Basic {
+			columns = 6
+		}
+		fields, command := cutFields(line, columns-1)
+		if len(fields) < columns-1 || command == "" {
+			continue
+		}
+
+		proc := ProcessInfo{Command: command}
+		proc.PID, _ = strconv.Atoi(fields[0])
+		proc.PPID, _ = strconv.Atoi(fields[1])
+		proc.User = fields[2]
+		proc.Nice, _ = strconv.Atoi(fields[3]) // "-" for realtime processes
+		elapsed := fields[4]
+		if !list.Basic {
+			rss, _ := strconv.ParseUint(fields[4], 10, 64)
+			proc.RSS = rss * 1024
+			proc.CPUPercent, _ = strconv.ParseFloat(fields[5], 64)
+			proc.MemPercent, _ = strconv.ParseFloat(fields[6], 64)
+			elapsed = fields[7]
+			proc.State = fields[8]
+		}
+		if proc.PID == 0 || proc.PID == self || proc.PPID == self {
+			continue
+		}
+
+		if seconds, ok := parseElapsed(elapsed); ok {
+			proc.StartTime = now.Add(-time.Duration(seconds) * time.Second).Truncate(time.Second)
+		}
+		proc.Name = command
+		if !strings.HasPrefix(command, "[") {
+			name, _, _ := strings.Cut(command, " ")
+			proc.Name = strings.TrimSuffix(path.Base(name), ":") // Daemons such as postgres rewrite their title
+		}
+
+		list.Processes = append(list.Processes, proc)
+	}
+
+	list.Total = len(list.Processes)
+	return list
+}
+
+// cutFields splits off the first n whitespace-separated fields, returning the rest of
+// the line with its spacing intact
+func cutFields(line string, n int) ([]string, string) {
+	fields := make([]string, 0, n)
+	rest := strings.TrimLeft(line, " \t")
+	for len(fields) < n && rest != "" {
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			end = len(rest)
+		}
+		fields = append(fields, rest[:end])
+		rest = strings.TrimLeft(rest[end:], " \t")
+	}
+	return fields, strings.TrimRight(rest, " \t\r")
+}
+
+// parseElapsed parses ps etime output, [[dd-]hh:]mm:ss, into seconds
+func parseElapsed(etime string) (int64, bool) {
+	var days int64
+	if d, rest, ok := strings.Cut(etime, "-"); ok {
+		var err error
+		if days, err = strconv.ParseInt(d, 10, 64); err != nil {
+			return 0, false
+		}
+		etime = rest
+	}
+
+	parts := strings.Split(etime, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+	var seconds int64
+	for _, part := range parts {
+		value, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		seconds = seconds*60 + value
+	}
+	return days*86400 + seconds, true
+}
+
+// sortProcesses orders processes by CPU or memory use, heaviest first
+func sortProcesses(procs []ProcessInfo, sortBy string) {
+	sort.SliceStable(procs, func(i, j int) bool {
+		a, b := &procs[i], &procs[j]
+		if sortBy == "memory" {
+			if a.RSS != b.RSS {
+				return a.RSS > b.RSS
+			}
+			return a.CPUPercent > b.CPUPercent
+		}
+		if a.CPUPercent != b.CPUPercent {
+			return a.CPUPercent > b.CPUPercent
+		}
+		return a.RSS > b.RSS
+	})
+}
+
+// SignalProcess sends a signal such as "TERM" or "KILL" to a process
+func (c *Client) SignalProcess(ctx context.Context, pid int, signal string, sudo SudoOptions) error {
+	signal = strings.TrimPrefix(strings.ToUpper(signal), "SIG")
+	if !processSignals[signal] {
+		return fmt.Errorf("unsupported signal: %s", signal)
+	}
+	if pid <= 1 {
+		return fmt.Errorf("invalid process ID: %d", pid)
+	}
+
+	return c.runProcessCommand(ctx, fmt.Sprintf("kill -s %s %d", signal, pid), sudo)
+}
+
+// ReniceProcess sets a process's nice value, from -20 (highest priority) to 19.
+// Lowering it below the current value needs root.
+func (c *Client) ReniceProcess(ctx context.Context, pid, nice int, sudo SudoOptions) error {
+	if nice < -20 || nice > 19 {
+		return fmt.Errorf("nice value out of range: %d", nice)
+	}
+	if pid <= 0 {
+		return fmt.Errorf("invalid process ID: %d", pid)
+	}
+
+	// Busybox takes -n as an increment, so the value is given in the historical absolute form
+	return c.runProcessCommand(ctx, fmt.Sprintf("renice %d -p %d", nice, pid), sudo)
+}
+
+// runProcessCommand runs kill or renice, through sudo if requested. Permission errors
+// without sudo are reported as ErrSudoRequired so the caller can offer to retry.
+func (c *Client) runProcessCommand(ctx context.Context, cmd string, sudo SudoOptions) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	tool, _, _ := strings.Cut(cmd, " ")
+	cmd, stdin := sudoCommand(cmd, sudo)
+
+	var stderr bytes.Buffer
+	err := c.RunCommandStream(ctx, "LC_ALL=C "+cmd, stdin, nil, &stderr)
+	if err == nil {
+		return nil
+	}
+
+	return processCommandError(tool, strings.TrimSpace(stderr.String()), sudo.Enabled, err)
+}
+
+// processCommandError describes a failed kill or renice. EPERM ("Operation not
+// permitted") and EACCES ("Permission denied", as when lowering the nice value) both
+// mean root is needed.
+func processCommandError(tool, message string, sudo bool, err error) error {
+	lower := strings.ToLower(message)
+	if !sudo && (strings.Contains(lower, "not permitted") || strings.Contains(lower, "permission denied")) {
+		return fmt.Errorf("%w: %s", ErrSudoRequired, message)
+	}
+	if message != "" {
+		return fmt.Errorf("%s failed: %s", tool, message)
+	}
+	return fmt.Errorf("failed to run %s: %w", tool, err)
+}
+
+// sudoCommand prefixes cmd with sudo if requested, returning the input that feeds sudo
+// its password
+func sudoCommand(cmd string, sudo SudoOptions) (string, io.Reader) {
+	if !sudo.Enabled {
+		return cmd, nil
+	}
+	if sudo.Password != "" {
+		return "sudo -S -p '' -- " + cmd, strings.NewReader(sudo.Password + "\n")
+	}
+	return "sudo -n -- " + cmd, nil
+}
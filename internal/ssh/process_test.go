@@ -0,0 +1,80 @@
+package ssh
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseProcesses(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	// The script's own shell (4242) and ps are left out
+	output := `4242
+  PID  PPID USER_______________________________  NI   RSS %CPU %MEM     ELAPSED STAT COMMAND
+    1     0 root                                  0  9600  0.2  0.1  3-01:00:00 Ss   /sbin/init splash
+    2     0 root                                  0     0  0.0  0.0  3-01:00:00 S    [kthreadd]
+  812     1 postgres                              -  1024 12.5  3.2       10:00 Rl   postgres: writer   process
+ 4243  4242 root                                  0  3000  0.0  0.0       00:00 R    ps -Ao pid
+`
+	list := parseProcesses(output, now)
+	sortProcesses(list.Processes, "cpu")
+
+	want := []ProcessInfo{
+		{PID: 812, PPID: 1, User: "postgres", Name: "postgres", Command: "postgres: writer   process", RSS: 1024 * 1024, CPUPercent: 12.5, MemPercent: 3.2, State: "Rl", StartTime: now.Add(-10 * time.Minute)},
+		{PID: 1, User: "root", Name: "init", Command: "/sbin/init splash", RSS: 9600 * 1024, CPUPercent: 0.2, MemPercent: 0.1, State: "Ss", StartTime: now.Add(-73 * time.Hour)},
+		{PID: 2, User: "root", Name: "[kthreadd]", Command: "[kthreadd]", State: "S", StartTime: now.Add(-73 * time.Hour)},
+	}
+	if !reflect.DeepEqual(list.Processes, want) || list.Total != 3 || list.Basic {
+		t.Errorf("processes = %+v, want %+v", list.Processes, want)
+	}
+
+	// Busybox ps only has the basic columns
+	basic := parseProcesses("7\n#basic\nPID   PPID  USER     NI    ELAPSED COMMAND\n    1     0 root      0   1:02:03 init\n", now)
+	if !basic.Basic || len(basic.Processes) != 1 || basic.Processes[0].StartTime != now.Add(-3723*time.Second) {
+		t.Errorf("basic = %+v", basic)
+	}
+}
+
+func TestParseElapsed(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+		ok    bool
+	}{
+		{"00:05", 5, true},
+		{"01:02:03", 3723, true},
+		{"2-00:00:01", 172801, true},
+		{"5", 0, false},
+		{"x-00:01", 0, false},
+	}
+
+	for _, tt := range tests {
+		if got, ok := parseElapsed(tt.input); got != tt.want || ok != tt.ok {
+			t.Errorf("parseElapsed(%q) = %d, %t, want %d, %t", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestProcessCommandError(t *testing.T) {
+	exitErr := errors.New("Process exited with status 1")
+	tests := []struct {
+		tool, message string
+		sudo          bool
+		sudoRequired  bool
+	}{
+		{"kill", "sh: kill: (1) - Operation not permitted", false, true},
+		{"renice", "renice: failed to set priority for 812 (process ID): Permission denied", false, true},
+		{"renice", "renice: setpriority: Permission denied", false, true},
+		{"renice", "renice: failed to set priority for 812 (process ID): Permission denied", true, false},
+		{"kill", "sh: kill: (99999) - No such process", false, false},
+		{"kill", "", false, false},
+	}
+	for _, test := range tests {
+		err := processCommandError(test.tool, test.message, test.sudo, exitErr)
+		if err == nil || errors.Is(err, ErrSudoRequired) != test.sudoRequired {
+			t.Errorf("processCommandError(%q, sudo %v) = %v, want sudo required %v", test.message, test.sudo, err, test.sudoRequired)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package ssh
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// maxRateLimitWait bounds a single sleep so rate changes apply to transfers that are already waiting
+const maxRateLimitWait = 100 * time.Millisecond
+
+// RateLimiter is a token bucket limiting throughput in bytes per second.
+// A nil RateLimiter or a rate of 0 does not limit. The rate can be changed at any time.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   int64 // bytes per second, 0 = unlimited
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a rate limiter; bytesPerSecond <= 0 means unlimited
+func NewRateLimiter(bytesPerSecond int64) *RateLimiter {
+	return &RateLimiter{
+		rate: max(bytesPerSecond, 0),
+		last: time.Now(),
+	}
+}
+
+// SetRate changes the limit; bytesPerSecond <= 0 removes it
+func (rl *RateLimiter) SetRate(bytesPerSecond int64) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.refill(time.Now())
+	rl.rate = max(bytesPerSecond, 0)
+	if rl.rate == 0 {
+		rl.tokens = 0
+	} else if rl.tokens > float64(rl.rate) {
+		rl.tokens = float64(rl.rate)
+	}
+}
+
+// Rate returns the current limit in bytes per second, 0 = unlimited
+func (rl *RateLimiter) Rate() int64 {
+	if rl == nil {
+		return 0
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.rate
+}
+
+// WaitN takes n bytes from the bucket and blocks until they are covered.
+// n may exceed the bucket size; the debt is then paid off before returning.
+func (rl *RateLimiter) WaitN(ctx context.Context, n int) error {
+	if rl == nil {
+		return nil
+	}
+
+	rl.mu.Lock()
+	if rl.rate == 0 {
+		rl.mu.Unlock()
+		return nil
+	}
+	rl.refill(time.Now())
+	rl.tokens -= float64(n)
+	rl.mu.Unlock()
+
+	for {
+		rl.mu.Lock()
+		rl.refill(time.Now())
+		rate := rl.rate
+		deficit := -rl.tokens
+		rl.mu.Unlock()
+
+		if rate == 0 || deficit <= 0 {
+			return nil
+		}
+
+		wait := time.Duration(deficit / float64(rate) * float64(time.Second))
+		if wait > maxRateLimitWait {
+			wait = maxRateLimitWait
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// refill adds the tokens earned since the last call; the bucket holds at most one second of data
+func (rl *RateLimiter) refill(now time.Time) {
+	elapsed := now.Sub(rl.last).Seconds()
+	rl.last = now
+	if rl.rate == 0 || elapsed <= 0 {
+		return
+	}
+
+	rl.tokens += elapsed * float64(rl.rate)
+	if rl.tokens > float64(rl.rate) {
+		rl.tokens = float64(rl.rate)
+	}
+}
+
+// rateLimitedReader paces reads with rate limiters, for streams copied without streamWithProgress
+type rateLimitedReader struct {
+	ctx      context.Context
+	r        io.Reader
+	limiters []*RateLimiter
+}
+
+func (lr *rateLimitedReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	if n > 0 {
+		if waitErr := waitAll(lr.ctx, lr.limiters, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// rateLimitedWriter paces writes with rate limiters
+type rateLimitedWriter struct {
+	ctx      context.Context
+	w        io.Writer
+	limiters []*RateLimiter
+}
+
+func (lw *rateLimitedWriter) Write(p []byte) (int, error) {
+	if err := waitAll(lw.ctx, lw.limiters, len(p)); err != nil {
+		return 0, err
+	}
+	return lw.w.Write(p)
+}
+
+// limitReader wraps r so reads wait on limiters; r is returned as is without limiters
+func limitReader(ctx context.Context, r io.Reader, limiters []*RateLimiter) io.Reader {
+	if len(limiters) == 0 {
+		return r
+	}
+	return &rateLimitedReader{ctx: ctx, r: r, limiters: limiters}
+}
+
+// limitWriter wraps w so writes wait on limiters; w is returned as is without limiters
+func limitWriter(ctx context.Context, w io.Writer, limiters []*RateLimiter) io.Writer {
+	if len(limiters) == 0 {
+		return w
+	}
+	return &rateLimitedWriter{ctx: ctx, w: w, limiters: limiters}
+}
+
+// waitAll waits on each limiter in turn, so the strictest one sets the pace
+func waitAll(ctx context.Context, limiters []*RateLimiter, n int) error {
+	for _, limiter := range limiters {
+		if err := limiter.WaitN(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
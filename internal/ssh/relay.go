@@ -0,0 +1,346 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// RelayOptions controls how a server-to-server transfer is performed
+type RelayOptions struct {
+	Direct     bool   `json:"direct"`      // Run scp/rsync on the source host instead of streaming through this machine
+	Tool       string `json:"tool"`        // "rsync" (default) or "scp", direct mode only
+	TargetHost string `json:"target_host"` // Target address as seen from the source host, direct mode only
+
+	// AcceptNewHostKey lets the source host trust the target's key on first contact
+	// (StrictHostKeyChecking=accept-new), direct mode only. Off by default, so the
+	// target must already be in the source host's known_hosts.
+	AcceptNewHostKey bool `json:"accept_new_host_key"`
+}
+
+// relayEntry is a single file or directory to be copied by RelayPath
+type relayEntry struct {
+	srcPath string
+	dstPath string
+	size    int64
+	mode    os.FileMode
+	isDir   bool
+}
+
+// RelayPath copies a file or directory from one SFTP session to another, streaming
+// the data through this machine without touching the local disk.
+// Only individual SFTP calls take the client locks, so both clients stay usable
+// (and src may equal dst) while the data is streaming.
+func RelayPath(ctx context.Context, src, dst *SFTPClient, srcPath, dstPath string, progressCb func(TransferProgress), limiters ...*RateLimiter) error {
+	srcPath = normalizePath(srcPath)
+	dstPath = normalizePath(dstPath)
+	defer dst.InvalidateListings(dstPath)
+
+	entries, totalBytes, err := src.collectRelayEntries(srcPath, dstPath)
+	if err != nil {
+		return err
+	}
+
+	var bytesDone int64
+	var lastSpeed int64
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if entry.isDir {
+			dst.mu.Lock()
+			err := dst.client.MkdirAll(entry.dstPath)
+			dst.mu.Unlock()
+			if err != nil {
+				return fmt.Errorf("failed to create directory %s: %w", entry.dstPath, err)
+			}
+			continue
+		}
+
+		base := bytesDone
+		err := relayFile(ctx, src, dst, entry, limiters, func(progress TransferProgress) {
+			// Report aggregated progress across all files of the transfer
+			progress.BytesSent += base
+			progress.TotalBytes = totalBytes
+			progress.Percentage = relayPercentage(progress.BytesSent, totalBytes)
+			progress.Status = "running"
+			lastSpeed = progress.Speed
+			if progressCb != nil {
+				progressCb(progress)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		bytesDone += entry.size
+	}
+
+	if progressCb != nil {
+		progressCb(TransferProgress{
+			BytesSent:  bytesDone,
+			TotalBytes: totalBytes,
+			Percentage: 100.0,
+			Speed:      lastSpeed,
+			Status:     "completed",
+		})
+	}
+
+	return nil
+}
+
+// relayFile streams a single regular file from src to dst
+func relayFile(ctx context.Context, src, dst *SFTPClient, entry relayEntry, limiters []*RateLimiter, progressCb func(TransferProgress)) error {
+	src.mu.Lock()
+	srcFile, err := src.client.Open(entry.srcPath)
+	src.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to open source file %s: %w", entry.srcPath, err)
+	}
+	defer srcFile.Close()
+
+	dst.mu.Lock()
+	dstFile, err := dst.client.Create(entry.dstPath)
+	dst.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to create target file %s: %w", entry.dstPath, err)
+	}
+	defer dstFile.Close()
+
+	if err := streamWithProgress(ctx, srcFile, dstFile, entry.size, progressCb, limiters...); err != nil {
+		return fmt.Errorf("failed to copy %s: %w", entry.srcPath, err)
+	}
+
+	// Preserve permission bits, ignoring servers that refuse chmod
+	dst.mu.Lock()
+	dst.client.Chmod(entry.dstPath, entry.mode.Perm())
+	dst.mu.Unlock()
+
+	return nil
+}
+
+// collectRelayEntries walks srcPath and maps every entry to its destination path.
+// Directories come before their contents so they can be created in order.
+func (sc *SFTPClient) collectRelayEntries(srcPath, dstPath string) ([]relayEntry, int64, error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	stat, err := sc.client.Stat(srcPath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to stat source: %w", err)
+	}
+
+	if !stat.IsDir() {
+		return []relayEntry{{
+			srcPath: srcPath,
+			dstPath: dstPath,
+			size:    stat.Size(),
+			mode:    stat.Mode(),
+		}}, stat.Size(), nil
+	}
+
+	var entries []relayEntry
+	var totalBytes int64
+
+	walker := sc.client.Walk(srcPath)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return nil, 0, fmt.Errorf("failed to walk %s: %w", walker.Path(), err)
+		}
+
+		info := walker.Stat()
+		entry := relayEntry{
+			srcPath: walker.Path(),
+			dstPath: path.Join(dstPath, strings.TrimPrefix(walker.Path(), srcPath)),
+			size:    info.Size(),
+			mode:    info.Mode(),
+			isDir:   info.IsDir(),
+		}
+
+		// Follow symlinks to files, skip symlinks to directories to avoid loops
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := sc.client.Stat(walker.Path())
+			if err != nil || target.IsDir() {
+				continue
+			}
+			entry.size = target.Size()
+			entry.mode = target.Mode()
+		}
+
+		if !entry.isDir {
+			totalBytes += entry.size
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, totalBytes, nil
+}
+
+// relayPercentage returns done/total as a percentage, treating an empty transfer as complete
+func relayPercentage(done, total int64) float64 {
+	if total <= 0 {
+		return 100.0
+	}
+	return float64(done) / float64(total) * 100
+}
+
+// contextReader aborts reads once its context is cancelled
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
+// rsyncProgressRegex matches rsync --info=progress2 lines, e.g. "  1,234,567  45%   12.34MB/s    0:00:03"
+var rsyncProgressRegex = regexp.MustCompile(`^\s*([\d,]+)\s+(\d+)%\s+([\d.]+)([kMGT]?B)/s`)
+
+// DirectRelay runs rsync or scp on the source host to push srcPaths straight to dstDir
+// on the target host. The local SSH agent is forwarded to the source host so it can
+// authenticate against the target; both hosts must be able to reach each other.
+func DirectRelay(ctx context.Context, src, dst *Client, srcPaths []string, dstDir string, opts RelayOptions, progressCb func(TransferProgress)) error {
+	if len(srcPaths) == 0 {
+		return fmt.Errorf("no source paths")
+	}
+
+	host, port, err := net.SplitHostPort(dst.Address())
+	if err != nil {
+		return fmt.Errorf("invalid target address: %w", err)
+	}
+	if opts.TargetHost != "" {
+		host = opts.TargetHost
+	}
+
+	sources := make([]string, len(srcPaths))
+	for i, p := range srcPaths {
+		sources[i] = normalizePath(p)
+	}
+	target := fmt.Sprintf("%s@%s:%s/", dst.User(), host, normalizePath(dstDir))
+	hostKeyChecking := "yes"
+	if opts.AcceptNewHostKey {
+		hostKeyChecking = "accept-new"
+	}
+	sshOpts := fmt.Sprintf("-o BatchMode=yes -o StrictHostKeyChecking=%s", hostKeyChecking)
+
+	tool := opts.Tool
+	if tool == "" {
+		tool = "rsync"
+	}
+
+	var cmd string
+	switch tool {
+	case "rsync":
+		cmd = fmt.Sprintf("rsync -a --info=progress2 -e %s %s %s",
+			shellQuote("ssh "+sshOpts+" -p "+port), shellQuoteAll(sources), shellQuote(target))
+	case "scp":
+		cmd = fmt.Sprintf("scp -r %s -P %s %s %s",
+			sshOpts, port, shellQuoteAll(sources), shellQuote(target))
+	default:
+		return fmt.Errorf("unsupported direct transfer tool: %s", tool)
+	}
+
+	prepare, err := src.agentForwarder()
+	if err != nil {
+		return err
+	}
+
+	var stderr bytes.Buffer
+	stdout := &lineWriter{fn: func(line string) {
+		if progress, ok := parseRsyncProgress(line); ok && progressCb != nil {
+			progressCb(progress)
+		}
+	}}
+
+	if progressCb != nil {
+		progressCb(TransferProgress{Status: "running"})
+	}
+
+	err = src.runCommandStream(ctx, cmd, nil, stdout, &stderr, prepare)
+	if err != nil {
+		var exitErr *ssh.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitStatus() == 127 {
+			return fmt.Errorf("%s is not installed on the source host", tool)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s failed: %s", tool, msg)
+		}
+		return fmt.Errorf("%s failed: %w", tool, err)
+	}
+
+	if progressCb != nil {
+		progressCb(TransferProgress{Percentage: 100.0, Status: "completed"})
+	}
+
+	return nil
+}
+
+// parseRsyncProgress converts an rsync --info=progress2 line into a progress update
+func parseRsyncProgress(line string) (TransferProgress, bool) {
+	matches := rsyncProgressRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return TransferProgress{}, false
+	}
+
+	sent, _ := strconv.ParseInt(strings.ReplaceAll(matches[1], ",", ""), 10, 64)
+	percentage, _ := strconv.ParseFloat(matches[2], 64)
+	rate, _ := strconv.ParseFloat(matches[3], 64)
+
+	switch matches[4] {
+	case "kB":
+		rate *= 1024
+	case "MB":
+		rate *= 1024 * 1024
+	case "GB":
+		rate *= 1024 * 1024 * 1024
+	case "TB":
+		rate *= 1024 * 1024 * 1024 * 1024
+	}
+
+	progress := TransferProgress{
+		BytesSent:  sent,
+		Percentage: percentage,
+		Speed:      int64(rate),
+		Status:     "running",
+	}
+	if percentage > 0 {
+		progress.TotalBytes = int64(float64(sent) * 100 / percentage)
+	}
+
+	return progress, true
+}
+
+// lineWriter splits written data on \r or \n and hands each complete line to fn
+type lineWriter struct {
+	fn  func(line string)
+	buf []byte
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	lw.buf = append(lw.buf, p...)
+	for {
+		idx := bytes.IndexAny(lw.buf, "\r\n")
+		if idx == -1 {
+			break
+		}
+		if idx > 0 {
+			lw.fn(string(lw.buf[:idx]))
+		}
+		lw.buf = lw.buf[idx+1:]
+	}
+	return len(p), nil
+}
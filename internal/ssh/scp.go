@@ -0,0 +1,235 @@
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// FileTransferer copies single files between the local machine and a server.
+// SFTPClient and SCPClient implement it.
+type FileTransferer interface {
+	UploadFile(ctx context.Context, localPath, remotePath string, progressCb func(TransferProgress), limiters ...*RateLimiter) error
+	DownloadFile(ctx context.Context, remotePath, localPath string, progressCb func(TransferProgress), limiters ...*RateLimiter) error
+}
+
+// SCPClient transfers files with the scp protocol over an exec channel, for servers
+// without an SFTP subsystem. The remote side needs the scp binary.
+type SCPClient struct {
+	client *Client
+}
+
+// NewSCPClient creates an SCP client on an existing SSH connection
+func NewSCPClient(client *Client) *SCPClient {
+	return &SCPClient{client: client}
+}
+
+// UploadFile uploads a file by running the remote scp in sink mode (scp -t)
+func (c *SCPClient) UploadFile(ctx context.Context, localPath, remotePath string, progressCb func(TransferProgress), limiters ...*RateLimiter) error {
+	remotePath = normalizePath(remotePath)
+
+	localFile, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open local file: %w", err)
+	}
+	defer localFile.Close()
+
+	stat, err := localFile.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat local file: %w", err)
+	}
+	if !stat.Mode().IsRegular() {
+		return fmt.Errorf("not a regular file: %s", localPath)
+	}
+	totalBytes := stat.Size()
+
+	return c.run(ctx, "scp -t -- "+shellQuote(remotePath), func(remote *bufio.Reader, w io.Writer) error {
+		if err := readSCPAck(remote); err != nil {
+			return err
+		}
+
+		header := fmt.Sprintf("C%04o %d %s\n", stat.Mode().Perm(), totalBytes, path.Base(remotePath))
+		if _, err := io.WriteString(w, header); err != nil {
+			return fmt.Errorf("failed to send file header: %w", err)
+		}
+		if err := readSCPAck(remote); err != nil {
+			return err
+		}
+
+		if err := streamWithProgress(ctx, localFile, w, totalBytes, progressCb, limiters...); err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte{0}); err != nil {
+			return fmt.Errorf("failed to finish file: %w", err)
+		}
+		return readSCPAck(remote)
+	})
+}
+
+// DownloadFile downloads a file by running the remote scp in source mode (scp -f)
+func (c *SCPClient) DownloadFile(ctx context.Context, remotePath, localPath string, progressCb func(TransferProgress), limiters ...*RateLimiter) error {
+	remotePath = normalizePath(remotePath)
+
+	return c.run(ctx, "scp -f -- "+shellQuote(remotePath), func(remote *bufio.Reader, w io.Writer) error {
+		if _, err := w.Write([]byte{0}); err != nil {
+			return fmt.Errorf("failed to start transfer: %w", err)
+		}
+
+		var totalBytes int64
+		for {
+			line, err := readSCPLine(remote)
+			if err != nil {
+				return err
+			}
+
+			switch line[0] {
+			case 'T':
+				// Modification times are not preserved
+				if _, err := w.Write([]byte{0}); err != nil {
+					return err
+				}
+				continue
+			case 'D':
+				return fmt.Errorf("remote path is a directory: %s", remotePath)
+			case 'C':
+				fields := strings.SplitN(line[1:], " ", 3)
+				if len(fields) != 3 {
+					return fmt.Errorf("invalid scp file header: %q", line)
+				}
+				if totalBytes, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+					return fmt.Errorf("invalid scp file size: %q", line)
+				}
+			default:
+				return fmt.Errorf("unexpected scp message: %q", line)
+			}
+			break
+		}
+
+		localFile, err := os.Create(localPath)
+		if err != nil {
+			return fmt.Errorf("failed to create local file: %w", err)
+		}
+		defer localFile.Close()
+
+		if _, err := w.Write([]byte{0}); err != nil {
+			return err
+		}
+		if err := streamWithProgress(ctx, io.LimitReader(remote, totalBytes), localFile, totalBytes, progressCb, limiters...); err != nil {
+			return err
+		}
+
+		// The source ends the file with its status; a short file means it failed mid-transfer
+		if stat, err := localFile.Stat(); err == nil && stat.Size() != totalBytes {
+			return fmt.Errorf("connection closed after %d of %d bytes", stat.Size(), totalBytes)
+		}
+		if err := readSCPAck(remote); err != nil {
+			return err
+		}
+		_, err = w.Write([]byte{0})
+		return err
+	})
+}
+
+// run starts command with its stdin and stdout connected to fn, which speaks the
+// scp protocol. Closing stdin afterwards lets the remote scp exit.
+func (c *SCPClient) run(ctx context.Context, command string, fn func(remote *bufio.Reader, w io.Writer) error) error {
+	if c.client == nil || !c.client.IsConnected() {
+		return fmt.Errorf("client not connected")
+	}
+
+	commandCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	var stderr bytes.Buffer
+
+	done := make(chan error, 1)
+	go func() {
+		err := c.client.RunCommandStream(commandCtx, command, stdinReader, stdoutWriter, &stderr)
+		stdoutWriter.CloseWithError(io.ErrUnexpectedEOF)
+		stdinReader.Close()
+		done <- err
+	}()
+
+	protocolErr := fn(bufio.NewReader(stdoutReader), stdinWriter)
+	stdinWriter.Close()
+	if protocolErr != nil {
+		cancel()
+	}
+
+	// Unread output must not block the command from exiting
+	go io.Copy(io.Discard, stdoutReader)
+	commandErr := <-done
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// A protocol error caused by the command going away is explained by its stderr
+	message := strings.TrimSpace(stderr.String())
+	lostCommand := errors.Is(protocolErr, io.ErrUnexpectedEOF) || errors.Is(protocolErr, io.ErrClosedPipe)
+	if protocolErr != nil && !lostCommand {
+		return protocolErr
+	}
+	if message != "" && (lostCommand || commandErr != nil) {
+		return fmt.Errorf("scp failed: %s", message)
+	}
+	if protocolErr != nil {
+		return protocolErr
+	}
+	if commandErr != nil {
+		return fmt.Errorf("scp failed: %w", commandErr)
+	}
+	return nil
+}
+
+// readSCPAck reads the status byte scp sends after every step; 1 and 2 carry an error message
+func readSCPAck(r *bufio.Reader) error {
+	status, err := r.ReadByte()
+	if err != nil {
+		return fmt.Errorf("failed to read scp response: %w", err)
+	}
+	if status == 0 {
+		return nil
+	}
+
+	message, _ := r.ReadString('\n')
+	if status == 1 || status == 2 {
+		return scpError(message)
+	}
+	return fmt.Errorf("unexpected scp response %q", string(status)+message)
+}
+
+// readSCPLine reads a protocol message from the source, turning error messages into errors
+func readSCPLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read scp message: %w", err)
+	}
+	line = strings.TrimSuffix(line, "\n")
+
+	if line == "" {
+		return "", fmt.Errorf("empty scp message")
+	}
+	if line[0] == 1 || line[0] == 2 {
+		return "", scpError(line[1:])
+	}
+	return line, nil
+}
+
+// scpError turns a message from the remote scp into an error
+func scpError(message string) error {
+	message = strings.TrimSpace(message)
+	if !strings.HasPrefix(message, "scp:") {
+		message = "scp: " + message
+	}
+	return errors.New(message)
+}
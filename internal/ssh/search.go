@@ -0,0 +1,485 @@
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileSearchQuery describes a file search below Path
+type FileSearchQuery struct {
+	Path           string `json:"path"`
+	NamePattern    string `json:"name_pattern"`    // Empty matches every file name
+	NameRegex      bool   `json:"name_regex"`      // Treat NamePattern as a regular expression instead of a glob
+	Content        string `json:"content"`         // Only match files containing this text
+	ContentRegex   bool   `json:"content_regex"`   // Treat Content as an extended regular expression
+	IgnoreCase     bool   `json:"ignore_case"`     // Case-insensitive name and content matching
+	MinSize        int64  `json:"min_size"`        // Bytes, 0 = no lower bound
+	MaxSize        int64  `json:"max_size"`        // Bytes, 0 = no upper bound
+	ModifiedAfter  int64  `json:"modified_after"`  // Unix seconds, 0 = no bound
+	ModifiedBefore int64  `json:"modified_before"` // Unix seconds, 0 = no bound
+	MaxDepth       int    `json:"max_depth"`       // 0 = unlimited
+	MaxResults     int    `json:"max_results"`     // 0 = default (1000), capped at 10000
+	PageSize       int    `json:"page_size"`       // 0 = default (100)
+}
+
+// FileSearchMatch is a single search hit; Line and Preview are set for content matches
+type FileSearchMatch struct {
+	Path    string    `json:"path"`
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time" ts_type:"string"`
+	Line    int       `json:"line,omitempty"`
+	Preview string    `json:"preview,omitempty"`
+}
+
+// FileSearchPage is a batch of matches delivered while a search is running
+type FileSearchPage struct {
+	SearchID  string            `json:"search_id"`
+	Page      int               `json:"page"`
+	Matches   []FileSearchMatch `json:"matches"`
+	Done      bool              `json:"done"`
+	Truncated bool              `json:"truncated"` // MaxResults was reached
+	Error     string            `json:"error,omitempty"`
+}
+
+const (
+	defaultSearchResults  = 1000
+	maxSearchResults      = 10000
+	defaultSearchPageSize = 100
+	// Files larger than this are not scanned for content in the SFTP fallback
+	maxFallbackGrepSize = 10 * 1024 * 1024
+	maxPreviewLength    = 200
+)
+
+// errSearchLimit stops a search once MaxResults matches were collected
+var errSearchLimit = fmt.Errorf("search result limit reached")
+
+// searchPager batches matches into pages
+type searchPager struct {
+	query   FileSearchQuery
+	pageCb  func(FileSearchPage)
+	matches []FileSearchMatch
+	page    int
+	total   int
+}
+
+func (sp *searchPager) add(match FileSearchMatch) error {
+	sp.matches = append(sp.matches, match)
+	sp.total++
+	if len(sp.matches) >= sp.query.PageSize {
+		sp.flush(false, false)
+	}
+	if sp.total >= sp.query.MaxResults {
+		return errSearchLimit
+	}
+	return nil
+}
+
+func (sp *searchPager) flush(done, truncated bool) {
+	if len(sp.matches) == 0 && !done {
+		return
+	}
+	if sp.pageCb != nil {
+		sp.pageCb(FileSearchPage{
+			Page:      sp.page,
+			Matches:   sp.matches,
+			Done:      done,
+			Truncated: truncated,
+		})
+	}
+	sp.page++
+	sp.matches = nil
+}
+
+// SearchFiles searches below query.Path by name, size, modification time and content.
+// It runs find/grep on the server when GNU find is available and walks the tree over
+// SFTP otherwise. Matches are delivered in pages; the last page has Done set.
+func (sc *SFTPClient) SearchFiles(ctx context.Context, query FileSearchQuery, pageCb func(FileSearchPage)) error {
+	query.Path = normalizePath(query.Path)
+	if query.MaxResults <= 0 {
+		query.MaxResults = defaultSearchResults
+	}
+	if query.MaxResults > maxSearchResults {
+		query.MaxResults = maxSearchResults
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = defaultSearchPageSize
+	}
+
+	nameMatcher, err := newNameMatcher(query)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pager := &searchPager{query: query, pageCb: pageCb}
+
+	if sc.hasRemoteSearchTools(ctx, query) {
+		err = sc.searchRemote(ctx, query, nameMatcher, pager)
+	} else {
+		err = sc.searchSFTP(ctx, query, nameMatcher, pager)
+	}
+
+	if err == errSearchLimit {
+		pager.flush(true, true)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	pager.flush(true, false)
+	return nil
+}
+
+// hasRemoteSearchTools reports whether GNU find (and grep, for content searches) can be used
+func (sc *SFTPClient) hasRemoteSearchTools(ctx context.Context, query FileSearchQuery) bool {
+	if sc.sshClient == nil {
+		return false
+	}
+	// -printf and -newermt are GNU extensions
+	if err := sc.sshClient.RunCommandStream(ctx, "find --version", nil, nil, nil); err != nil {
+		return false
+	}
+	if query.Content != "" {
+		return sc.sshClient.requireRemoteTool(ctx, "grep") == nil
+	}
+	return true
+}
+
+// searchRemote runs find (piped into grep for content searches) over an exec channel
+func (sc *SFTPClient) searchRemote(ctx context.Context, query FileSearchQuery, nameMatcher func(string) bool, pager *searchPager) error {
+	cmd := buildFindCommand(query)
+
+	// Stop the remote command as soon as the result limit is reached
+	cmdCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	var addErr error
+	stdout := &lineWriter{fn: func(line string) {
+		if addErr != nil {
+			return
+		}
+
+		var match FileSearchMatch
+		var ok bool
+		if query.Content != "" {
+			match, ok = parseGrepLine(line)
+		} else {
+			match, ok = parseFindLine(line)
+		}
+		if !ok || !nameMatcher(match.Name) {
+			return
+		}
+
+		if addErr = pager.add(match); addErr != nil {
+			cancel()
+		}
+	}}
+
+	err := sc.sshClient.RunCommandStream(cmdCtx, cmd, nil, stdout, &stderr)
+	if addErr != nil {
+		return addErr
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	// find and grep exit non-zero on unreadable directories or when nothing matched;
+	// that is not a failure as long as the command itself ran
+	if err != nil && stderr.Len() > 0 && pager.total == 0 && !strings.Contains(stderr.String(), "Permission denied") {
+		return fmt.Errorf("search failed: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+// buildFindCommand translates a query into a find (| xargs grep) pipeline
+func buildFindCommand(query FileSearchQuery) string {
+	args := []string{"find", shellQuote(query.Path)}
+	if query.MaxDepth > 0 {
+		args = append(args, "-maxdepth", strconv.Itoa(query.MaxDepth))
+	}
+	args = append(args, "-type", "f")
+
+	// Globs are matched remotely; regexes are applied to the name in Go
+	if query.NamePattern != "" && !query.NameRegex {
+		flag := "-name"
+		if query.IgnoreCase {
+			flag = "-iname"
+		}
+		args = append(args, flag, shellQuote(query.NamePattern))
+	}
+	if query.MinSize > 0 {
+		args = append(args, "-size", fmt.Sprintf("+%dc", query.MinSize-1))
+	}
+	if query.MaxSize > 0 {
+		args = append(args, "-size", fmt.Sprintf("-%dc", query.MaxSize+1))
+	}
+	if query.ModifiedAfter > 0 {
+		args = append(args, "-newermt", fmt.Sprintf("@%d", query.ModifiedAfter))
+	}
+	if query.ModifiedBefore > 0 {
+		args = append(args, "!", "-newermt", fmt.Sprintf("@%d", query.ModifiedBefore))
+	}
+
+	if query.Content == "" {
+		args = append(args, "-printf", shellQuote(`%s\t%T@\t%p\n`))
+		return strings.Join(args, " ") + " 2>/dev/null"
+	}
+
+	grepArgs := []string{"grep", "-n", "-H", "-I", "-Z"}
+	if query.IgnoreCase {
+		grepArgs = append(grepArgs, "-i")
+	}
+	if query.ContentRegex {
+		grepArgs = append(grepArgs, "-E")
+	} else {
+		grepArgs = append(grepArgs, "-F")
+	}
+	grepArgs = append(grepArgs, "-e", shellQuote(query.Content))
+
+	return strings.Join(args, " ") + " -print0 2>/dev/null | xargs -0 -r " + strings.Join(grepArgs, " ")
+}
+
+// parseFindLine parses a "size<TAB>mtime<TAB>path" line printed by find -printf
+func parseFindLine(line string) (FileSearchMatch, bool) {
+	parts := strings.SplitN(line, "\t", 3)
+	if len(parts) != 3 {
+		return FileSearchMatch{}, false
+	}
+
+	size, _ := strconv.ParseInt(parts[0], 10, 64)
+	mtime, _ := strconv.ParseFloat(parts[1], 64)
+
+	return FileSearchMatch{
+		Path:    parts[2],
+		Name:    path.Base(parts[2]),
+		Size:    size,
+		ModTime: time.Unix(int64(mtime), 0),
+	}, true
+}
+
+// parseGrepLine parses a "path\0line:text" line printed by grep -n -H -Z
+func parseGrepLine(line string) (FileSearchMatch, bool) {
+	filePath, rest, ok := strings.Cut(line, "\x00")
+	if !ok {
+		return FileSearchMatch{}, false
+	}
+	lineNo, text, ok := strings.Cut(rest, ":")
+	if !ok {
+		return FileSearchMatch{}, false
+	}
+	n, err := strconv.Atoi(lineNo)
+	if err != nil {
+		return FileSearchMatch{}, false
+	}
+
+	return FileSearchMatch{
+		Path:    filePath,
+		Name:    path.Base(filePath),
+		Line:    n,
+		Preview: truncatePreview(text),
+	}, true
+}
+
+// searchSFTP walks the tree over SFTP, applying all filters client-side
+func (sc *SFTPClient) searchSFTP(ctx context.Context, query FileSearchQuery, nameMatcher func(string) bool, pager *searchPager) error {
+	contentMatcher, err := newContentMatcher(query)
+	if err != nil {
+		return err
+	}
+
+	baseDepth := strings.Count(strings.TrimSuffix(query.Path, "/"), "/")
+
+	sc.mu.Lock()
+	walker := sc.client.Walk(query.Path)
+	sc.mu.Unlock()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Lock per step so other file operations can interleave with a long search
+		sc.mu.Lock()
+		more := walker.Step()
+		sc.mu.Unlock()
+		if !more {
+			return nil
+		}
+
+		if walker.Err() != nil {
+			// Unreadable directories are skipped
+			continue
+		}
+
+		info := walker.Stat()
+		filePath := walker.Path()
+		depth := strings.Count(filePath, "/") - baseDepth
+
+		if info.IsDir() {
+			if query.MaxDepth > 0 && depth >= query.MaxDepth && filePath != query.Path {
+				walker.SkipDir()
+			}
+			continue
+		}
+		if !info.Mode().IsRegular() || !nameMatcher(info.Name()) {
+			continue
+		}
+		if query.MinSize > 0 && info.Size() < query.MinSize {
+			continue
+		}
+		if query.MaxSize > 0 && info.Size() > query.MaxSize {
+			continue
+		}
+		if query.ModifiedAfter > 0 && info.ModTime().Unix() <= query.ModifiedAfter {
+			continue
+		}
+		if query.ModifiedBefore > 0 && info.ModTime().Unix() > query.ModifiedBefore {
+			continue
+		}
+
+		match := FileSearchMatch{
+			Path:    filePath,
+			Name:    info.Name(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		}
+
+		if contentMatcher == nil {
+			if err := pager.add(match); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if info.Size() > maxFallbackGrepSize {
+			continue
+		}
+		if err := sc.grepFile(ctx, match, contentMatcher, pager); err != nil {
+			return err
+		}
+	}
+}
+
+// grepFile scans a remote text file line by line, adding one match per matching line
+func (sc *SFTPClient) grepFile(ctx context.Context, match FileSearchMatch, contentMatcher func(string) bool, pager *searchPager) error {
+	sc.mu.Lock()
+	file, err := sc.client.Open(match.Path)
+	sc.mu.Unlock()
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(&contextReader{ctx: ctx, r: file})
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+
+		// Skip binary files, like grep -I
+		if lineNo == 1 && strings.IndexByte(line, 0) >= 0 {
+			return nil
+		}
+
+		if contentMatcher(line) {
+			hit := match
+			hit.Line = lineNo
+			hit.Preview = truncatePreview(line)
+			if err := pager.add(hit); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// newNameMatcher builds the file name filter for a query
+func newNameMatcher(query FileSearchQuery) (func(string) bool, error) {
+	if query.NamePattern == "" {
+		return func(string) bool { return true }, nil
+	}
+
+	if query.NameRegex {
+		pattern := query.NamePattern
+		if query.IgnoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid name pattern: %w", err)
+		}
+		return re.MatchString, nil
+	}
+
+	pattern := query.NamePattern
+	if query.IgnoreCase {
+		pattern = strings.ToLower(pattern)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid name pattern: %w", err)
+	}
+	return func(name string) bool {
+		if query.IgnoreCase {
+			name = strings.ToLower(name)
+		}
+		ok, _ := path.Match(pattern, name)
+		return ok
+	}, nil
+}
+
+// newContentMatcher builds the line filter for the SFTP fallback, or nil when no content is searched
+func newContentMatcher(query FileSearchQuery) (func(string) bool, error) {
+	if query.Content == "" {
+		return nil, nil
+	}
+
+	if query.ContentRegex {
+		pattern := query.Content
+		if query.IgnoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid content pattern: %w", err)
+		}
+		return re.MatchString, nil
+	}
+
+	needle := query.Content
+	if query.IgnoreCase {
+		needle = strings.ToLower(needle)
+		return func(line string) bool {
+			return strings.Contains(strings.ToLower(line), needle)
+		}, nil
+	}
+	return func(line string) bool {
+		return strings.Contains(line, needle)
+	}, nil
+}
+
+// truncatePreview shortens a matched line for display
+func truncatePreview(line string) string {
+	line = strings.TrimRight(line, "\r")
+	if runes := []rune(line); len(runes) > maxPreviewLength {
+		return string(runes[:maxPreviewLength]) + "…"
+	}
+	return line
+}
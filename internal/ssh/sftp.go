@@ -1,6 +1,7 @@
 package ssh
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -8,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/sftp"
@@ -27,15 +29,18 @@ type FileInfo struct {
 
 // TransferProgress represents transfer progress for frontend_old
 type TransferProgress struct {
-	TransferID string  `json:"transfer_id"`
-	SessionID  string  `json:"session_id"`
-	Filename   string  `json:"filename"`
-	BytesSent  int64   `json:"bytes_sent"`
-	TotalBytes int64   `json:"total_bytes"`
-	Percentage float64 `json:"percentage"`
-	Speed      int64   `json:"speed"` // bytes per second
-	Status     string  `json:"status"`
-	Error      string  `json:"error,omitempty"`
+	TransferID      string  `json:"transfer_id"`
+	SessionID       string  `json:"session_id"`
+	TargetSessionID string  `json:"target_session_id,omitempty"`
+	Filename        string  `json:"filename"`
+	BytesSent       int64   `json:"bytes_sent"`
+	TotalBytes      int64   `json:"total_bytes"`
+	Percentage      float64 `json:"percentage"`
+	Speed           int64   `json:"speed"` // bytes per second
+	EntriesDone     int64   `json:"entries_done,omitempty"`
+	EntriesTotal    int64   `json:"entries_total,omitempty"`
+	Status          string  `json:"status"`
+	Error           string  `json:"error,omitempty"`
 }
 
 // SFTPClient wraps pkg/sftp client with metadata
@@ -44,6 +49,14 @@ type SFTPClient struct {
 	sshClient   *Client
 	currentPath string
 	mu          sync.Mutex
+	chunks      atomic.Int32 // Concurrent ranges for large files, 0 = DefaultTransferChunks
+
+	watchMu sync.Mutex
+	watches map[string]context.CancelFunc // watchID -> cancel
+
+	listMu   sync.Mutex
+	listings map[string]*cachedListing // dirPath -> recent listing
+	listGen  uint64                    // Bumped by InvalidateListings
 }
 
 // NewSFTPClient creates a new SFTP client from an existing SSH client
@@ -71,55 +84,31 @@ func NewSFTPClient(sshClient *Client) (*SFTPClient, error) {
 	}, nil
 }
 
-// Close closes the SFTP client
+// Close stops the client's directory watches and closes the SFTP client
 func (sc *SFTPClient) Close() error {
+	sc.StopWatches()
 	if sc.client != nil {
 		return sc.client.Close()
 	}
 	return nil
 }
 
-// ListDirectory lists files in a directory
+// ListDirectory lists files in a directory, always reading it from the server
 func (sc *SFTPClient) ListDirectory(dirPath string) ([]FileInfo, error) {
-	sc.mu.Lock()
-	defer sc.mu.Unlock()
-
 	// Normalize path
 	if dirPath == "" {
-		dirPath = sc.currentPath
+		dirPath = sc.GetCurrentPath()
 	}
 	dirPath = normalizePath(dirPath)
 
-	files, err := sc.client.ReadDir(dirPath)
+	listing, err := sc.readDirectory(context.Background(), dirPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read directory: %w", err)
-	}
-
-	result := make([]FileInfo, 0, len(files))
-	for _, file := range files {
-		filePath := path.Join(dirPath, file.Name())
-
-		fileInfo := FileInfo{
-			Name:      file.Name(),
-			Path:      filePath,
-			Size:      file.Size(),
-			Mode:      file.Mode().String(),
-			ModTime:   file.ModTime(),
-			IsDir:     file.IsDir(),
-			IsSymlink: file.Mode()&os.ModeSymlink != 0,
-		}
-
-		// Read symlink target if it's a symlink
-		if fileInfo.IsSymlink {
-			target, err := sc.client.ReadLink(filePath)
-			if err == nil {
-				fileInfo.LinkTarget = target
-			}
-		}
-
-		result = append(result, fileInfo)
+		return nil, err
 	}
 
+	// The result is a copy, as link targets are filled in without touching the cache
+	result := append([]FileInfo{}, listing.files...)
+	sc.resolveSymlinks(context.Background(), result)
 	return result, nil
 }
 
@@ -156,12 +145,39 @@ func (sc *SFTPClient) GetFileInfo(filePath string) (*FileInfo, error) {
 	return fileInfo, nil
 }
 
+// OpenFile opens a remote file for reading
+func (sc *SFTPClient) OpenFile(filePath string) (io.ReadCloser, error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	file, err := sc.client.Open(normalizePath(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open remote file: %w", err)
+	}
+	return file, nil
+}
+
+// CreateFile creates or truncates a remote file for writing
+func (sc *SFTPClient) CreateFile(filePath string) (io.WriteCloser, error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	sc.InvalidateListings(filePath)
+	file, err := sc.client.Create(normalizePath(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create remote file: %w", err)
+	}
+	return file, nil
+}
+
 // UploadFile uploads a file from local to remote with progress tracking
-func (sc *SFTPClient) UploadFile(localPath, remotePath string, progressCb func(TransferProgress)) error {
+// Throughput is held below every given rate limiter
+func (sc *SFTPClient) UploadFile(ctx context.Context, localPath, remotePath string, progressCb func(TransferProgress), limiters ...*RateLimiter) error {
 	sc.mu.Lock()
 	defer sc.mu.Unlock()
 
 	remotePath = normalizePath(remotePath)
+	defer sc.InvalidateListings(remotePath)
 
 	// Open local file
 	localFile, err := os.Open(localPath)
@@ -184,12 +200,18 @@ func (sc *SFTPClient) UploadFile(localPath, remotePath string, progressCb func(T
 	}
 	defer remoteFile.Close()
 
+	// Large files are split into ranges transferred concurrently
+	if chunks := sc.transferChunks(totalBytes); chunks > 1 {
+		return sc.streamChunked(ctx, localFile, remoteFile, totalBytes, chunks, progressCb, limiters...)
+	}
+
 	// Stream file with progress tracking
-	return sc.streamWithProgress(localFile, remoteFile, totalBytes, progressCb)
+	return streamWithProgress(ctx, localFile, remoteFile, totalBytes, progressCb, limiters...)
 }
 
 // DownloadFile downloads a file from remote to local with progress tracking
-func (sc *SFTPClient) DownloadFile(remotePath, localPath string, progressCb func(TransferProgress)) error {
+// Throughput is held below every given rate limiter
+func (sc *SFTPClient) DownloadFile(ctx context.Context, remotePath, localPath string, progressCb func(TransferProgress), limiters ...*RateLimiter) error {
 	sc.mu.Lock()
 	defer sc.mu.Unlock()
 
@@ -216,12 +238,18 @@ func (sc *SFTPClient) DownloadFile(remotePath, localPath string, progressCb func
 	}
 	defer localFile.Close()
 
+	// Large files are split into ranges transferred concurrently
+	if chunks := sc.transferChunks(totalBytes); chunks > 1 {
+		return sc.streamChunked(ctx, remoteFile, localFile, totalBytes, chunks, progressCb, limiters...)
+	}
+
 	// Stream file with progress tracking
-	return sc.streamWithProgress(remoteFile, localFile, totalBytes, progressCb)
+	return streamWithProgress(ctx, remoteFile, localFile, totalBytes, progressCb, limiters...)
 }
 
 // streamWithProgress streams data from reader to writer with progress tracking
-func (sc *SFTPClient) streamWithProgress(reader io.Reader, writer io.Writer, totalBytes int64, progressCb func(TransferProgress)) error {
+// Each chunk waits on the rate limiters before it is written
+func streamWithProgress(ctx context.Context, reader io.Reader, writer io.Writer, totalBytes int64, progressCb func(TransferProgress), limiters ...*RateLimiter) error {
 	const bufferSize = 64 * 1024 // 64KB buffer
 	buffer := make([]byte, bufferSize)
 
@@ -237,8 +265,16 @@ func (sc *SFTPClient) streamWithProgress(reader io.Reader, writer io.Writer, tot
 	}
 
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		n, err := reader.Read(buffer)
 		if n > 0 {
+			if waitErr := waitAll(ctx, limiters, n); waitErr != nil {
+				return waitErr
+			}
+
 			_, writeErr := writer.Write(buffer[:n])
 			if writeErr != nil {
 				return fmt.Errorf("write error: %w", writeErr)
@@ -298,6 +334,7 @@ func (sc *SFTPClient) DeleteFile(filePath string) error {
 	defer sc.mu.Unlock()
 
 	filePath = normalizePath(filePath)
+	defer sc.InvalidateListings(filePath)
 
 	err := sc.client.Remove(filePath)
 	if err != nil {
@@ -313,6 +350,7 @@ func (sc *SFTPClient) DeleteDirectory(dirPath string) error {
 	defer sc.mu.Unlock()
 
 	dirPath = normalizePath(dirPath)
+	defer sc.InvalidateListings(dirPath)
 
 	// Recursive delete
 	return sc.deleteRecursive(dirPath)
@@ -358,6 +396,7 @@ func (sc *SFTPClient) RenameFile(oldPath, newPath string) error {
 
 	oldPath = normalizePath(oldPath)
 	newPath = normalizePath(newPath)
+	defer sc.InvalidateListings(oldPath, newPath)
 
 	err := sc.client.Rename(oldPath, newPath)
 	if err != nil {
@@ -373,6 +412,7 @@ func (sc *SFTPClient) CreateDirectory(dirPath string) error {
 	defer sc.mu.Unlock()
 
 	dirPath = normalizePath(dirPath)
+	defer sc.InvalidateListings(dirPath)
 
 	err := sc.client.Mkdir(dirPath)
 	if err != nil {
@@ -543,3 +583,167 @@ func (sc *SFTPClient) SearchDirectories(searchPath, query string, maxDepth, maxR
 
 	return results, nil
 }
+
+// DownloadTree downloads files and directories into localDir over SFTP, one file at a time.
+// It is the fallback for DownloadTar on hosts without tar.
+func (sc *SFTPClient) DownloadTree(ctx context.Context, remotePaths []string, localDir string, progressCb func(TransferProgress), limiters ...*RateLimiter) error {
+	var entries []relayEntry
+	var totalBytes int64
+	for _, remotePath := range remotePaths {
+		remotePath = normalizePath(remotePath)
+		treeEntries, treeBytes, err := sc.collectRelayEntries(remotePath, path.Base(remotePath))
+		if err != nil {
+			return err
+		}
+		entries = append(entries, treeEntries...)
+		totalBytes += treeBytes
+	}
+
+	progress := newBulkProgress(totalBytes, int64(len(entries)), progressCb)
+
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		target, err := safeLocalPath(localDir, entry.dstPath)
+		if err != nil {
+			return err
+		}
+
+		if entry.isDir {
+			if err := os.MkdirAll(target, dirMode(entry.mode)); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+		} else if err := sc.downloadEntry(ctx, entry.srcPath, target, entry.mode, progress, limiters); err != nil {
+			return err
+		}
+
+		progress.addEntry(entry.dstPath)
+	}
+
+	progress.complete()
+	return nil
+}
+
+// downloadEntry copies a single remote file to target
+func (sc *SFTPClient) downloadEntry(ctx context.Context, remotePath, target string, mode os.FileMode, progress *bulkProgress, limiters []*RateLimiter) error {
+	sc.mu.Lock()
+	remoteFile, err := sc.client.Open(remotePath)
+	sc.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to open remote file %s: %w", remotePath, err)
+	}
+	defer remoteFile.Close()
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	localFile, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return fmt.Errorf("failed to create local file: %w", err)
+	}
+	defer localFile.Close()
+
+	if _, err := io.Copy(progress.writer(limitWriter(ctx, localFile, limiters)), &contextReader{ctx: ctx, r: remoteFile}); err != nil {
+		return fmt.Errorf("failed to download %s: %w", remotePath, err)
+	}
+
+	return nil
+}
+
+// UploadTree uploads local files and directories into remoteDir over SFTP, one file at a time.
+// It is the fallback for UploadTar on hosts without tar.
+func (sc *SFTPClient) UploadTree(ctx context.Context, localPaths []string, remoteDir string, progressCb func(TransferProgress), limiters ...*RateLimiter) error {
+	remoteDir = normalizePath(remoteDir)
+	defer sc.InvalidateListings(remoteDir)
+
+	var totalBytes, totalEntries int64
+	for _, localPath := range localPaths {
+		err := filepath.Walk(localPath, func(_ string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			totalEntries++
+			if info.Mode().IsRegular() {
+				totalBytes += info.Size()
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scan local files: %w", err)
+		}
+	}
+
+	progress := newBulkProgress(totalBytes, totalEntries, progressCb)
+
+	for _, localPath := range localPaths {
+		base := filepath.Dir(filepath.Clean(localPath))
+
+		err := filepath.Walk(localPath, func(filePath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			rel, err := filepath.Rel(base, filePath)
+			if err != nil {
+				return err
+			}
+			remotePath := path.Join(remoteDir, filepath.ToSlash(rel))
+
+			switch {
+			case info.IsDir():
+				sc.mu.Lock()
+				err = sc.client.MkdirAll(remotePath)
+				sc.mu.Unlock()
+				if err != nil {
+					return fmt.Errorf("failed to create directory %s: %w", remotePath, err)
+				}
+			case info.Mode().IsRegular():
+				if err := sc.uploadEntry(ctx, filePath, remotePath, info.Mode(), progress, limiters); err != nil {
+					return err
+				}
+			}
+
+			progress.addEntry(filepath.ToSlash(rel))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	progress.complete()
+	return nil
+}
+
+// uploadEntry copies a single local file to remotePath
+func (sc *SFTPClient) uploadEntry(ctx context.Context, localPath, remotePath string, mode os.FileMode, progress *bulkProgress, limiters []*RateLimiter) error {
+	localFile, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open local file: %w", err)
+	}
+	defer localFile.Close()
+
+	sc.mu.Lock()
+	remoteFile, err := sc.client.Create(remotePath)
+	sc.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to create remote file %s: %w", remotePath, err)
+	}
+	defer remoteFile.Close()
+
+	if _, err := io.Copy(progress.writer(limitWriter(ctx, remoteFile, limiters)), &contextReader{ctx: ctx, r: localFile}); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", localPath, err)
+	}
+
+	sc.mu.Lock()
+	sc.client.Chmod(remotePath, mode.Perm())
+	sc.mu.Unlock()
+
+	return nil
+}
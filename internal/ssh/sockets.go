@@ -0,0 +1,312 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SocketInfo describes a TCP or UDP socket
+type SocketInfo struct {
+	Protocol     string `json:"protocol"` // "tcp" or "udp", for IPv4 and IPv6 alike
+	State        string `json:"state"`    // Netstat-style, e.g. LISTEN, ESTABLISHED, TIME_WAIT; UNCONN for unconnected UDP
+	LocalAddress string `json:"local_address"`
+	LocalPort    int    `json:"local_port"`
+	PeerAddress  string `json:"peer_address"`
+	PeerPort     int    `json:"peer_port"` // 0 = any, for listening sockets
+	PID          int    `json:"pid"`       // 0 = unknown; other users' sockets need sudo
+	Process      string `json:"process"`
+}
+
+// SocketListOptions selects the sockets returned by ListSockets
+type SocketListOptions struct {
+	ListeningOnly bool `json:"listening_only"` // Only listening TCP and bound UDP sockets
+}
+
+// SocketList is the host's socket table
+type SocketList struct {
+	Sockets   []SocketInfo `json:"sockets"` // Listening sockets first, then by local port
+	Timestamp int64        `json:"timestamp"`
+	Source    string       `json:"source"` // "ss" or "netstat" on Linux, "sockstat" on FreeBSD, "lsof" on macOS
+}
+
+// socketSourceMarker precedes the name of the tool whose output follows, when it is not ss
+const socketSourceMarker = "#"
+
+// socketScript lists sockets with the system's tool. Linux has ss, or netstat where ss is
+// missing (busybox, old distributions), and %[1]s is the flags for both. Listing all
+// sockets elsewhere is filtered afterwards; lsof exits with 1 when it finds none.
+const socketScript = `export LC_ALL=C
+case "$(uname -s)" in
+Linux)
+	if command -v ss >/dev/null 2>&1; then ss %[1]s && exit 0; fi
+	echo '` + socketSourceMarker + `netstat'
+	netstat %[1]s ;;
+FreeBSD)
+	echo '` + socketSourceMarker + `sockstat'
+	sockstat -46 ;;
+Darwin)
+	echo '` + socketSourceMarker + `lsof'
+	lsof -nP -iTCP -iUDP -FpcPnT
+	[ $? -le 1 ] ;;
+*)
+	echo "listing sockets is not supported on $(uname -s)" >&2
+	exit 1 ;;
+esac`
+
+// ssUsersPattern matches the first process in ss's users:(("name",pid=N,fd=N),...)
+// column; older versions leave out the pid= and fd= names
+var ssUsersPattern = regexp.MustCompile(`\(\("((?:[^"\\]|\\.)*)",(?:pid=)?(\d+)`)
+
+// ListSockets lists the host's TCP and UDP sockets with their owning processes. Only the
+// user's own processes are shown unless sudo is used.
+func (c *Client) ListSockets(ctx context.Context, opts SocketListOptions, sudo SudoOptions) (*SocketList, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	flags := "-tuapn"
+	if opts.ListeningOnly {
+		flags = "-tulpn"
+	}
+	cmd, stdin := sudoCommand("sh -c "+shellQuote(fmt.Sprintf(socketScript, flags)), sudo)
+
+	var stdout, stderr bytes.Buffer
+	if err := c.RunCommandStream(ctx, cmd, stdin, &stdout, &stderr); err != nil {
+		return nil, fmt.Errorf("failed to list sockets: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	list := parseSockets(stdout.String())
+	if opts.ListeningOnly {
+		list.Sockets = slices.DeleteFunc(list.Sockets, func(socket SocketInfo) bool { return !socket.isListening() })
+	}
+	list.Timestamp = time.Now().Unix()
+	return list, nil
+}
+
+// parseSockets parses the output of socketScript
+func parseSockets(output string) *SocketList {
+	list := &SocketList{Sockets: []SocketInfo{}, Source: "ss"}
+
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if source, ok := strings.CutPrefix(line, socketSourceMarker); ok {
+			list.Source = source
+			if source == "lsof" {
+				// lsof's fields span several lines
+				list.Sockets = append(list.Sockets, parseLsofSockets(lines[i+1:])...)
+				break
+			}
+			continue
+		}
+
+		var socket SocketInfo
+		var ok bool
+		switch list.Source {
+		case "ss":
+			socket, ok = parseSSLine(line)
+		case "netstat":
+			socket, ok = parseNetstatLine(line)
+		case "sockstat":
+			socket, ok = parseSockstatLine(line)
+		}
+		if ok {
+			list.Sockets = append(list.Sockets, socket)
+		}
+	}
+
+	sort.SliceStable(list.Sockets, func(i, j int) bool {
+		a, b := &list.Sockets[i], &list.Sockets[j]
+		if a.isListening() != b.isListening() {
+			return a.isListening()
+		}
+		if a.LocalPort != b.LocalPort {
+			return a.LocalPort < b.LocalPort
+		}
+		return a.Protocol < b.Protocol
+	})
+	return list
+}
+
+// parseSSLine parses a line of ss -tuapn:
+// Netid State Recv-Q Send-Q Local-Address:Port Peer-Address:Port [users:((...))]
+func parseSSLine(line string) (SocketInfo, bool) {
+	fields, process := cutFields(line, 6)
+	if len(fields) < 6 || (fields[0] != "tcp" && fields[0] != "udp") {
+		return SocketInfo{}, false
+	}
+
+	socket := SocketInfo{Protocol: fields[0], State: normalizeSocketState(fields[1])}
+	socket.LocalAddress, socket.LocalPort = splitSocketAddress(fields[4])
+	socket.PeerAddress, socket.PeerPort = splitSocketAddress(fields[5])
+	if match := ssUsersPattern.FindStringSubmatch(process); match != nil {
+		socket.Process = match[1]
+		socket.PID, _ = strconv.Atoi(match[2])
+	}
+	return socket, true
+}
+
+// parseNetstatLine parses a line of netstat -tuapn:
+// Proto Recv-Q Send-Q Local-Address Foreign-Address [State] PID/Program
+// UDP sockets usually have no state, leaving the column blank.
+func parseNetstatLine(line string) (SocketInfo, bool) {
+	fields, rest := cutFields(line, 5)
+	if len(fields) < 5 {
+		return SocketInfo{}, false
+	}
+	protocol := strings.TrimSuffix(fields[0], "6")
+	if protocol != "tcp" && protocol != "udp" {
+		return SocketInfo{}, false
+	}
+
+	socket := SocketInfo{Protocol: protocol}
+	socket.LocalAddress, socket.LocalPort = splitSocketAddress(fields[3])
+	socket.PeerAddress, socket.PeerPort = splitSocketAddress(fields[4])
+
+	state, program, _ := strings.Cut(rest, " ")
+	if state == "-" || strings.Contains(state, "/") {
+		state, program = "", rest
+	}
+	program = strings.TrimSpace(program)
+	socket.State = normalizeSocketState(state)
+	if socket.State == "" && protocol == "udp" {
+		socket.State = "UNCONN"
+	}
+
+	if pid, name, ok := strings.Cut(program, "/"); ok {
+		socket.PID, _ = strconv.Atoi(pid)
+		name, _, _ = strings.Cut(name, " ")
+		socket.Process = strings.TrimSuffix(name, ":") // Daemons such as sshd rewrite their title
+	}
+	return socket, true
+}
+
+// parseSockstatLine parses a line of FreeBSD's sockstat -46:
+// USER COMMAND PID FD PROTO LOCAL-ADDRESS FOREIGN-ADDRESS
+// sockstat has no TCP state, so sockets with a peer are taken as established. Sockets
+// without a process show "?".
+func parseSockstatLine(line string) (SocketInfo, bool) {
+	fields, _ := cutFields(line, 7)
+	if len(fields) < 7 {
+		return SocketInfo{}, false
+	}
+	protocol := strings.TrimRight(fields[4], "46")
+	if protocol != "tcp" && protocol != "udp" {
+		return SocketInfo{}, false
+	}
+
+	socket := SocketInfo{Protocol: protocol}
+	socket.LocalAddress, socket.LocalPort = splitSocketAddress(fields[5])
+	socket.PeerAddress, socket.PeerPort = splitSocketAddress(fields[6])
+	switch {
+	case socket.PeerAddress != "*":
+		socket.State = "ESTABLISHED"
+	case protocol == "tcp":
+		socket.State = "LISTEN"
+	default:
+		socket.State = "UNCONN"
+	}
+	if pid, err := strconv.Atoi(fields[2]); err == nil {
+		socket.PID = pid
+		socket.Process = fields[1]
+	}
+	return socket, true
+}
+
+// parseLsofSockets parses the output of lsof -FpcPnT: a p (PID) and c (command) line
+// per process, then for each of its sockets an f (descriptor) line followed by P
+// (protocol), n (local->peer address) and T (TCP information such as ST=LISTEN) lines
+func parseLsofSockets(lines []string) []SocketInfo {
+	var sockets []SocketInfo
+	var pid int
+	var process string
+	var socket *SocketInfo
+
+	flush := func() {
+		if socket != nil && (socket.Protocol == "tcp" || socket.Protocol == "udp") {
+			if socket.State == "" && socket.Protocol == "udp" {
+				socket.State = "UNCONN"
+				if socket.PeerAddress != "" {
+					socket.State = "ESTABLISHED"
+				}
+			}
+			sockets = append(sockets, *socket)
+		}
+		socket = nil
+	}
+
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		value := line[1:]
+		switch line[0] {
+		case 'p':
+			flush()
+			pid, _ = strconv.Atoi(value)
+			process = ""
+		case 'c':
+			process = value
+		case 'f':
+			flush()
+			socket = &SocketInfo{PID: pid, Process: process}
+		case 'P':
+			if socket != nil {
+				socket.Protocol = strings.ToLower(value)
+			}
+		case 'n':
+			if socket != nil {
+				local, peer, _ := strings.Cut(value, "->")
+				socket.LocalAddress, socket.LocalPort = splitSocketAddress(local)
+				socket.PeerAddress, socket.PeerPort = splitSocketAddress(peer)
+			}
+		case 'T':
+			if state, ok := strings.CutPrefix(value, "ST="); ok && socket != nil {
+				socket.State = normalizeSocketState(state)
+			}
+		}
+	}
+	flush()
+	return sockets
+}
+
+// normalizeSocketState converts ss state names, such as ESTAB or FIN-WAIT-1, to the
+// netstat names used by both sources
+func normalizeSocketState(state string) string {
+	switch state {
+	case "ESTAB":
+		return "ESTABLISHED"
+	case "FIN-WAIT-1", "FIN-WAIT-2":
+		return "FIN_WAIT" + state[len(state)-1:]
+	}
+	return strings.ReplaceAll(state, "-", "_")
+}
+
+// splitSocketAddress splits an address such as 0.0.0.0:22, [::1]:53, :::80 or
+// 127.0.0.53%lo:53 at its port, dropping brackets and interface zones. A port of *
+// means any and is returned as 0.
+func splitSocketAddress(address string) (string, int) {
+	colon := strings.LastIndex(address, ":")
+	if colon < 0 {
+		return address, 0
+	}
+
+	host := strings.TrimSuffix(strings.TrimPrefix(address[:colon], "["), "]")
+	if zone := strings.Index(host, "%"); zone >= 0 {
+		host = host[:zone]
+	}
+	port, _ := strconv.Atoi(address[colon+1:])
+	return host, port
+}
+
+// isListening reports whether a socket accepts connections or datagrams
+func (s *SocketInfo) isListening() bool {
+	return s.State == "LISTEN" || s.State == "UNCONN"
+}
@@ -0,0 +1,118 @@
+package ssh
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSockets(t *testing.T) {
+	ss := `Netid State  Recv-Q Send-Q      Local Address:Port  Peer Address:Port Process
+udp   UNCONN 0      0           127.0.0.53%lo:53         0.0.0.0:*     users:(("systemd-resolve",pid=600,fd=13))
+tcp   ESTAB  0      0                10.0.0.5:22        10.0.0.9:51234 users:(("sshd",pid=1234,fd=4),("sshd",pid=1300,fd=4))
+tcp   LISTEN 0      4096              0.0.0.0:22         0.0.0.0:*     users:(("sshd",pid=800,fd=3))
+tcp   LISTEN 0      511                  [::]:80            [::]:*
+tcp   TIME-WAIT 0   0                10.0.0.5:80        10.0.0.7:40000
+`
+	list := parseSockets(ss)
+	want := []SocketInfo{
+		{Protocol: "tcp", State: "LISTEN", LocalAddress: "0.0.0.0", LocalPort: 22, PeerAddress: "0.0.0.0", PID: 800, Process: "sshd"},
+		{Protocol: "udp", State: "UNCONN", LocalAddress: "127.0.0.53", LocalPort: 53, PeerAddress: "0.0.0.0", PID: 600, Process: "systemd-resolve"},
+		{Protocol: "tcp", State: "LISTEN", LocalAddress: "::", LocalPort: 80, PeerAddress: "::"},
+		{Protocol: "tcp", State: "ESTABLISHED", LocalAddress: "10.0.0.5", LocalPort: 22, PeerAddress: "10.0.0.9", PeerPort: 51234, PID: 1234, Process: "sshd"},
+		{Protocol: "tcp", State: "TIME_WAIT", LocalAddress: "10.0.0.5", LocalPort: 80, PeerAddress: "10.0.0.7", PeerPort: 40000},
+	}
+	if list.Source != "ss" || !reflect.DeepEqual(list.Sockets, want) {
+		t.Errorf("ss sockets = %+v, want %+v", list.Sockets, want)
+	}
+
+	netstat := `#netstat
+Active Internet connections (servers and established)
+Proto Recv-Q Send-Q Local Address           Foreign Address         State       PID/Program name
+tcp        0      0 0.0.0.0:22              0.0.0.0:*               LISTEN      800/sshd: /usr/sbin
+tcp        0      0 10.0.0.5:22             10.0.0.9:51234          ESTABLISHED -
+tcp6       0      0 :::8080                 :::*                    LISTEN      900/java
+udp        0      0 0.0.0.0:68              0.0.0.0:*                           500/dhclient
+`
+	list = parseSockets(netstat)
+	want = []SocketInfo{
+		{Protocol: "tcp", State: "LISTEN", LocalAddress: "0.0.0.0", LocalPort: 22, PeerAddress: "0.0.0.0", PID: 800, Process: "sshd"},
+		{Protocol: "udp", State: "UNCONN", LocalAddress: "0.0.0.0", LocalPort: 68, PeerAddress: "0.0.0.0", PID: 500, Process: "dhclient"},
+		{Protocol: "tcp", State: "LISTEN", LocalAddress: "::", LocalPort: 8080, PeerAddress: "::", PID: 900, Process: "java"},
+		{Protocol: "tcp", State: "ESTABLISHED", LocalAddress: "10.0.0.5", LocalPort: 22, PeerAddress: "10.0.0.9", PeerPort: 51234},
+	}
+	if list.Source != "netstat" || !reflect.DeepEqual(list.Sockets, want) {
+		t.Errorf("netstat sockets = %+v, want %+v", list.Sockets, want)
+	}
+}
+
+func TestParseSocketsAddressesAndOldSS(t *testing.T) {
+	// IPv4 clients of dual-stack sockets show as IPv4-mapped IPv6 addresses, and older ss
+	// versions leave the pid= and fd= names out
+	ss := `Netid State  Recv-Q Send-Q         Local Address:Port          Peer Address:Port
+tcp   ESTAB  0      0      [::ffff:10.0.0.5]:8080     [::ffff:10.0.0.9]:40001 users:(("java",900,12))
+tcp   LISTEN 0      128                [::1]:631                   [::]:*     users:(("cupsd",700,7))
+tcp   LISTEN 0      128   [fe80::1%eth0]:5000                     [::]:*     users:(("my \"app\"",701,3))
+`
+	list := parseSockets(ss)
+	want := []SocketInfo{
+		{Protocol: "tcp", State: "LISTEN", LocalAddress: "::1", LocalPort: 631, PeerAddress: "::", PID: 700, Process: "cupsd"},
+		{Protocol: "tcp", State: "LISTEN", LocalAddress: "fe80::1", LocalPort: 5000, PeerAddress: "::", PID: 701, Process: `my \"app\"`},
+		{Protocol: "tcp", State: "ESTABLISHED", LocalAddress: "::ffff:10.0.0.5", LocalPort: 8080, PeerAddress: "::ffff:10.0.0.9", PeerPort: 40001, PID: 900, Process: "java"},
+	}
+	if !reflect.DeepEqual(list.Sockets, want) {
+		t.Errorf("ss sockets = %+v, want %+v", list.Sockets, want)
+	}
+}
+
+func TestParseSocketsBSD(t *testing.T) {
+	sockstat := `#sockstat
+USER     COMMAND    PID   FD  PROTO  LOCAL ADDRESS         FOREIGN ADDRESS
+root     sshd       812   4   tcp6   *:22                  *:*
+root     sshd       812   5   tcp4   *:22                  *:*
+www      nginx      900   6   tcp4   10.0.0.5:80           10.0.0.9:51234
+root     syslogd    600   7   udp4   *:514                 *:*
+?        ?          ?     ?   tcp46  *:8080                *:*
+`
+	list := parseSockets(sockstat)
+	want := []SocketInfo{
+		{Protocol: "tcp", State: "LISTEN", LocalAddress: "*", LocalPort: 22, PeerAddress: "*", PID: 812, Process: "sshd"},
+		{Protocol: "tcp", State: "LISTEN", LocalAddress: "*", LocalPort: 22, PeerAddress: "*", PID: 812, Process: "sshd"},
+		{Protocol: "udp", State: "UNCONN", LocalAddress: "*", LocalPort: 514, PeerAddress: "*", PID: 600, Process: "syslogd"},
+		{Protocol: "tcp", State: "LISTEN", LocalAddress: "*", LocalPort: 8080, PeerAddress: "*"},
+		{Protocol: "tcp", State: "ESTABLISHED", LocalAddress: "10.0.0.5", LocalPort: 80, PeerAddress: "10.0.0.9", PeerPort: 51234, PID: 900, Process: "nginx"},
+	}
+	if list.Source != "sockstat" || !reflect.DeepEqual(list.Sockets, want) {
+		t.Errorf("sockstat sockets = %+v, want %+v", list.Sockets, want)
+	}
+
+	lsof := "#lsof\np1\nclaunchd\nf10\nPTCP\nn*:22\nTST=LISTEN\nTQR=0\nTQS=0\n" +
+		"p300\ncmDNSResponder\nf8\nPUDP\nn*:5353\n" +
+		"p512\ncrapportd\nf4\nPTCP\nn[fe80:4::1]:49152->[fe80:4::2]:443\nTST=ESTABLISHED\n" +
+		"f5\nPTCP\nn127.0.0.1:7000\nTST=LISTEN\n"
+	list = parseSockets(lsof)
+	want = []SocketInfo{
+		{Protocol: "tcp", State: "LISTEN", LocalAddress: "*", LocalPort: 22, PID: 1, Process: "launchd"},
+		{Protocol: "udp", State: "UNCONN", LocalAddress: "*", LocalPort: 5353, PID: 300, Process: "mDNSResponder"},
+		{Protocol: "tcp", State: "LISTEN", LocalAddress: "127.0.0.1", LocalPort: 7000, PID: 512, Process: "rapportd"},
+		{Protocol: "tcp", State: "ESTABLISHED", LocalAddress: "fe80:4::1", LocalPort: 49152, PeerAddress: "fe80:4::2", PeerPort: 443, PID: 512, Process: "rapportd"},
+	}
+	if list.Source != "lsof" || !reflect.DeepEqual(list.Sockets, want) {
+		t.Errorf("lsof sockets = %+v, want %+v", list.Sockets, want)
+	}
+}
+
+func TestForwardHost(t *testing.T) {
+	tests := map[string]string{
+		"0.0.0.0":   "127.0.0.1",
+		"*":         "127.0.0.1",
+		"::":        "::1",
+		"[::]":      "::1",
+		"10.0.0.5":  "10.0.0.5",
+		"localhost": "localhost",
+	}
+	for host, want := range tests {
+		if got := forwardHost(host); got != want {
+			t.Errorf("forwardHost(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tail modes
+const (
+	TailModeAuto = ""     // tail -F over exec when available, SFTP polling otherwise
+	TailModeSFTP = "sftp" // Poll the file size and read new data over SFTP
+	TailModeExec = "exec" // Run tail -F on the remote host
+)
+
+// TailOptions describes a file to follow
+type TailOptions struct {
+	Path         string `json:"path"`
+	Mode         string `json:"mode"`
+	Filter       string `json:"filter"`        // Regular expression, empty passes every line
+	IgnoreCase   bool   `json:"ignore_case"`   // Case-insensitive filter
+	InitialLines int    `json:"initial_lines"` // Existing lines sent first, 0 = default (10), negative = none
+	PollInterval int    `json:"poll_interval"` // Milliseconds between SFTP polls, 0 = default (1000)
+}
+
+// TailEvent is a batch of new lines, or a notice that the file was reset
+type TailEvent struct {
+	TailID    string   `json:"tail_id"`
+	SessionID string   `json:"session_id"`
+	Path      string   `json:"path"`
+	Lines     []string `json:"lines,omitempty"`
+	Reset     string   `json:"reset,omitempty"` // "truncated" or "rotated"
+	Done      bool     `json:"done"`
+	Error     string   `json:"error,omitempty"`
+}
+
+const (
+	defaultTailLines    = 10
+	defaultTailInterval = time.Second
+	minTailInterval     = 100 * time.Millisecond
+	tailFlushInterval   = 200 * time.Millisecond
+	// Data appended faster than this between two polls is skipped to the newest part
+	maxTailRead = 1024 * 1024
+	// Bytes at the start of the file used to notice that it was replaced
+	tailFingerprintSize = 256
+	maxTailLineLength   = 64 * 1024
+)
+
+// TailFile follows opts.Path until ctx is cancelled, delivering new lines through eventCb
+func (sc *SFTPClient) TailFile(ctx context.Context, opts TailOptions, eventCb func(TailEvent)) error {
+	opts.Path = normalizePath(opts.Path)
+	if opts.InitialLines == 0 {
+		opts.InitialLines = defaultTailLines
+	}
+	if opts.InitialLines < 0 {
+		opts.InitialLines = 0
+	}
+
+	filter, err := newTailFilter(opts)
+	if err != nil {
+		return err
+	}
+	batcher := &tailBatcher{path: opts.Path, filter: filter, eventCb: eventCb}
+
+	switch opts.Mode {
+	case TailModeExec:
+		if sc.sshClient == nil {
+			return fmt.Errorf("exec tail requires an SSH connection")
+		}
+		return sc.tailExec(ctx, opts, batcher)
+	case TailModeSFTP:
+		return sc.tailSFTP(ctx, opts, batcher)
+	case TailModeAuto:
+		if sc.sshClient != nil && sc.sshClient.requireRemoteTool(ctx, "tail") == nil {
+			return sc.tailExec(ctx, opts, batcher)
+		}
+		return sc.tailSFTP(ctx, opts, batcher)
+	default:
+		return fmt.Errorf("unsupported tail mode: %s", opts.Mode)
+	}
+}
+
+// tailExec runs "tail -F", which follows the file name across rotation on its own
+func (sc *SFTPClient) tailExec(ctx context.Context, opts TailOptions, batcher *tailBatcher) error {
+	if _, err := sc.GetFileInfo(opts.Path); err != nil {
+		return err
+	}
+
+	stdout := &tailLineWriter{fn: batcher.addLine}
+	// GNU and BusyBox tail report truncation and replacement on stderr
+	stderr := &tailLineWriter{fn: func(line string) {
+		switch {
+		case strings.Contains(line, "truncated"):
+			batcher.reset("truncated")
+		case strings.Contains(line, "replaced") || strings.Contains(line, "has appeared"):
+			batcher.reset("rotated")
+		}
+	}}
+
+	cmd := fmt.Sprintf("tail -n %d -F %s", opts.InitialLines, shellQuote(opts.Path))
+	done := make(chan error, 1)
+	go func() {
+		done <- sc.sshClient.RunCommandStream(ctx, cmd, nil, stdout, stderr)
+	}()
+
+	ticker := time.NewTicker(tailFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-done:
+			batcher.flush()
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil {
+				return fmt.Errorf("tail failed: %w", err)
+			}
+			return nil
+		case <-ticker.C:
+			batcher.flush()
+		}
+	}
+}
+
+// tailSFTP polls the file over SFTP and reads whatever was appended since the last poll
+func (sc *SFTPClient) tailSFTP(ctx context.Context, opts TailOptions, batcher *tailBatcher) error {
+	interval := time.Duration(opts.PollInterval) * time.Millisecond
+	if interval <= 0 {
+		interval = defaultTailInterval
+	}
+	if interval < minTailInterval {
+		interval = minTailInterval
+	}
+
+	state := &tailState{path: opts.Path, initialLines: opts.InitialLines, first: true}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := state.poll(sc, batcher); err != nil {
+			return err
+		}
+		batcher.flush()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// tailState tracks the read position of a polled file
+type tailState struct {
+	path         string
+	initialLines int
+	first        bool
+
+	offset      int64
+	fingerprint []byte
+	pending     []byte
+	dropPartial bool // Discard data up to the next newline after skipping ahead
+}
+
+func (ts *tailState) poll(sc *SFTPClient, batcher *tailBatcher) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	info, err := sc.client.Stat(ts.path)
+	if err != nil {
+		// A rotated file may be briefly missing until it is recreated
+		if os.IsNotExist(err) && !ts.first {
+			return nil
+		}
+		return fmt.Errorf("failed to stat %s: %w", ts.path, err)
+	}
+
+	file, err := sc.client.Open(ts.path)
+	if err != nil {
+		if os.IsNotExist(err) && !ts.first {
+			return nil
+		}
+		return fmt.Errorf("failed to open %s: %w", ts.path, err)
+	}
+	defer file.Close()
+
+	size := info.Size()
+	keepLines := -1
+
+	if ts.first {
+		ts.first = false
+		keepLines = ts.initialLines
+		// Start close enough to the end to show the last few lines
+		ts.offset = max(size-int64(keepLines)*1024, 0)
+		if ts.offset > 0 {
+			// Only a line cut in half by the starting point is dropped
+			prev := make([]byte, 1)
+			n, _ := file.ReadAt(prev, ts.offset-1)
+			ts.dropPartial = n == 1 && prev[0] != '\n'
+		}
+	} else {
+		reset := ""
+		if size < ts.offset {
+			reset = "truncated"
+		} else if len(ts.fingerprint) > 0 {
+			head := make([]byte, len(ts.fingerprint))
+			n, _ := file.ReadAt(head, 0)
+			if !bytes.Equal(head[:n], ts.fingerprint) {
+				reset = "rotated"
+			}
+		}
+		if reset != "" {
+			batcher.reset(reset)
+			ts.offset = 0
+			ts.fingerprint = nil
+			ts.pending = nil
+			ts.dropPartial = false
+		}
+	}
+
+	if len(ts.fingerprint) < tailFingerprintSize && size > int64(len(ts.fingerprint)) {
+		head := make([]byte, min(size, tailFingerprintSize))
+		n, _ := file.ReadAt(head, 0)
+		ts.fingerprint = head[:n]
+	}
+
+	if size == ts.offset {
+		return nil
+	}
+	if size-ts.offset > maxTailRead {
+		ts.offset = size - maxTailRead
+		ts.pending = nil
+		ts.dropPartial = true
+	}
+
+	if _, err := file.Seek(ts.offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek %s: %w", ts.path, err)
+	}
+	data, err := io.ReadAll(io.LimitReader(file, size-ts.offset))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", ts.path, err)
+	}
+	ts.offset += int64(len(data))
+
+	if ts.dropPartial {
+		idx := bytes.IndexByte(data, '\n')
+		if idx == -1 {
+			return nil
+		}
+		data = data[idx+1:]
+		ts.dropPartial = false
+	}
+
+	ts.pending = append(ts.pending, data...)
+	lines := bytes.Split(ts.pending, []byte("\n"))
+	ts.pending = append([]byte(nil), lines[len(lines)-1]...)
+	lines = lines[:len(lines)-1]
+	if len(ts.pending) > maxTailLineLength {
+		lines = append(lines, ts.pending)
+		ts.pending = nil
+	}
+
+	// Only the last initialLines of the existing content are sent
+	if keepLines >= 0 && len(lines) > keepLines {
+		lines = lines[len(lines)-keepLines:]
+	}
+
+	for _, line := range lines {
+		batcher.addLine(string(bytes.TrimSuffix(line, []byte("\r"))))
+	}
+	return nil
+}
+
+// tailBatcher filters lines and delivers them in batches
+type tailBatcher struct {
+	path    string
+	filter  func(string) bool
+	eventCb func(TailEvent)
+
+	mu    sync.Mutex
+	lines []string
+}
+
+func (tb *tailBatcher) addLine(line string) {
+	if tb.filter != nil && !tb.filter(line) {
+		return
+	}
+
+	tb.mu.Lock()
+	tb.lines = append(tb.lines, line)
+	tb.mu.Unlock()
+}
+
+// reset sends pending lines followed by a reset notice
+func (tb *tailBatcher) reset(reason string) {
+	tb.flush()
+	if tb.eventCb != nil {
+		tb.eventCb(TailEvent{Path: tb.path, Reset: reason})
+	}
+}
+
+func (tb *tailBatcher) flush() {
+	tb.mu.Lock()
+	lines := tb.lines
+	tb.lines = nil
+	tb.mu.Unlock()
+
+	if len(lines) > 0 && tb.eventCb != nil {
+		tb.eventCb(TailEvent{Path: tb.path, Lines: lines})
+	}
+}
+
+// tailLineWriter splits written data into lines on \n, dropping a trailing \r
+type tailLineWriter struct {
+	fn  func(line string)
+	buf []byte
+}
+
+func (tw *tailLineWriter) Write(p []byte) (int, error) {
+	tw.buf = append(tw.buf, p...)
+	for {
+		idx := bytes.IndexByte(tw.buf, '\n')
+		if idx == -1 {
+			break
+		}
+		tw.fn(string(bytes.TrimSuffix(tw.buf[:idx], []byte("\r"))))
+		tw.buf = tw.buf[idx+1:]
+	}
+	if len(tw.buf) > maxTailLineLength {
+		tw.fn(string(tw.buf))
+		tw.buf = nil
+	}
+	return len(p), nil
+}
+
+// newTailFilter compiles the line filter; nil passes every line
+func newTailFilter(opts TailOptions) (func(string) bool, error) {
+	if opts.Filter == "" {
+		return nil, nil
+	}
+
+	pattern := opts.Filter
+	if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter pattern: %w", err)
+	}
+	return re.MatchString, nil
+}
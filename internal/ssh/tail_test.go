@@ -0,0 +1,57 @@
+package ssh
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTailPoll(t *testing.T) {
+	sc := newTestSFTPClient(t, 0)
+	filePath := filepath.Join(t.TempDir(), "app.log")
+
+	tests := []struct {
+		name         string
+		initialLines int
+		existing     string
+		want         []string // Lines delivered after "new\n" is appended
+	}{
+		{"none", 0, "one\ntwo\n", []string{"new"}},
+		{"none mid-line", 0, "one\ntw", nil},
+		{"last lines", 1, "one\ntwo\n", []string{"two", "new"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(filePath, []byte(tt.existing), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			batcher := &tailBatcher{path: filePath, eventCb: func(event TailEvent) {
+				got = append(got, event.Lines...)
+			}}
+			state := &tailState{path: filePath, initialLines: tt.initialLines, first: true}
+			if err := state.poll(sc, batcher); err != nil {
+				t.Fatal(err)
+			}
+
+			file, _ := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY, 0644)
+			file.WriteString("new\n")
+			file.Close()
+
+			if err := state.poll(sc, batcher); err != nil {
+				t.Fatal(err)
+			}
+			batcher.flush()
+
+			// The rest of a line cut by the starting point is not a line of its own
+			if tt.want == nil && len(got) != 0 {
+				t.Errorf("lines = %q, want none", got)
+			} else if tt.want != nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lines = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,398 @@
+package ssh
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DownloadTar copies remotePaths into localDir by running "tar -cf -" on the remote host
+// and unpacking the stream locally. This avoids one SFTP round trip per file, which
+// dominates when a tree holds thousands of small files.
+// All paths must share the same parent directory.
+func (c *Client) DownloadTar(ctx context.Context, remotePaths []string, localDir string, progressCb func(TransferProgress), limiters ...*RateLimiter) error {
+	if len(remotePaths) == 0 {
+		return fmt.Errorf("no paths to download")
+	}
+
+	parent, names, err := splitCommonParent(remotePaths)
+	if err != nil {
+		return err
+	}
+
+	if err := c.requireRemoteTool(ctx, "tar"); err != nil {
+		return err
+	}
+
+	// Sizes are only estimates used for the progress bar; du -sb is GNU-specific
+	quotedNames := shellQuoteAll(names)
+	totalBytes := c.countRemoteLines(ctx, fmt.Sprintf("cd %s && du -sb %s 2>/dev/null | awk '{s+=$1} END {print s}'", shellQuote(parent), quotedNames))
+	totalEntries := c.countRemoteLines(ctx, fmt.Sprintf("cd %s && find %s 2>/dev/null | wc -l", shellQuote(parent), quotedNames))
+
+	progress := newBulkProgress(totalBytes, totalEntries, progressCb)
+
+	pr, pw := io.Pipe()
+	var stderr bytes.Buffer
+	cmdErr := make(chan error, 1)
+	go func() {
+		err := c.RunCommandStream(ctx, fmt.Sprintf("cd %s && tar -cf - %s", shellQuote(parent), quotedNames), nil, pw, &stderr)
+		pw.CloseWithError(err)
+		cmdErr <- err
+	}()
+
+	extractErr := extractTar(ctx, limitReader(ctx, pr, limiters), localDir, progress)
+	pr.CloseWithError(extractErr)
+	err = <-cmdErr
+
+	if extractErr != nil {
+		return extractErr
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("tar failed: %s", msg)
+		}
+		return fmt.Errorf("tar failed: %w", err)
+	}
+
+	progress.complete()
+	return nil
+}
+
+// UploadTar copies localPaths into remoteDir by packing them locally and piping the
+// stream into "tar -xf -" on the remote host
+func (c *Client) UploadTar(ctx context.Context, localPaths []string, remoteDir string, progressCb func(TransferProgress), limiters ...*RateLimiter) error {
+	if len(localPaths) == 0 {
+		return fmt.Errorf("no paths to upload")
+	}
+	remoteDir = normalizePath(remoteDir)
+
+	if err := c.requireRemoteTool(ctx, "tar"); err != nil {
+		return err
+	}
+
+	var totalBytes, totalEntries int64
+	for _, localPath := range localPaths {
+		err := filepath.Walk(localPath, func(_ string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			totalEntries++
+			if info.Mode().IsRegular() {
+				totalBytes += info.Size()
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scan local files: %w", err)
+		}
+	}
+
+	progress := newBulkProgress(totalBytes, totalEntries, progressCb)
+
+	pr, pw := io.Pipe()
+	packErr := make(chan error, 1)
+	go func() {
+		err := packTar(ctx, pw, localPaths, progress)
+		pw.CloseWithError(err)
+		packErr <- err
+	}()
+
+	var stderr bytes.Buffer
+	cmd := fmt.Sprintf("mkdir -p %s && tar -xf - -C %s", shellQuote(remoteDir), shellQuote(remoteDir))
+	err := c.RunCommandStream(ctx, cmd, limitReader(ctx, pr, limiters), nil, &stderr)
+	pr.CloseWithError(err)
+
+	// When the remote side fails first, the packer only sees the closed pipe
+	if perr := <-packErr; perr != nil && !errors.Is(perr, io.ErrClosedPipe) && (err == nil || !errors.Is(perr, err)) {
+		return perr
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("tar failed: %s", msg)
+		}
+		return fmt.Errorf("tar failed: %w", err)
+	}
+
+	progress.complete()
+	return nil
+}
+
+// extractTar unpacks a tar stream into localDir, refusing entries that would escape it
+func extractTar(ctx context.Context, r io.Reader, localDir string, progress *bulkProgress) error {
+	tr := tar.NewReader(&contextReader{ctx: ctx, r: r})
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar stream: %w", err)
+		}
+
+		target, err := safeLocalPath(localDir, header.Name)
+		if err != nil {
+			return err
+		}
+		// Links created by earlier entries must not redirect this one
+		if err := checkNoSymlinkParents(localDir, target); err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, dirMode(header.FileInfo().Mode())); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+			// Replace rather than write through an existing link
+			if info, err := os.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				os.Remove(target)
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, header.FileInfo().Mode().Perm())
+			if err != nil {
+				return fmt.Errorf("failed to create local file: %w", err)
+			}
+			_, err = io.Copy(progress.writer(file), tr)
+			file.Close()
+			if err != nil {
+				return fmt.Errorf("failed to write %s: %w", header.Name, err)
+			}
+			os.Chtimes(target, header.ModTime, header.ModTime)
+		case tar.TypeSymlink:
+			// Links pointing outside localDir could redirect later entries, so they are skipped.
+			// Best effort: symlinks may not be creatable on every platform.
+			if _, err := safeLocalPath(localDir, path.Join(path.Dir(header.Name), header.Linkname)); err == nil && !path.IsAbs(header.Linkname) {
+				os.Remove(target)
+				os.Symlink(header.Linkname, target)
+			}
+		default:
+			// Devices, FIFOs and hard links are skipped
+		}
+
+		progress.addEntry(header.Name)
+	}
+}
+
+// packTar writes localPaths, with their directory trees, as a tar stream to w.
+// Entries are named relative to each path's parent directory.
+func packTar(ctx context.Context, w io.Writer, localPaths []string, progress *bulkProgress) error {
+	tw := tar.NewWriter(w)
+
+	for _, localPath := range localPaths {
+		base := filepath.Dir(filepath.Clean(localPath))
+
+		err := filepath.Walk(localPath, func(filePath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			rel, err := filepath.Rel(base, filePath)
+			if err != nil {
+				return err
+			}
+
+			link := ""
+			if info.Mode()&os.ModeSymlink != 0 {
+				if link, err = os.Readlink(filePath); err != nil {
+					return err
+				}
+			}
+
+			header, err := tar.FileInfoHeader(info, link)
+			if err != nil {
+				return err
+			}
+			header.Name = filepath.ToSlash(rel)
+			if info.IsDir() {
+				header.Name += "/"
+			}
+
+			if err := tw.WriteHeader(header); err != nil {
+				return err
+			}
+
+			if info.Mode().IsRegular() {
+				file, err := os.Open(filePath)
+				if err != nil {
+					return err
+				}
+				_, err = io.Copy(progress.writer(tw), file)
+				file.Close()
+				if err != nil {
+					return err
+				}
+			}
+
+			progress.addEntry(header.Name)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to pack %s: %w", localPath, err)
+		}
+	}
+
+	return tw.Close()
+}
+
+// safeLocalPath joins a tar entry name onto localDir, rejecting absolute names and ".." escapes
+func safeLocalPath(localDir, name string) (string, error) {
+	name = filepath.ToSlash(name)
+	cleaned := path.Clean(name)
+	if path.IsAbs(name) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("refusing to extract entry outside target directory: %s", name)
+	}
+	if cleaned == "." {
+		return localDir, nil
+	}
+
+	return filepath.Join(localDir, filepath.FromSlash(cleaned)), nil
+}
+
+// checkNoSymlinkParents rejects a target below localDir when one of the directories
+// between them is a symlink, which a malicious archive could chain to escape localDir
+func checkNoSymlinkParents(localDir, target string) error {
+	rel, err := filepath.Rel(localDir, target)
+	if err != nil || rel == "." {
+		return nil
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+
+	current := localDir
+	for _, part := range parts[:len(parts)-1] {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if err != nil {
+			return nil // Missing directories are created as real ones
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("refusing to extract entry through a symlink: %s", target)
+		}
+	}
+	return nil
+}
+
+// dirMode makes sure extracted directories stay writable by the owner
+func dirMode(mode os.FileMode) os.FileMode {
+	return mode.Perm() | 0700
+}
+
+// bulkProgress aggregates byte and entry counts for multi-file transfers and
+// reports them about every 1MB or 200ms
+type bulkProgress struct {
+	totalBytes   int64
+	totalEntries int64
+	bytes        int64
+	entries      int64
+	progressCb   func(TransferProgress)
+
+	lastBytes int64
+	lastTime  time.Time
+}
+
+func newBulkProgress(totalBytes, totalEntries int64, progressCb func(TransferProgress)) *bulkProgress {
+	return &bulkProgress{
+		totalBytes:   totalBytes,
+		totalEntries: totalEntries,
+		progressCb:   progressCb,
+		lastTime:     time.Now(),
+	}
+}
+
+// writer wraps w so that written bytes are counted
+func (bp *bulkProgress) writer(w io.Writer) io.Writer {
+	return &countingWriter{w: w, bp: bp}
+}
+
+func (bp *bulkProgress) addBytes(n int64) {
+	bp.bytes += n
+	bp.report("", false)
+}
+
+func (bp *bulkProgress) addEntry(name string) {
+	bp.entries++
+	bp.report(name, false)
+}
+
+func (bp *bulkProgress) report(name string, force bool) {
+	if bp.progressCb == nil {
+		return
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(bp.lastTime)
+	if !force && bp.bytes-bp.lastBytes < 1024*1024 && elapsed < 200*time.Millisecond {
+		return
+	}
+
+	var speed int64
+	if elapsed > 0 {
+		speed = int64(float64(bp.bytes-bp.lastBytes) / elapsed.Seconds())
+	}
+
+	percentage := entryPercentage(bp.entries, bp.totalEntries)
+	if bp.totalBytes > 0 {
+		percentage = entryPercentage(bp.bytes, bp.totalBytes)
+	}
+
+	bp.progressCb(TransferProgress{
+		Filename:     name,
+		BytesSent:    bp.bytes,
+		TotalBytes:   bp.totalBytes,
+		Percentage:   percentage,
+		Speed:        speed,
+		EntriesDone:  bp.entries,
+		EntriesTotal: bp.totalEntries,
+		Status:       "running",
+	})
+
+	bp.lastBytes = bp.bytes
+	bp.lastTime = now
+}
+
+func (bp *bulkProgress) complete() {
+	if bp.progressCb == nil {
+		return
+	}
+
+	bp.progressCb(TransferProgress{
+		BytesSent:    bp.bytes,
+		TotalBytes:   max(bp.totalBytes, bp.bytes),
+		Percentage:   100.0,
+		EntriesDone:  bp.entries,
+		EntriesTotal: max(bp.totalEntries, bp.entries),
+		Status:       "completed",
+	})
+}
+
+// countingWriter forwards writes and adds the written byte count to a bulkProgress
+type countingWriter struct {
+	w  io.Writer
+	bp *bulkProgress
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.bp.addBytes(int64(n))
+	return n, err
+}
@@ -0,0 +1,52 @@
+package ssh
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtractTarRejectsChainedSymlinks(t *testing.T) {
+	// a -> . and a/b -> .. pass a lexical check each, but together a/b points above
+	// the target directory, so a/b/x would land next to it
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "a", Typeflag: tar.TypeSymlink, Linkname: "."})
+	tw.WriteHeader(&tar.Header{Name: "a/b", Typeflag: tar.TypeSymlink, Linkname: ".."})
+	tw.WriteHeader(&tar.Header{Name: "a/b/x", Typeflag: tar.TypeReg, Mode: 0644, Size: 4})
+	tw.Write([]byte("evil"))
+	tw.Close()
+
+	parent := t.TempDir()
+	localDir := filepath.Join(parent, "target")
+	os.Mkdir(localDir, 0755)
+
+	err := extractTar(context.Background(), &buf, localDir, newBulkProgress(0, 0, nil))
+	if err == nil {
+		t.Error("extractTar accepted an entry below a symlink")
+	}
+	if _, err := os.Stat(filepath.Join(parent, "x")); err == nil {
+		t.Error("file was written outside the target directory")
+	}
+}
+
+func TestExtractTar(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755})
+	tw.WriteHeader(&tar.Header{Name: "dir/file", Typeflag: tar.TypeReg, Mode: 0644, Size: 2})
+	tw.Write([]byte("ok"))
+	tw.WriteHeader(&tar.Header{Name: "dir/link", Typeflag: tar.TypeSymlink, Linkname: "file"})
+	tw.Close()
+
+	localDir := t.TempDir()
+	if err := extractTar(context.Background(), &buf, localDir, newBulkProgress(0, 0, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(localDir, "dir", "link")); err != nil || string(data) != "ok" {
+		t.Errorf("link content = %q, %v", data, err)
+	}
+}
@@ -0,0 +1,326 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const terminalHoldTimeout = 200 * time.Millisecond
+
+var errTerminalTimeout = errors.New("terminal transfer peer timed out")
+
+// terminalStream carries shell output to an in-band file transfer protocol
+// (ZMODEM, trzsz) running in its own goroutine; replies go to the remote stdin
+type terminalStream struct {
+	sessionID string
+	transfers *TransferManager
+	progress  func(TransferProgress)
+	out       io.Writer
+	timeout   time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	in     chan []byte
+	done   chan struct{}
+	err    error
+
+	pending []byte
+}
+
+func newTerminalStream(sessionID string, transfers *TransferManager, progress func(TransferProgress), out io.Writer, timeout time.Duration) *terminalStream {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &terminalStream{
+		sessionID: sessionID,
+		transfers: transfers,
+		progress:  progress,
+		out:       out,
+		timeout:   timeout,
+		ctx:       ctx,
+		cancel:    cancel,
+		in:        make(chan []byte, 64),
+		done:      make(chan struct{}),
+	}
+}
+
+// start runs the protocol; the stream is finished when run returns
+func (ts *terminalStream) start(run func() error) {
+	go func() {
+		defer close(ts.done)
+		defer ts.cancel()
+		ts.err = run()
+	}()
+}
+
+// feed passes terminal output to the protocol; it returns false once the exchange has ended
+func (ts *terminalStream) feed(data []byte) bool {
+	select {
+	case ts.in <- append([]byte(nil), data...):
+		return true
+	case <-ts.done:
+		return false
+	}
+}
+
+// finished reports whether the exchange is over
+func (ts *terminalStream) finished() bool {
+	select {
+	case <-ts.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// leftover returns output received after the exchange ended, which belongs to the terminal.
+// Only valid once finished reports true.
+func (ts *terminalStream) leftover() []byte {
+	data := ts.pending
+	ts.pending = nil
+	for {
+		select {
+		case chunk := <-ts.in:
+			data = append(data, chunk...)
+		default:
+			return data
+		}
+	}
+}
+
+// abort cancels the exchange, e.g. when the user presses Ctrl+C
+func (ts *terminalStream) abort() {
+	ts.cancel()
+}
+
+func (ts *terminalStream) readByte() (byte, error) {
+	for len(ts.pending) == 0 {
+		timer := time.NewTimer(ts.timeout)
+		select {
+		case data := <-ts.in:
+			ts.pending = data
+		case <-ts.ctx.Done():
+			timer.Stop()
+			return 0, ts.ctx.Err()
+		case <-timer.C:
+			return 0, errTerminalTimeout
+		}
+		timer.Stop()
+	}
+
+	b := ts.pending[0]
+	ts.pending = ts.pending[1:]
+	return b, nil
+}
+
+func (ts *terminalStream) unreadByte(b byte) {
+	ts.pending = append([]byte{b}, ts.pending...)
+}
+
+// hasInput reports whether the peer sent something that has not been read yet
+func (ts *terminalStream) hasInput() bool {
+	return len(ts.pending) > 0 || len(ts.in) > 0
+}
+
+// startTransfer registers a file with the TransferManager. Cancelling it there aborts the exchange.
+func (ts *terminalStream) startTransfer(transferType, localPath, name string, size int64) (*TransferContext, *bulkProgress, func() bool) {
+	var transfer *TransferContext
+	if ts.transfers != nil {
+		transfer, _ = ts.transfers.StartTransfer(ts.sessionID, transferType, []string{localPath})
+	}
+
+	progressCb := func(progress TransferProgress) {
+		progress.SessionID = ts.sessionID
+		progress.Filename = name
+		if transfer != nil {
+			progress.TransferID = transfer.ID
+			ts.transfers.UpdateProgress(transfer.ID, progress)
+		}
+		if ts.progress != nil {
+			ts.progress(progress)
+		}
+	}
+
+	stop := func() bool { return false }
+	if transfer != nil {
+		stop = context.AfterFunc(transfer.Context(), ts.cancel)
+	}
+
+	return transfer, newBulkProgress(size, 1, progressCb), stop
+}
+
+// failTransfer reports a failed file unless it was cancelled by the user
+func (ts *terminalStream) failTransfer(transfer *TransferContext, err error) {
+	if transfer == nil || transfer.IsCancelled() {
+		return
+	}
+
+	progress := transfer.GetProgress()
+	progress.Status = "failed"
+	progress.Error = err.Error()
+	ts.transfers.UpdateProgress(transfer.ID, progress)
+	if ts.progress != nil {
+		ts.progress(progress)
+	}
+}
+
+// terminalOutput sits between a shell's output and onOutput and diverts in-band
+// file transfers to their protocol handlers
+type terminalOutput struct {
+	sm       *SessionManager
+	managed  *ManagedSession
+	onOutput func([]byte)
+	stdin    io.Writer
+
+	mu          sync.Mutex
+	held        []byte // Output ending inside a possible transfer trigger
+	holdTimer   *time.Timer
+	lastTrzszID string
+}
+
+func newTerminalOutput(sm *SessionManager, managed *ManagedSession, stdin io.Writer, onOutput func([]byte)) *terminalOutput {
+	return &terminalOutput{sm: sm, managed: managed, onOutput: onOutput, stdin: stdin}
+}
+
+// write handles one chunk of shell output
+func (to *terminalOutput) write(data []byte) {
+	to.mu.Lock()
+	defer to.mu.Unlock()
+
+	if active := to.managed.transfer.Load(); active != nil {
+		if active.feed(data) {
+			return
+		}
+		// The exchange just ended; this output belongs to the terminal again
+		to.finishLocked(active)
+	}
+
+	if len(to.held) > 0 {
+		data = append(to.held, data...)
+		to.held = nil
+		to.holdTimer.Stop()
+	}
+
+	trigger, holdFrom := to.detect(data)
+	if trigger == nil {
+		if holdFrom >= 0 {
+			to.hold(data[holdFrom:])
+			data = data[:holdFrom]
+		}
+		to.emit(data)
+		return
+	}
+
+	to.emit(data[:trigger.offset])
+	stream := trigger.start()
+	to.managed.transfer.Store(stream)
+	stream.feed(trigger.frame)
+
+	go func() {
+		<-stream.done
+		to.mu.Lock()
+		defer to.mu.Unlock()
+		to.finishLocked(stream)
+	}()
+}
+
+// terminalTrigger is the start of an in-band transfer found in shell output
+type terminalTrigger struct {
+	offset int    // Output before this belongs to the terminal
+	frame  []byte // Output handed to the protocol
+	start  func() *terminalStream
+}
+
+// detect finds the first transfer trigger in data. Without one, holdFrom is the
+// offset of a possible trigger cut off at the end of data, or -1.
+func (to *terminalOutput) detect(data []byte) (*terminalTrigger, int) {
+	to.sm.mu.RLock()
+	zmodemHandler, trzszHandler, transfers := to.sm.zmodemHandler, to.sm.trzszHandler, to.sm.transferManager
+	to.sm.mu.RUnlock()
+
+	var trigger *terminalTrigger
+	holdFrom := -1
+
+	if zmodemHandler != nil {
+		offset, sending, found, partial := detectZmodem(data)
+		if found {
+			frame := data[offset:]
+			if frame[0] != zpad {
+				// A leading ZPAD was split off into earlier output
+				frame = append([]byte{zpad}, frame...)
+			}
+			trigger = &terminalTrigger{offset: offset, frame: frame, start: func() *terminalStream {
+				return newZmodemSession(to.managed.ID, sending, zmodemHandler, transfers, to.stdin).terminalStream
+			}}
+		} else if partial {
+			holdFrom = offset
+		}
+	}
+
+	if trzszHandler != nil {
+		start, end, mode, id, found, partial := detectTrzsz(data)
+		// A trigger is only acted on once, so redrawn scrollback does not restart it
+		if found && id != to.lastTrzszID && (trigger == nil || start < trigger.offset) {
+			to.lastTrzszID = id
+			trigger = &terminalTrigger{offset: start, frame: data[end:], start: func() *terminalStream {
+				return newTrzszSession(to.managed.ID, mode, trzszHandler, transfers, to.stdin).terminalStream
+			}}
+		} else if partial && (holdFrom == -1 || start < holdFrom) {
+			holdFrom = start
+		}
+	}
+
+	if trigger != nil && holdFrom >= 0 && holdFrom < trigger.offset {
+		holdFrom = -1
+	}
+	return trigger, holdFrom
+}
+
+// hold keeps back output that may be the start of a trigger. It is released after
+// a short time if no more output arrives, so a coincidental match is not stuck.
+func (to *terminalOutput) hold(data []byte) {
+	to.held = append([]byte(nil), data...)
+	held := to.held
+	to.holdTimer = time.AfterFunc(terminalHoldTimeout, func() {
+		to.mu.Lock()
+		defer to.mu.Unlock()
+		if len(to.held) > 0 && &to.held[0] == &held[0] {
+			to.emit(to.held)
+			to.held = nil
+		}
+	})
+}
+
+// finishLocked detaches an ended exchange and passes on the output it did not consume
+func (to *terminalOutput) finishLocked(stream *terminalStream) {
+	if !to.managed.transfer.CompareAndSwap(stream, nil) {
+		return
+	}
+	if stream.err != nil && !errors.Is(stream.err, context.Canceled) {
+		fmt.Printf("Terminal file transfer on %s failed: %v\n", to.managed.ID, stream.err)
+	}
+	to.emit(stream.leftover())
+}
+
+func (to *terminalOutput) emit(data []byte) {
+	if len(data) > 0 && to.onOutput != nil {
+		to.onOutput(data)
+	}
+}
+
+// interceptInput keeps user input from corrupting a running in-band transfer; Ctrl+C aborts it.
+// Returns true when the input was consumed.
+func interceptInput(managed *ManagedSession, data []byte) bool {
+	active := managed.transfer.Load()
+	if active == nil || active.finished() {
+		return false
+	}
+
+	if bytes.IndexByte(data, 0x03) >= 0 {
+		active.abort()
+	}
+	return true
+}
@@ -9,31 +9,60 @@ import (
 
 // TransferContext represents a file transfer operation
 type TransferContext struct {
-	ID         string
-	SessionID  string
-	Type       string // "upload" or "download"
-	Files      []string
-	ctx        context.Context
-	cancel     context.CancelFunc
-	progress   TransferProgress
-	mu         sync.Mutex
-	startTime  time.Time
-	lastUpdate time.Time
+	ID              string
+	SessionID       string
+	TargetSessionID string // Destination session for "relay" transfers
+	Type            string // "upload", "download" or "relay"
+	Files           []string
+	ctx             context.Context
+	cancel          context.CancelFunc
+	progress        TransferProgress
+	limiter         *RateLimiter // Per-transfer limit
+	global          *RateLimiter // The manager's limit shared by all transfers
+	mu              sync.Mutex
+	startTime       time.Time
+	lastUpdate      time.Time
 }
 
 // TransferManager manages multiple file transfer operations
 type TransferManager struct {
 	mu        sync.RWMutex
 	transfers map[string]*TransferContext
+	limiter   *RateLimiter // Global limit shared by all transfers
 }
 
 // NewTransferManager creates a new transfer manager
 func NewTransferManager() *TransferManager {
 	return &TransferManager{
 		transfers: make(map[string]*TransferContext),
+		limiter:   NewRateLimiter(0),
 	}
 }
 
+// SetGlobalRateLimit limits the combined throughput of all transfers; 0 removes the limit
+func (tm *TransferManager) SetGlobalRateLimit(bytesPerSecond int64) {
+	tm.limiter.SetRate(bytesPerSecond)
+}
+
+// GlobalRateLimit returns the combined limit in bytes per second, 0 = unlimited
+func (tm *TransferManager) GlobalRateLimit() int64 {
+	return tm.limiter.Rate()
+}
+
+// SetTransferRateLimit limits a single transfer, also while it is running; 0 removes the limit
+func (tm *TransferManager) SetTransferRateLimit(transferID string, bytesPerSecond int64) error {
+	tm.mu.RLock()
+	transfer, exists := tm.transfers[transferID]
+	tm.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("transfer not found: %s", transferID)
+	}
+
+	transfer.limiter.SetRate(bytesPerSecond)
+	return nil
+}
+
 // StartTransfer creates and registers a new transfer
 func (tm *TransferManager) StartTransfer(sessionID, transferType string, files []string) (*TransferContext, error) {
 	tm.mu.Lock()
@@ -52,6 +81,8 @@ func (tm *TransferManager) StartTransfer(sessionID, transferType string, files [
 		Files:      files,
 		ctx:        ctx,
 		cancel:     cancel,
+		limiter:    NewRateLimiter(0),
+		global:     tm.limiter,
 		startTime:  time.Now(),
 		lastUpdate: time.Now(),
 		progress: TransferProgress{
@@ -66,6 +97,21 @@ func (tm *TransferManager) StartTransfer(sessionID, transferType string, files [
 	return transfer, nil
 }
 
+// StartRelayTransfer creates and registers a server-to-server transfer
+func (tm *TransferManager) StartRelayTransfer(sourceSessionID, targetSessionID string, files []string) (*TransferContext, error) {
+	transfer, err := tm.StartTransfer(sourceSessionID, "relay", files)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer.mu.Lock()
+	transfer.TargetSessionID = targetSessionID
+	transfer.progress.TargetSessionID = targetSessionID
+	transfer.mu.Unlock()
+
+	return transfer, nil
+}
+
 // GetTransfer retrieves a transfer by ID
 func (tm *TransferManager) GetTransfer(transferID string) (*TransferContext, bool) {
 	tm.mu.RLock()
@@ -163,7 +209,7 @@ func (tm *TransferManager) ListSessionTransfers(sessionID string) []string {
 
 	ids := make([]string, 0)
 	for _, transfer := range tm.transfers {
-		if transfer.SessionID == sessionID {
+		if transfer.SessionID == sessionID || transfer.TargetSessionID == sessionID {
 			ids = append(ids, transfer.ID)
 		}
 	}
@@ -176,7 +222,7 @@ func (tm *TransferManager) CleanupSessionTransfers(sessionID string) {
 	defer tm.mu.Unlock()
 
 	for id, transfer := range tm.transfers {
-		if transfer.SessionID == sessionID {
+		if transfer.SessionID == sessionID || transfer.TargetSessionID == sessionID {
 			transfer.cancel()
 			delete(tm.transfers, id)
 		}
@@ -188,6 +234,11 @@ func (tc *TransferContext) Context() context.Context {
 	return tc.ctx
 }
 
+// Limiters returns the global and per-transfer rate limiters to apply to this transfer
+func (tc *TransferContext) Limiters() []*RateLimiter {
+	return []*RateLimiter{tc.global, tc.limiter}
+}
+
 // IsCancelled checks if the transfer has been cancelled
 func (tc *TransferContext) IsCancelled() bool {
 	select {
@@ -0,0 +1,518 @@
+package ssh
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+// The remote trash follows the freedesktop.org layout, so items trashed here can also be
+// restored with gio or trash-cli on the server and vice versa: deleted entries live in
+// ~/.local/share/Trash/files and each has a .trashinfo file in ~/.local/share/Trash/info.
+// Items on other filesystems go to $topdir/.Trash-$uid at the top of their filesystem.
+const (
+	trashDir          = ".local/share/Trash"
+	topdirTrashPrefix = ".Trash-"
+	trashInfoSuffix   = ".trashinfo"
+	trashTimeLayout   = "2006-01-02T15:04:05"
+)
+
+// ErrTrashUnavailable is returned when an item cannot be moved to any trash; the caller
+// can offer to delete it permanently instead
+var ErrTrashUnavailable = errors.New("trash is not available")
+
+// TrashItem is an entry in a server's trash
+type TrashItem struct {
+	Name         string    `json:"name"` // Used to restore or purge the item: its name in the home trash, or the full path of entries in other trashes
+	OriginalPath string    `json:"original_path"`
+	DeletedAt    time.Time `json:"deleted_at" ts_type:"string"`
+	Size         int64     `json:"size"`
+	IsDir        bool      `json:"is_dir"`
+}
+
+// trashPaths returns the home trash's files and info directories
+func (sc *SFTPClient) trashPaths() (string, string, error) {
+	// pkg/sftp never changes the server-side directory, so this is the login directory
+	home, err := sc.client.Getwd()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	root := path.Join(home, trashDir)
+	return path.Join(root, "files"), path.Join(root, "info"), nil
+}
+
+// trashUID returns the user's id, taken from the owner of the home directory
+func (sc *SFTPClient) trashUID() (string, error) {
+	home, err := sc.client.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	stat, err := sc.client.Stat(home)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat home directory: %w", err)
+	}
+	fileStat, ok := stat.Sys().(*sftp.FileStat)
+	if !ok {
+		return "", fmt.Errorf("failed to find user id")
+	}
+	return strconv.FormatUint(uint64(fileStat.UID), 10), nil
+}
+
+// topdirTrash returns the trash at the top directory of a filesystem, creating it if needed
+func (sc *SFTPClient) topdirTrash(topdir string) (string, error) {
+	uid, err := sc.trashUID()
+	if err != nil {
+		return "", err
+	}
+	root := path.Join(topdir, topdirTrashPrefix+uid)
+
+	stat, err := sc.client.Lstat(root)
+	if errors.Is(err, os.ErrNotExist) {
+		if err := sc.client.Mkdir(root); err != nil {
+			return "", fmt.Errorf("failed to create trash directory: %w", err)
+		}
+		// Nobody else may see what was deleted
+		if err := sc.client.Chmod(root, 0700); err != nil {
+			return "", fmt.Errorf("failed to create trash directory: %w", err)
+		}
+		return root, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to stat trash directory: %w", err)
+	}
+
+	// The spec requires ignoring anything but a directory owned by the user
+	fileStat, ok := stat.Sys().(*sftp.FileStat)
+	if !stat.IsDir() || !ok || strconv.FormatUint(uint64(fileStat.UID), 10) != uid {
+		return "", fmt.Errorf("%s is not a usable trash directory", root)
+	}
+	return root, nil
+}
+
+// trashRoots returns the home trash followed by the existing trashes at the top of other
+// filesystems
+func (sc *SFTPClient) trashRoots() ([]string, error) {
+	// Read before locking, as reading the mount table takes the lock itself
+	mounts := sc.remoteMountPoints(context.Background())
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	filesDir, _, err := sc.trashPaths()
+	if err != nil {
+		return nil, err
+	}
+	roots := []string{path.Dir(filesDir)}
+	if len(mounts) == 0 {
+		return roots, nil
+	}
+
+	uid, err := sc.trashUID()
+	if err != nil {
+		return nil, err
+	}
+	for mount := range mounts {
+		if isPseudoMount(mount) {
+			continue
+		}
+		root := path.Join(mount, topdirTrashPrefix+uid)
+		if stat, err := sc.client.Lstat(path.Join(root, "info")); err == nil && stat.IsDir() {
+			roots = append(roots, root)
+		}
+	}
+	sort.Strings(roots[1:])
+	return roots, nil
+}
+
+// isPseudoMount reports whether a mount point belongs to a kernel filesystem, which
+// never holds a trash
+func isPseudoMount(mount string) bool {
+	for _, prefix := range []string{"/proc", "/sys", "/dev"} {
+		if mount == prefix || strings.HasPrefix(mount, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// mountPointOf returns the mount point containing p, or "" if mounts is empty
+func mountPointOf(p string, mounts map[string]bool) string {
+	for dir := p; ; dir = path.Dir(dir) {
+		if mounts[dir] {
+			return dir
+		}
+		if dir == "/" {
+			return ""
+		}
+	}
+}
+
+// trashLocation resolves an item name to the files and info directories of its trash and
+// its entry name there
+func (sc *SFTPClient) trashLocation(name string) (string, string, string, error) {
+	if !strings.Contains(name, "/") {
+		if name == "" || name == "." || name == ".." {
+			return "", "", "", fmt.Errorf("invalid trash item: %s", name)
+		}
+		filesDir, infoDir, err := sc.trashPaths()
+		return filesDir, infoDir, name, err
+	}
+
+	// Entries in other trashes are named $topdir/.Trash-$uid/files/name
+	uid, err := sc.trashUID()
+	if err != nil {
+		return "", "", "", err
+	}
+	filesDir, entry := path.Dir(name), path.Base(name)
+	root := path.Dir(filesDir)
+	if !path.IsAbs(name) || path.Clean(name) != name || entry == ".." ||
+		path.Base(filesDir) != "files" || path.Base(root) != topdirTrashPrefix+uid {
+		return "", "", "", fmt.Errorf("invalid trash item: %s", name)
+	}
+	return filesDir, path.Join(root, "info"), entry, nil
+}
+
+// MoveToTrash moves a file or directory into the trash instead of deleting it. Items on
+// another filesystem than the home directory, which cannot be renamed into the home
+// trash, go to the trash at the top of their filesystem; if that fails too the error
+// wraps ErrTrashUnavailable.
+func (sc *SFTPClient) MoveToTrash(filePath string) (*TrashItem, error) {
+	filePath = normalizePath(filePath)
+
+	sc.mu.Lock()
+	filesDir, _, err := sc.trashPaths()
+	if err != nil {
+		sc.mu.Unlock()
+		return nil, err
+	}
+	homeRoot := path.Dir(filesDir)
+	if filePath == "/" || filePath == homeRoot || strings.HasPrefix(filePath+"/", homeRoot+"/") {
+		sc.mu.Unlock()
+		return nil, fmt.Errorf("cannot move %s to trash", filePath)
+	}
+	if strings.HasPrefix(homeRoot+"/", filePath+"/") {
+		sc.mu.Unlock()
+		return nil, fmt.Errorf("cannot move %s to trash: it contains the trash", filePath)
+	}
+	item, renameFailed, err := sc.moveIntoTrash(homeRoot, filePath, filePath)
+	sc.mu.Unlock()
+	if !renameFailed {
+		return item, err
+	}
+
+	// A rename cannot cross filesystems
+	mounts := sc.remoteMountPoints(context.Background())
+	topdir := mountPointOf(filePath, mounts)
+	if topdir != "" && topdir == mountPointOf(homeRoot, mounts) {
+		return nil, err
+	}
+	if topdir == "" || topdir == filePath {
+		return nil, fmt.Errorf("%w: %s: %w", ErrTrashUnavailable, filePath, err)
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	root, err := sc.topdirTrash(topdir)
+	if err == nil && (filePath == root || strings.HasPrefix(filePath+"/", root+"/")) {
+		return nil, fmt.Errorf("cannot move %s to trash", filePath)
+	}
+	if err == nil {
+		// The spec records paths relative to the top directory in its trash
+		relPath := strings.TrimPrefix(strings.TrimPrefix(filePath, topdir), "/")
+		if item, _, err = sc.moveIntoTrash(root, filePath, relPath); err == nil {
+			item.Name = path.Join(root, "files", item.Name)
+			return item, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s: %w", ErrTrashUnavailable, filePath, err)
+}
+
+// moveIntoTrash moves a file into the trash at root, recording originalPath in its info
+// file. The returned bool reports that only the final rename failed, as it does when the
+// trash is on another filesystem.
+func (sc *SFTPClient) moveIntoTrash(root, filePath, originalPath string) (*TrashItem, bool, error) {
+	filesDir, infoDir := path.Join(root, "files"), path.Join(root, "info")
+
+	stat, err := sc.client.Lstat(filePath)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	if err := sc.client.MkdirAll(filesDir); err != nil {
+		return nil, false, fmt.Errorf("failed to create trash directory: %w", err)
+	}
+	if err := sc.client.MkdirAll(infoDir); err != nil {
+		return nil, false, fmt.Errorf("failed to create trash directory: %w", err)
+	}
+
+	item := &TrashItem{
+		OriginalPath: filePath,
+		DeletedAt:    time.Now().Truncate(time.Second),
+		Size:         stat.Size(),
+		IsDir:        stat.IsDir(),
+	}
+
+	// The info file is created exclusively first, which reserves the name in the trash
+	base := path.Base(filePath)
+	var infoFile io.WriteCloser
+	for i := 1; ; i++ {
+		item.Name = base
+		if i > 1 {
+			item.Name = base + "." + strconv.Itoa(i)
+		}
+		if _, err := sc.client.Lstat(path.Join(filesDir, item.Name)); err == nil {
+			continue
+		}
+
+		infoFile, err = sc.client.OpenFile(path.Join(infoDir, item.Name+trashInfoSuffix), os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+		if err == nil {
+			break
+		}
+		if _, statErr := sc.client.Lstat(path.Join(infoDir, item.Name+trashInfoSuffix)); statErr != nil {
+			return nil, false, fmt.Errorf("failed to create trash info: %w", err)
+		}
+	}
+
+	infoPath := path.Join(infoDir, item.Name+trashInfoSuffix)
+	info := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: originalPath}).EscapedPath(), item.DeletedAt.Format(trashTimeLayout))
+	_, err = io.WriteString(infoFile, info)
+	if closeErr := infoFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		sc.client.Remove(infoPath)
+		return nil, false, fmt.Errorf("failed to write trash info: %w", err)
+	}
+
+	if err := sc.client.Rename(filePath, path.Join(filesDir, item.Name)); err != nil {
+		sc.client.Remove(infoPath)
+		return nil, true, fmt.Errorf("failed to move %s to trash: %w", filePath, err)
+	}
+	sc.InvalidateListings(filePath, path.Join(filesDir, item.Name))
+
+	return item, false, nil
+}
+
+// ListTrash lists the items in all trashes, most recently deleted first
+func (sc *SFTPClient) ListTrash() ([]TrashItem, error) {
+	roots, err := sc.trashRoots()
+	if err != nil {
+		return nil, err
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	return sc.listTrash(roots)
+}
+
+// listTrash lists the trashes at roots; the first is the home trash
+func (sc *SFTPClient) listTrash(roots []string) ([]TrashItem, error) {
+	items := []TrashItem{}
+	for i, root := range roots {
+		filesDir, infoDir := path.Join(root, "files"), path.Join(root, "info")
+
+		infos, err := sc.client.ReadDir(infoDir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read trash: %w", err)
+		}
+
+		for _, info := range infos {
+			name, ok := strings.CutSuffix(info.Name(), trashInfoSuffix)
+			if !ok {
+				continue
+			}
+
+			item, err := sc.readTrashInfo(path.Join(infoDir, info.Name()))
+			if err != nil {
+				continue
+			}
+			item.Name = name
+			if i > 0 {
+				item.Name = path.Join(filesDir, name)
+			}
+
+			// Info files without their entry are leftovers of interrupted operations
+			stat, err := sc.client.Lstat(path.Join(filesDir, name))
+			if err != nil {
+				continue
+			}
+			item.Size = stat.Size()
+			item.IsDir = stat.IsDir()
+
+			items = append(items, *item)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+// readTrashInfo parses a .trashinfo file
+func (sc *SFTPClient) readTrashInfo(infoPath string) (*TrashItem, error) {
+	file, err := sc.client.Open(infoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	item := &TrashItem{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Path":
+			if unescaped, err := url.PathUnescape(value); err == nil {
+				item.OriginalPath = unescaped
+			}
+		case "DeletionDate":
+			if deletedAt, err := time.ParseInLocation(trashTimeLayout, value, time.Local); err == nil {
+				item.DeletedAt = deletedAt
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if item.OriginalPath == "" {
+		return nil, fmt.Errorf("invalid trash info: %s", infoPath)
+	}
+
+	// Relative paths are relative to the directory containing the trash
+	if !path.IsAbs(item.OriginalPath) {
+		item.OriginalPath = path.Join(path.Dir(path.Dir(path.Dir(infoPath))), item.OriginalPath)
+	}
+	return item, nil
+}
+
+// RestoreFromTrash moves a trashed item back to its original path, recreating missing
+// parent directories. It fails rather than overwrite anything now at that path.
+func (sc *SFTPClient) RestoreFromTrash(name string) (string, error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	filesDir, infoDir, name, err := sc.trashLocation(name)
+	if err != nil {
+		return "", err
+	}
+
+	infoPath := path.Join(infoDir, name+trashInfoSuffix)
+	item, err := sc.readTrashInfo(infoPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read trash info: %w", err)
+	}
+
+	if _, err := sc.client.Lstat(item.OriginalPath); err == nil {
+		return "", fmt.Errorf("cannot restore %s: a file with that name already exists", item.OriginalPath)
+	}
+	if err := sc.client.MkdirAll(path.Dir(item.OriginalPath)); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if err := sc.client.Rename(path.Join(filesDir, name), item.OriginalPath); err != nil {
+		return "", fmt.Errorf("failed to restore %s: %w", item.OriginalPath, err)
+	}
+	sc.InvalidateListings(item.OriginalPath, path.Join(filesDir, name))
+	sc.client.Remove(infoPath)
+
+	return item.OriginalPath, nil
+}
+
+// PurgeTrash permanently deletes items from the trash
+func (sc *SFTPClient) PurgeTrash(names []string) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	for _, name := range names {
+		if err := sc.purgeTrashItem(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EmptyTrash permanently deletes everything in the trash
+func (sc *SFTPClient) EmptyTrash() error {
+	return sc.PurgeTrashOlderThan(0)
+}
+
+// PurgeTrashOlderThan permanently deletes items that were trashed more than maxAge ago
+func (sc *SFTPClient) PurgeTrashOlderThan(maxAge time.Duration) error {
+	roots, err := sc.trashRoots()
+	if err != nil {
+		return err
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	items, err := sc.listTrash(roots)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	for _, item := range items {
+		// Items without a readable deletion date are only removed when emptying the trash
+		if item.DeletedAt.After(cutoff) || item.DeletedAt.IsZero() && maxAge > 0 {
+			continue
+		}
+		if err := sc.purgeTrashItem(item.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeTrashItem deletes an entry and its info file; the info file goes last so a
+// partly deleted entry still shows up in the trash
+func (sc *SFTPClient) purgeTrashItem(name string) error {
+	filesDir, infoDir, name, err := sc.trashLocation(name)
+	if err != nil {
+		return err
+	}
+
+	entryPath := path.Join(filesDir, name)
+	defer sc.InvalidateListings(entryPath)
+
+	stat, err := sc.client.Lstat(entryPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to stat trash item: %w", err)
+	case stat.IsDir():
+		if err := sc.deleteRecursive(entryPath); err != nil {
+			return err
+		}
+	default:
+		if err := sc.client.Remove(entryPath); err != nil {
+			return fmt.Errorf("failed to delete file %s: %w", entryPath, err)
+		}
+	}
+
+	if err := sc.client.Remove(path.Join(infoDir, name+trashInfoSuffix)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete trash info: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package ssh
+
+import (
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestMountPointOf(t *testing.T) {
+	mounts := map[string]bool{"/": true, "/home": true, "/mnt/data": true}
+	tests := map[string]string{
+		"/etc/passwd":        "/",
+		"/home":              "/home",
+		"/home/user/file":    "/home",
+		"/mnt/data/dir/file": "/mnt/data",
+		"/mnt/database/file": "/",
+		"/":                  "/",
+	}
+	for p, want := range tests {
+		if got := mountPointOf(p, mounts); got != want {
+			t.Errorf("mountPointOf(%q) = %q, want %q", p, got, want)
+		}
+	}
+	if got := mountPointOf("/home/user", nil); got != "" {
+		t.Errorf("mountPointOf without mounts = %q, want empty", got)
+	}
+}
+
+func TestTrashLocation(t *testing.T) {
+	sc := newTestSFTPClient(t, 0)
+	uid, err := sc.trashUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filesDir, infoDir, entry, err := sc.trashLocation("notes.txt")
+	if err != nil || !strings.HasSuffix(filesDir, trashDir+"/files") || infoDir != path.Join(path.Dir(filesDir), "info") || entry != "notes.txt" {
+		t.Errorf("home trash location = %q, %q, %q, %v", filesDir, infoDir, entry, err)
+	}
+
+	root := "/mnt/data/" + topdirTrashPrefix + uid
+	filesDir, infoDir, entry, err = sc.trashLocation(root + "/files/notes.txt")
+	if err != nil || filesDir != root+"/files" || infoDir != root+"/info" || entry != "notes.txt" {
+		t.Errorf("topdir trash location = %q, %q, %q, %v", filesDir, infoDir, entry, err)
+	}
+
+	for _, name := range []string{
+		"",
+		".",
+		"..",
+		"/etc/passwd",
+		"/etc/files/passwd",
+		root + "/info/notes.txt",
+		root + "/files/../../../etc/passwd",
+		"mnt/data/" + topdirTrashPrefix + uid + "/files/notes.txt",
+		"/mnt/data/" + topdirTrashPrefix + uid + "0/files/notes.txt",
+	} {
+		if _, _, _, err := sc.trashLocation(name); err == nil {
+			t.Errorf("trashLocation(%q) succeeded, want an error", name)
+		}
+	}
+}
@@ -0,0 +1,722 @@
+package ssh
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TrzszHandler supplies the user interaction for trzsz transfers started with
+// trz/tsz in a terminal
+type TrzszHandler struct {
+	// SelectFiles is asked which local files to send to a remote trz; none cancels
+	SelectFiles func(sessionID string) ([]string, error)
+	// SelectDirectory is asked which local directory to send to a remote trz -d; "" cancels
+	SelectDirectory func(sessionID string) (string, error)
+	// SaveDirectory is asked where to store files sent by a remote tsz; "" cancels
+	SaveDirectory func(sessionID string) (string, error)
+	// Progress receives updates for every file, which are also recorded in the TransferManager
+	Progress func(progress TransferProgress)
+}
+
+const (
+	trzszVersion      = "1.1.6"
+	trzszMarker       = "::TRZSZ:TRANSFER:"
+	trzszTimeout      = 30 * time.Second
+	trzszMinChunkSize = 1024
+	trzszMaxChunkSize = 1024 * 1024
+)
+
+// trzszTrigger matches the line trz/tsz prints when it starts; the mode is R (trz),
+// D (trz -d) or S (tsz), followed by the version and an ID unique to the run
+var trzszTrigger = regexp.MustCompile(`(?:\x1b7\x07)?::TRZSZ:TRANSFER:([RDS]):(\d+\.\d+\.\d+)(:\d+)?[^\n]*\n`)
+
+// trzszConfig is the part of the server's CFG message the client needs
+type trzszConfig struct {
+	Binary      bool            `json:"binary"`
+	Directory   bool            `json:"directory"`
+	Overwrite   bool            `json:"overwrite"`
+	Timeout     int             `json:"timeout"`
+	Newline     string          `json:"newline"`
+	MaxBufSize  int64           `json:"max_buf_size"`
+	EscapeChars json.RawMessage `json:"escape_chars"`
+}
+
+// trzszFileName describes an entry in directory mode
+type trzszFileName struct {
+	PathID   int      `json:"path_id"`
+	PathName []string `json:"path_name"`
+	IsDir    bool     `json:"is_dir"`
+}
+
+// trzszRemoteError is a failure reported by the remote trz/tsz
+type trzszRemoteError struct {
+	message string
+}
+
+func (e *trzszRemoteError) Error() string {
+	return "trzsz: " + e.message
+}
+
+// trzszSession runs one trz or tsz exchange over a terminal stream
+type trzszSession struct {
+	*terminalStream
+	mode    byte
+	handler *TrzszHandler
+	config  trzszConfig
+
+	escapes  map[byte][]byte // Binary mode escaping of data sent to the server
+	escapeBy byte            // Leading byte of every escape sequence
+	escaped  map[byte]byte   // Second escape byte to original byte
+}
+
+func newTrzszSession(sessionID string, mode byte, handler *TrzszHandler, transfers *TransferManager, out io.Writer) *trzszSession {
+	ts := &trzszSession{
+		terminalStream: newTerminalStream(sessionID, transfers, handler.Progress, out, trzszTimeout),
+		mode:           mode,
+		handler:        handler,
+		config:         trzszConfig{Newline: "\n"},
+	}
+	ts.start(func() error {
+		err := ts.run()
+		var remoteErr *trzszRemoteError
+		if err != nil && !errors.As(err, &remoteErr) {
+			message := err.Error()
+			if errors.Is(err, context.Canceled) {
+				message = "Stopped"
+			}
+			ts.sendString("fail", message)
+		}
+		return err
+	})
+	return ts
+}
+
+// detectTrzsz looks for the trigger line of trz/tsz. It returns the trigger's
+// bounds, its mode and unique ID. When data ends inside a possible trigger,
+// partial is set and the caller should hold back data[start:].
+func detectTrzsz(data []byte) (start, end int, mode byte, id string, found, partial bool) {
+	if match := trzszTrigger.FindSubmatchIndex(data); match != nil {
+		id = string(data[match[4]:match[5]])
+		if match[6] >= 0 {
+			id += string(data[match[6]:match[7]])
+		}
+		return match[0], match[1], data[match[2]], id, true, false
+	}
+
+	// The marker arrived but not yet the end of its line
+	if idx := bytes.LastIndex(data, []byte(trzszMarker)); idx >= 0 && len(data)-idx < 128 && bytes.IndexByte(data[idx:], '\n') == -1 {
+		if idx >= 3 && bytes.HasPrefix(data[idx-3:], []byte("\x1b7\x07")) {
+			idx -= 3
+		}
+		return idx, 0, 0, "", false, true
+	}
+
+	// The marker itself is cut off; only longer fragments are held so common output is not delayed
+	for _, prefix := range []string{"\x1b7\x07" + trzszMarker, trzszMarker} {
+		for n := min(int64(len(prefix)-1), int64(len(data))); n >= 3; n-- {
+			if bytes.HasSuffix(data, []byte(prefix[:n])) {
+				return len(data) - int(n), 0, 0, "", false, true
+			}
+		}
+	}
+
+	return 0, 0, 0, "", false, false
+}
+
+func (ts *trzszSession) run() error {
+	switch ts.mode {
+	case 'S':
+		var dir string
+		var err error
+		if ts.handler.SaveDirectory != nil {
+			if dir, err = ts.handler.SaveDirectory(ts.sessionID); err != nil {
+				return err
+			}
+		}
+		if dir == "" {
+			return ts.sendAction(false)
+		}
+		if err := ts.handshake(); err != nil {
+			return err
+		}
+		return ts.recvFiles(dir)
+
+	case 'R', 'D':
+		var paths []string
+		var err error
+		if ts.mode == 'D' && ts.handler.SelectDirectory != nil {
+			var dir string
+			if dir, err = ts.handler.SelectDirectory(ts.sessionID); dir != "" {
+				paths = []string{dir}
+			}
+		} else if ts.handler.SelectFiles != nil {
+			paths, err = ts.handler.SelectFiles(ts.sessionID)
+		}
+		if err != nil {
+			return err
+		}
+		if len(paths) == 0 {
+			return ts.sendAction(false)
+		}
+		if err := ts.handshake(); err != nil {
+			return err
+		}
+		return ts.sendFiles(paths)
+	}
+
+	return fmt.Errorf("unknown trzsz mode %q", ts.mode)
+}
+
+func (ts *trzszSession) sendAction(confirm bool) error {
+	action, _ := json.Marshal(map[string]interface{}{
+		"lang":        "go",
+		"confirm":     confirm,
+		"version":     trzszVersion,
+		"support_dir": true,
+	})
+	return ts.sendString("ACT", string(action))
+}
+
+// handshake confirms the transfer and reads the server's configuration
+func (ts *trzszSession) handshake() error {
+	if err := ts.sendAction(true); err != nil {
+		return err
+	}
+
+	cfg, err := ts.recvString("CFG")
+	if err != nil {
+		return err
+	}
+	config := trzszConfig{}
+	if err := json.Unmarshal([]byte(cfg), &config); err != nil {
+		return fmt.Errorf("failed to parse trzsz config: %w", err)
+	}
+	if config.Newline == "" {
+		config.Newline = "\n"
+	}
+	if config.Timeout > 0 {
+		ts.timeout = time.Duration(config.Timeout) * time.Second
+	}
+	ts.config = config
+
+	if config.Binary {
+		ts.setEscapeChars(config.EscapeChars)
+	}
+	return nil
+}
+
+// setEscapeChars builds the binary mode escape tables from pairs of
+// [original, escaped] strings whose characters are single bytes
+func (ts *trzszSession) setEscapeChars(raw json.RawMessage) {
+	pairs := [][]string{}
+	if len(raw) == 0 || json.Unmarshal(raw, &pairs) != nil || len(pairs) == 0 {
+		pairs = [][]string{{"î", "îî"}, {"~", "î1"}}
+	}
+
+	toBytes := func(s string) []byte {
+		var b []byte
+		for _, r := range s {
+			b = append(b, byte(r))
+		}
+		return b
+	}
+
+	ts.escapes = make(map[byte][]byte)
+	ts.escaped = make(map[byte]byte)
+	for _, pair := range pairs {
+		if len(pair) != 2 {
+			continue
+		}
+		original, escaped := toBytes(pair[0]), toBytes(pair[1])
+		if len(original) != 1 || len(escaped) != 2 {
+			continue
+		}
+		ts.escapes[original[0]] = escaped
+		ts.escapeBy = escaped[0]
+		ts.escaped[escaped[1]] = original[0]
+	}
+}
+
+// sendFiles uploads local files, or directories in directory mode
+func (ts *trzszSession) sendFiles(paths []string) error {
+	type sourceFile struct {
+		localPath string
+		name      trzszFileName
+		size      int64
+	}
+
+	var sources []sourceFile
+	for pathID, localPath := range paths {
+		info, err := os.Stat(localPath)
+		if err != nil {
+			return fmt.Errorf("failed to stat local file: %w", err)
+		}
+		if !info.IsDir() {
+			sources = append(sources, sourceFile{localPath, trzszFileName{pathID, []string{info.Name()}, false}, info.Size()})
+			continue
+		}
+		if !ts.config.Directory {
+			return fmt.Errorf("%s is a directory, use trz -d to upload directories", localPath)
+		}
+
+		root := filepath.Dir(localPath)
+		err = filepath.Walk(localPath, func(walkPath string, entry os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() && !entry.Mode().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(root, walkPath)
+			if err != nil {
+				return err
+			}
+			sources = append(sources, sourceFile{walkPath, trzszFileName{pathID, strings.Split(filepath.ToSlash(rel), "/"), entry.IsDir()}, entry.Size()})
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to walk local directory: %w", err)
+		}
+	}
+
+	if err := ts.sendInteger("NUM", int64(len(sources))); err != nil {
+		return err
+	}
+	if err := ts.checkInteger(int64(len(sources))); err != nil {
+		return err
+	}
+
+	var remoteNames []string
+	for _, source := range sources {
+		name := source.name.PathName[len(source.name.PathName)-1]
+		if ts.config.Directory {
+			encoded, _ := json.Marshal(source.name)
+			name = string(encoded)
+		}
+		if err := ts.sendString("NAME", name); err != nil {
+			return err
+		}
+		remoteName, err := ts.recvString("SUCC")
+		if err != nil {
+			return err
+		}
+		if len(source.name.PathName) == 1 {
+			remoteNames = append(remoteNames, remoteName)
+		}
+		if source.name.IsDir {
+			continue
+		}
+
+		if err := ts.sendFile(source.localPath, source.name.PathName[len(source.name.PathName)-1], source.size); err != nil {
+			return err
+		}
+	}
+
+	return ts.sendString("EXIT", fmt.Sprintf("Received %s", strings.Join(remoteNames, ", ")))
+}
+
+// sendFile sends the size, data and digest of one file
+func (ts *trzszSession) sendFile(localPath, name string, size int64) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open local file: %w", err)
+	}
+	defer file.Close()
+
+	transfer, progress, stopCancel := ts.startTransfer("upload", localPath, name, size)
+	defer stopCancel()
+
+	fail := func(err error) error {
+		ts.failTransfer(transfer, err)
+		return err
+	}
+
+	if err := ts.sendInteger("SIZE", size); err != nil {
+		return fail(err)
+	}
+	if err := ts.checkInteger(size); err != nil {
+		return fail(err)
+	}
+
+	chunkSize := int64(trzszMinChunkSize)
+	maxChunkSize := int64(trzszMaxChunkSize)
+	if ts.config.MaxBufSize > 0 {
+		maxChunkSize = max(min(maxChunkSize, ts.config.MaxBufSize), trzszMinChunkSize)
+	}
+
+	hasher := md5.New()
+	buffer := make([]byte, maxChunkSize)
+	for sent := int64(0); sent < size; {
+		n, err := file.Read(buffer[:chunkSize])
+		if n == 0 && err != nil {
+			return fail(fmt.Errorf("failed to read local file: %w", err))
+		}
+
+		began := time.Now()
+		if err := ts.sendData(buffer[:n]); err != nil {
+			return fail(err)
+		}
+		if err := ts.checkInteger(int64(n)); err != nil {
+			return fail(err)
+		}
+		hasher.Write(buffer[:n])
+		sent += int64(n)
+		progress.addBytes(int64(n))
+
+		// Every chunk is acknowledged, so larger chunks pay for the round trip less often
+		if time.Since(began) < time.Second {
+			chunkSize = min(chunkSize*2, maxChunkSize)
+		}
+	}
+
+	digest := hasher.Sum(nil)
+	if err := ts.sendBinary("MD5", digest); err != nil {
+		return fail(err)
+	}
+	remoteDigest, err := ts.recvBinary("SUCC")
+	if err != nil {
+		return fail(err)
+	}
+	if !bytes.Equal(digest, remoteDigest) {
+		return fail(fmt.Errorf("check MD5 of %s failed", name))
+	}
+
+	progress.complete()
+	return nil
+}
+
+// recvFiles downloads the files sent by the server into dir
+func (ts *trzszSession) recvFiles(dir string) error {
+	num, err := ts.recvInteger("NUM")
+	if err != nil {
+		return err
+	}
+	if err := ts.sendInteger("SUCC", num); err != nil {
+		return err
+	}
+
+	topNames := make(map[int]string)
+	var localNames []string
+	for i := int64(0); i < num; i++ {
+		fileName, err := ts.recvString("NAME")
+		if err != nil {
+			return err
+		}
+
+		name := trzszFileName{PathName: []string{fileName}}
+		if ts.config.Directory {
+			if err := json.Unmarshal([]byte(fileName), &name); err != nil {
+				return fmt.Errorf("failed to parse trzsz file name: %w", err)
+			}
+		}
+		for _, part := range name.PathName {
+			if part == "" || part == "." || part == ".." || strings.ContainsAny(part, "/\\") {
+				return fmt.Errorf("invalid file name from server: %q", fileName)
+			}
+		}
+		if len(name.PathName) == 0 {
+			return fmt.Errorf("invalid file name from server: %q", fileName)
+		}
+
+		// The top level entry gets a new name if it exists; everything below follows it
+		top, exists := topNames[name.PathID]
+		if !exists || len(name.PathName) == 1 {
+			top = name.PathName[0]
+			if !ts.config.Overwrite {
+				top = uniqueLocalName(dir, top)
+			}
+			topNames[name.PathID] = top
+			localNames = append(localNames, top)
+		}
+		localPath := filepath.Join(append([]string{dir, top}, name.PathName[1:]...)...)
+
+		if name.IsDir {
+			if err := os.MkdirAll(localPath, 0755); err != nil {
+				return fmt.Errorf("failed to create local directory: %w", err)
+			}
+			if err := ts.sendString("SUCC", filepath.Base(localPath)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+			return fmt.Errorf("failed to create local directory: %w", err)
+		}
+		if err := ts.recvFile(localPath); err != nil {
+			return err
+		}
+	}
+
+	return ts.sendString("EXIT", fmt.Sprintf("Saved %s to %s", strings.Join(localNames, ", "), dir))
+}
+
+// recvFile receives the size, data and digest of one file
+func (ts *trzszSession) recvFile(localPath string) error {
+	file, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to create local file: %w", err)
+	}
+	defer file.Close()
+
+	name := filepath.Base(localPath)
+	if err := ts.sendString("SUCC", name); err != nil {
+		return err
+	}
+
+	size, err := ts.recvInteger("SIZE")
+	if err != nil {
+		return err
+	}
+	if err := ts.sendInteger("SUCC", size); err != nil {
+		return err
+	}
+
+	transfer, progress, stopCancel := ts.startTransfer("download", localPath, name, size)
+	defer stopCancel()
+
+	fail := func(err error) error {
+		ts.failTransfer(transfer, err)
+		return err
+	}
+
+	hasher := md5.New()
+	for received := int64(0); received < size; {
+		data, err := ts.recvData()
+		if err != nil {
+			return fail(err)
+		}
+		if _, err := file.Write(data); err != nil {
+			return fail(fmt.Errorf("failed to write local file: %w", err))
+		}
+		hasher.Write(data)
+		received += int64(len(data))
+		progress.addBytes(int64(len(data)))
+
+		if err := ts.sendInteger("SUCC", int64(len(data))); err != nil {
+			return fail(err)
+		}
+	}
+
+	expected, err := ts.recvBinary("MD5")
+	if err != nil {
+		return fail(err)
+	}
+	digest := hasher.Sum(nil)
+	if !bytes.Equal(digest, expected) {
+		return fail(fmt.Errorf("check MD5 of %s failed", name))
+	}
+	if err := ts.sendBinary("SUCC", digest); err != nil {
+		return fail(err)
+	}
+
+	progress.complete()
+	return nil
+}
+
+// uniqueLocalName returns name, or name.0 to name.999 if it already exists in dir
+func uniqueLocalName(dir, name string) string {
+	if _, err := os.Lstat(filepath.Join(dir, name)); os.IsNotExist(err) {
+		return name
+	}
+	for i := 0; i < 1000; i++ {
+		candidate := fmt.Sprintf("%s.%d", name, i)
+		if _, err := os.Lstat(filepath.Join(dir, candidate)); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+	return name
+}
+
+func (ts *trzszSession) sendLine(typ, buf string) error {
+	_, err := ts.out.Write([]byte("#" + typ + ":" + buf + ts.config.Newline))
+	return err
+}
+
+func (ts *trzszSession) sendInteger(typ string, value int64) error {
+	return ts.sendLine(typ, strconv.FormatInt(value, 10))
+}
+
+func (ts *trzszSession) sendString(typ, value string) error {
+	return ts.sendLine(typ, trzszEncode([]byte(value)))
+}
+
+func (ts *trzszSession) sendBinary(typ string, value []byte) error {
+	return ts.sendLine(typ, trzszEncode(value))
+}
+
+// sendData sends a chunk of file data, raw with escaping in binary mode
+func (ts *trzszSession) sendData(data []byte) error {
+	if err := ts.ctx.Err(); err != nil {
+		return err
+	}
+	if !ts.config.Binary {
+		return ts.sendBinary("DATA", data)
+	}
+
+	escaped := make([]byte, 0, len(data)+len(data)/16)
+	for _, b := range data {
+		if escape, ok := ts.escapes[b]; ok {
+			escaped = append(escaped, escape...)
+		} else {
+			escaped = append(escaped, b)
+		}
+	}
+
+	if err := ts.sendLine("DATA", strconv.Itoa(len(escaped))); err != nil {
+		return err
+	}
+	_, err := ts.out.Write(escaped)
+	return err
+}
+
+// recvLine reads the next "#TYPE:buffer" line, skipping terminal noise in front of it
+func (ts *trzszSession) recvLine() (string, string, error) {
+	var line []byte
+	for {
+		b, err := ts.readByte()
+		if err != nil {
+			return "", "", err
+		}
+		if b != '\n' {
+			line = append(line, b)
+			continue
+		}
+
+		idx := bytes.LastIndexByte(line, '#')
+		if idx == -1 {
+			line = line[:0]
+			continue
+		}
+		typ, buf, ok := strings.Cut(strings.TrimRight(string(line[idx+1:]), "\r!"), ":")
+		if !ok {
+			line = line[:0]
+			continue
+		}
+		return typ, buf, nil
+	}
+}
+
+// recv reads the next line of the expected type; failures reported by the server become errors
+func (ts *trzszSession) recv(expected string) (string, error) {
+	typ, buf, err := ts.recvLine()
+	if err != nil {
+		return "", err
+	}
+	if typ == expected {
+		return buf, nil
+	}
+
+	if typ == "fail" || typ == "FAIL" || typ == "EXIT" {
+		message, err := trzszDecode(buf)
+		if err != nil {
+			message = []byte(buf)
+		}
+		return "", &trzszRemoteError{message: string(message)}
+	}
+	return "", fmt.Errorf("trzsz: expected %s but got %s", expected, typ)
+}
+
+func (ts *trzszSession) recvInteger(expected string) (int64, error) {
+	buf, err := ts.recv(expected)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(buf, 10, 64)
+}
+
+func (ts *trzszSession) recvString(expected string) (string, error) {
+	data, err := ts.recvBinary(expected)
+	return string(data), err
+}
+
+func (ts *trzszSession) recvBinary(expected string) ([]byte, error) {
+	buf, err := ts.recv(expected)
+	if err != nil {
+		return nil, err
+	}
+	return trzszDecode(buf)
+}
+
+// checkInteger reads the server's acknowledgement of value
+func (ts *trzszSession) checkInteger(value int64) error {
+	acknowledged, err := ts.recvInteger("SUCC")
+	if err != nil {
+		return err
+	}
+	if acknowledged != value {
+		return fmt.Errorf("trzsz: server acknowledged %d instead of %d", acknowledged, value)
+	}
+	return nil
+}
+
+// recvData reads a chunk of file data, raw with escaping in binary mode
+func (ts *trzszSession) recvData() ([]byte, error) {
+	if !ts.config.Binary {
+		return ts.recvBinary("DATA")
+	}
+
+	size, err := ts.recvInteger("DATA")
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 0, size)
+	for i := int64(0); i < size; i++ {
+		b, err := ts.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if b == ts.escapeBy && len(ts.escaped) > 0 {
+			if i++; i >= size {
+				return nil, fmt.Errorf("trzsz: truncated escape sequence")
+			}
+			next, err := ts.readByte()
+			if err != nil {
+				return nil, err
+			}
+			original, ok := ts.escaped[next]
+			if !ok {
+				return nil, fmt.Errorf("trzsz: unknown escape sequence %#x %#x", b, next)
+			}
+			b = original
+		}
+		data = append(data, b)
+	}
+	return data, nil
+}
+
+// trzszEncode compresses data with zlib and encodes it as base64
+func trzszEncode(data []byte) string {
+	var buf bytes.Buffer
+	writer := zlib.NewWriter(&buf)
+	writer.Write(data)
+	writer.Close()
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func trzszDecode(encoded string) ([]byte, error) {
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("trzsz: invalid base64: %w", err)
+	}
+	reader, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("trzsz: invalid zlib data: %w", err)
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
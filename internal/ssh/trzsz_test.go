@@ -0,0 +1,62 @@
+package ssh
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestDetectTrzsz(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		start, end int
+		mode       byte
+		id         string
+		found      bool
+		partial    bool
+	}{
+		{"tsz", "$ tsz a.txt\r\n\x1b7\x07::TRZSZ:TRANSFER:S:1.1.6:1700000000123\r\n", 13, 56, 'S', "1.1.6:1700000000123", true, false},
+		{"trz without cursor save", "::TRZSZ:TRANSFER:R:1.1.6:42\n", 0, 28, 'R', "1.1.6:42", true, false},
+		{"trz -d, old version", "::TRZSZ:TRANSFER:D:1.0.0\r\n", 0, 26, 'D', "1.0.0", true, false},
+		{"line not finished", "out\x1b7\x07::TRZSZ:TRANSFER:D:1.1", 3, 0, 0, "", false, true},
+		{"marker cut off", "out::TRZ", 3, 0, 0, "", false, true},
+		{"short fragment", "ab::", 0, 0, 0, "", false, false},
+		{"unknown mode", "::TRZSZ:TRANSFER:X:1.1.6\n", 0, 0, 0, "", false, false},
+		{"plain output", "hello world\n", 0, 0, 0, "", false, false},
+	}
+
+	for _, tt := range tests {
+		start, end, mode, id, found, partial := detectTrzsz([]byte(tt.data))
+		if start != tt.start || end != tt.end || mode != tt.mode || id != tt.id || found != tt.found || partial != tt.partial {
+			t.Errorf("%s: detectTrzsz = %d, %d, %q, %q, %t, %t, want %d, %d, %q, %q, %t, %t", tt.name,
+				start, end, mode, id, found, partial, tt.start, tt.end, tt.mode, tt.id, tt.found, tt.partial)
+		}
+	}
+}
+
+func TestTrzszEncodeRoundTrip(t *testing.T) {
+	random := make([]byte, 100*1024)
+	rand.Read(random)
+
+	for _, data := range [][]byte{{}, []byte("hello trzsz"), bytes.Repeat([]byte("a"), 64*1024), random} {
+		decoded, err := trzszDecode(trzszEncode(data))
+		if err != nil {
+			t.Fatalf("trzszDecode failed: %v", err)
+		}
+		if !bytes.Equal(decoded, data) {
+			t.Errorf("round trip of %d bytes returned %d different bytes", len(data), len(decoded))
+		}
+	}
+
+	// "hello" compressed with zlib at the default level, as trzsz sends it
+	if decoded, err := trzszDecode("eJzLSM3JyQcABiwCFQ=="); err != nil || string(decoded) != "hello" {
+		t.Errorf("trzszDecode = %q, %v, want hello", decoded, err)
+	}
+
+	for _, invalid := range []string{"not base64!", "aGVsbG8="} {
+		if _, err := trzszDecode(invalid); err == nil {
+			t.Errorf("trzszDecode(%q) succeeded, want error", invalid)
+		}
+	}
+}
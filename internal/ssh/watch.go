@@ -0,0 +1,427 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Directory change types
+const (
+	DirChangeCreated  = "created"
+	DirChangeModified = "modified"
+	DirChangeDeleted  = "deleted"
+)
+
+// Watch modes
+const (
+	WatchModeAuto    = ""        // inotifywait when available, polling otherwise
+	WatchModePoll    = "poll"    // Compare directory listings over SFTP
+	WatchModeInotify = "inotify" // Run inotifywait on the remote host
+)
+
+// WatchOptions describes a directory to watch
+type WatchOptions struct {
+	Path         string `json:"path"`
+	Mode         string `json:"mode"`
+	PollInterval int    `json:"poll_interval"` // Milliseconds between listings, 0 = default (2000)
+	Debounce     int    `json:"debounce"`      // Milliseconds of quiet before changes are sent, 0 = default (300)
+}
+
+// DirChange is a single change to an entry of a watched directory
+type DirChange struct {
+	Type  string `json:"type"` // "created", "modified" or "deleted"
+	Path  string `json:"path"`
+	Name  string `json:"name"`
+	IsDir bool   `json:"is_dir"`
+}
+
+// DirWatchEvent is a debounced batch of changes
+type DirWatchEvent struct {
+	WatchID   string      `json:"watch_id"`
+	SessionID string      `json:"session_id"`
+	Path      string      `json:"path"`
+	Changes   []DirChange `json:"changes,omitempty"`
+	Done      bool        `json:"done"`
+	Error     string      `json:"error,omitempty"`
+}
+
+const (
+	defaultWatchInterval = 2 * time.Second
+	minWatchInterval     = 500 * time.Millisecond
+	defaultWatchDebounce = 300 * time.Millisecond
+	// Changes are sent after this long even if the directory never goes quiet
+	maxWatchDelay = 2 * time.Second
+	watchTick     = 100 * time.Millisecond
+)
+
+// WatchDirectory starts watching opts.Path for created, modified and deleted entries.
+// Changes are delivered through eventCb until StopWatch, StopWatches or Close is called;
+// the final event has Done set. Returns watchID.
+func (sc *SFTPClient) WatchDirectory(opts WatchOptions, eventCb func(DirWatchEvent)) (string, error) {
+	opts.Path = normalizePath(opts.Path)
+
+	info, err := sc.GetFileInfo(opts.Path)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir {
+		return "", fmt.Errorf("not a directory: %s", opts.Path)
+	}
+
+	switch opts.Mode {
+	case WatchModeAuto, WatchModePoll, WatchModeInotify:
+	default:
+		return "", fmt.Errorf("unsupported watch mode: %s", opts.Mode)
+	}
+
+	// Choosing the mode and taking the initial listing are network calls, so they are done
+	// before the watch is registered
+	useInotify := opts.Mode == WatchModeInotify
+	if opts.Mode == WatchModeAuto && sc.sshClient != nil {
+		useInotify = sc.sshClient.requireRemoteTool(context.Background(), "inotifywait") == nil
+	}
+	if useInotify && sc.sshClient == nil {
+		return "", fmt.Errorf("inotify watch requires an SSH connection")
+	}
+	var snapshot map[string]watchEntry
+	if !useInotify {
+		if snapshot, err = sc.snapshotDirectory(opts.Path); err != nil {
+			return "", err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sc.watchMu.Lock()
+	if sc.watches == nil {
+		sc.watches = make(map[string]context.CancelFunc)
+	}
+	watchID := fmt.Sprintf("watch_%d_%d", time.Now().UnixNano(), len(sc.watches))
+	sc.watches[watchID] = cancel
+	sc.watchMu.Unlock()
+
+	// Return only once changes are being tracked, so changes after this call are not missed
+	established := make(chan struct{})
+	var establishOnce sync.Once
+	ready := func() { establishOnce.Do(func() { close(established) }) }
+
+	go func() {
+		defer ready()
+		defer func() {
+			sc.watchMu.Lock()
+			delete(sc.watches, watchID)
+			sc.watchMu.Unlock()
+			cancel()
+		}()
+
+		err := sc.runWatch(ctx, opts, snapshot, ready, func(changes []DirChange) {
+			if eventCb != nil {
+				eventCb(DirWatchEvent{WatchID: watchID, Path: opts.Path, Changes: changes})
+			}
+		})
+		if eventCb != nil {
+			event := DirWatchEvent{WatchID: watchID, Path: opts.Path, Done: true}
+			if err != nil && ctx.Err() == nil {
+				event.Error = err.Error()
+			}
+			eventCb(event)
+		}
+	}()
+
+	<-established
+	return watchID, nil
+}
+
+// StopWatch stops a directory watch
+func (sc *SFTPClient) StopWatch(watchID string) error {
+	sc.watchMu.Lock()
+	cancel, exists := sc.watches[watchID]
+	sc.watchMu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("watch not found: %s", watchID)
+	}
+
+	cancel()
+	return nil
+}
+
+// StopWatches stops every directory watch of this client
+func (sc *SFTPClient) StopWatches() {
+	sc.watchMu.Lock()
+	defer sc.watchMu.Unlock()
+
+	for _, cancel := range sc.watches {
+		cancel()
+	}
+}
+
+// runWatch reports changes to opts.Path until ctx is cancelled, with inotifywait when
+// snapshot is nil and otherwise by polling and comparing listings with snapshot.
+// ready is called once changes are being tracked.
+func (sc *SFTPClient) runWatch(ctx context.Context, opts WatchOptions, snapshot map[string]watchEntry, ready func(), flush func([]DirChange)) error {
+	// Changes seen by the watch also make cached listings stale
+	notify := flush
+	flush = func(changes []DirChange) {
+		sc.InvalidateListings(opts.Path)
+		notify(changes)
+	}
+
+	debounce := time.Duration(opts.Debounce) * time.Millisecond
+	if debounce <= 0 {
+		debounce = defaultWatchDebounce
+	}
+	debouncer := &watchDebouncer{quiet: debounce}
+
+	var poll func() error
+	var done chan error
+	if snapshot == nil {
+		done = make(chan error, 1)
+		go func() {
+			done <- sc.watchInotify(ctx, opts.Path, debouncer, ready)
+		}()
+	} else {
+		// Changes are measured against snapshot, which was taken before the watch started
+		ready()
+
+		interval := time.Duration(opts.PollInterval) * time.Millisecond
+		if interval <= 0 {
+			interval = defaultWatchInterval
+		}
+		if interval < minWatchInterval {
+			interval = minWatchInterval
+		}
+
+		lastPoll := time.Now()
+		poll = func() error {
+			if time.Since(lastPoll) < interval {
+				return nil
+			}
+			lastPoll = time.Now()
+
+			current, err := sc.snapshotDirectory(opts.Path)
+			if err != nil {
+				return err
+			}
+			for _, change := range diffSnapshots(opts.Path, snapshot, current) {
+				debouncer.add(change)
+			}
+			snapshot = current
+			return nil
+		}
+	}
+
+	ticker := time.NewTicker(watchTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-done:
+			if changes := debouncer.take(true); len(changes) > 0 {
+				flush(changes)
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		case <-ticker.C:
+		}
+
+		if poll != nil {
+			if err := poll(); err != nil {
+				return err
+			}
+		}
+		if changes := debouncer.take(false); len(changes) > 0 {
+			flush(changes)
+		}
+	}
+}
+
+// watchInotify runs inotifywait on dirPath and feeds its events to the debouncer,
+// calling ready once inotifywait reports its watch is in place
+func (sc *SFTPClient) watchInotify(ctx context.Context, dirPath string, debouncer *watchDebouncer, ready func()) error {
+	var selfGone bool
+	stdout := &tailLineWriter{fn: func(line string) {
+		events, name, ok := strings.Cut(line, " ")
+		if !ok {
+			return
+		}
+		if strings.Contains(events, "_SELF") {
+			selfGone = true
+			return
+		}
+
+		change := DirChange{
+			Path:  path.Join(dirPath, name),
+			Name:  name,
+			IsDir: strings.Contains(events, "ISDIR"),
+		}
+		switch {
+		case strings.Contains(events, "CREATE") || strings.Contains(events, "MOVED_TO"):
+			change.Type = DirChangeCreated
+		case strings.Contains(events, "DELETE") || strings.Contains(events, "MOVED_FROM"):
+			change.Type = DirChangeDeleted
+		default:
+			change.Type = DirChangeModified
+		}
+		debouncer.add(change)
+	}}
+
+	// Without -q inotifywait announces on stderr when the watch is in place
+	stderr := &tailLineWriter{fn: func(line string) {
+		if strings.HasPrefix(line, "Watches established") {
+			ready()
+		}
+	}}
+
+	// close_write instead of modify keeps a large upload from producing one event per write
+	cmd := fmt.Sprintf("inotifywait -m -e create,delete,close_write,attrib,move,delete_self,move_self --format '%%e %%f' %s",
+		shellQuote(dirPath))
+	err := sc.sshClient.RunCommandStream(ctx, cmd, nil, stdout, stderr)
+	if selfGone {
+		return fmt.Errorf("watched directory was removed: %s", dirPath)
+	}
+	if err != nil {
+		return fmt.Errorf("inotifywait failed: %w", err)
+	}
+	return fmt.Errorf("inotifywait exited")
+}
+
+// watchEntry is the state of a directory entry used to detect modifications
+type watchEntry struct {
+	size    int64
+	modTime time.Time
+	mode    os.FileMode
+}
+
+func (sc *SFTPClient) snapshotDirectory(dirPath string) (map[string]watchEntry, error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	entries, err := sc.client.ReadDir(dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	snapshot := make(map[string]watchEntry, len(entries))
+	for _, entry := range entries {
+		snapshot[entry.Name()] = watchEntry{
+			size:    entry.Size(),
+			modTime: entry.ModTime(),
+			mode:    entry.Mode(),
+		}
+	}
+	return snapshot, nil
+}
+
+// diffSnapshots compares two listings of dirPath
+func diffSnapshots(dirPath string, before, after map[string]watchEntry) []DirChange {
+	var changes []DirChange
+
+	for name, entry := range after {
+		change := DirChange{Path: path.Join(dirPath, name), Name: name, IsDir: entry.mode.IsDir()}
+		old, existed := before[name]
+		switch {
+		case !existed:
+			change.Type = DirChangeCreated
+		case old != entry:
+			change.Type = DirChangeModified
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	for name, entry := range before {
+		if _, exists := after[name]; !exists {
+			changes = append(changes, DirChange{
+				Type:  DirChangeDeleted,
+				Path:  path.Join(dirPath, name),
+				Name:  name,
+				IsDir: entry.mode.IsDir(),
+			})
+		}
+	}
+
+	return changes
+}
+
+// watchDebouncer merges changes per path and releases them once the directory
+// has been quiet for a while
+type watchDebouncer struct {
+	quiet time.Duration
+
+	mu      sync.Mutex
+	pending map[string]DirChange
+	order   []string
+	first   time.Time
+	last    time.Time
+}
+
+func (wd *watchDebouncer) add(change DirChange) {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+
+	now := time.Now()
+	if len(wd.pending) == 0 {
+		wd.first = now
+	}
+	wd.last = now
+
+	if wd.pending == nil {
+		wd.pending = make(map[string]DirChange)
+	}
+
+	old, exists := wd.pending[change.Path]
+	if !exists {
+		wd.pending[change.Path] = change
+		wd.order = append(wd.order, change.Path)
+		return
+	}
+
+	switch {
+	case old.Type == DirChangeCreated && change.Type == DirChangeDeleted:
+		// Short-lived entries are not reported at all
+		delete(wd.pending, change.Path)
+	case old.Type == DirChangeCreated && change.Type == DirChangeModified:
+		// Still a new entry
+	case old.Type == DirChangeDeleted && change.Type == DirChangeCreated:
+		change.Type = DirChangeModified
+		wd.pending[change.Path] = change
+	default:
+		wd.pending[change.Path] = change
+	}
+}
+
+// take returns pending changes in arrival order once they are due, or always when force is set
+func (wd *watchDebouncer) take(force bool) []DirChange {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+
+	if len(wd.pending) == 0 {
+		wd.order = nil
+		return nil
+	}
+	now := time.Now()
+	if !force && now.Sub(wd.last) < wd.quiet && now.Sub(wd.first) < maxWatchDelay {
+		return nil
+	}
+
+	changes := make([]DirChange, 0, len(wd.pending))
+	for _, p := range wd.order {
+		if change, ok := wd.pending[p]; ok {
+			changes = append(changes, change)
+			delete(wd.pending, p)
+		}
+	}
+	wd.order = nil
+	return changes
+}
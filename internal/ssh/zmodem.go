@@ -0,0 +1,888 @@
+package ssh
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ZmodemHandler supplies the user interaction for ZMODEM transfers started with
+// rz/sz in a terminal
+type ZmodemHandler struct {
+	// SaveFile is asked where to store a file offered by a remote sz; "" skips the file
+	SaveFile func(sessionID, filename string, size int64) (string, error)
+	// SelectFiles is asked which local files to send to a remote rz; none ends the transfer
+	SelectFiles func(sessionID string) ([]string, error)
+	// Progress receives updates for every file, which are also recorded in the TransferManager
+	Progress func(progress TransferProgress)
+}
+
+// ZMODEM framing bytes
+const (
+	zpad   = '*'
+	zdle   = 0x18
+	zbin   = 'A'
+	zhex   = 'B'
+	zbin32 = 'C'
+	zrub0  = 'l'
+	zrub1  = 'm'
+	zcrce  = 'h' // End of frame, header follows
+	zcrcg  = 'i' // Frame continues, no response expected
+	zcrcq  = 'j' // Frame continues, ZACK expected
+	zcrcw  = 'k' // End of frame, ZACK expected
+	xon    = 0x11
+)
+
+// ZMODEM frame types
+const (
+	zrqinit = 0
+	zrinit  = 1
+	zsinit  = 2
+	zack    = 3
+	zfile   = 4
+	zskip   = 5
+	znak    = 6
+	zabort  = 7
+	zfin    = 8
+	zrpos   = 9
+	zdata   = 10
+	zeof    = 11
+	zferr   = 12
+	zcrc    = 13
+	zcan    = 16
+)
+
+// ZRINIT capability flags (ZF0)
+const (
+	zCanFullDuplex = 0x01
+	zCanOverlapIO  = 0x02
+	zCanCRC32      = 0x20
+)
+
+const (
+	zmodemTimeout      = 30 * time.Second
+	zmodemBlockSize    = 1024
+	zmodemMaxBlockSize = 8192
+	zmodemMaxRetries   = 10
+)
+
+var (
+	errZmodemCancelled = errors.New("zmodem transfer cancelled by peer")
+	errZmodemCRC       = errors.New("zmodem checksum mismatch")
+)
+
+// zmodemAbort is sent to make the remote rz/sz give up
+var zmodemAbort = []byte{zdle, zdle, zdle, zdle, zdle, zdle, zdle, zdle, 8, 8, 8, 8, 8, 8, 8, 8}
+
+// zheader is a ZMODEM frame header; data holds either a little-endian file
+// position or the flags ZF3..ZF0
+type zheader struct {
+	typ   byte
+	data  [4]byte
+	crc32 bool // Received as ZBIN32, so data subpackets use CRC-32
+}
+
+func posHeader(typ byte, pos int64) zheader {
+	return zheader{typ: typ, data: [4]byte{byte(pos), byte(pos >> 8), byte(pos >> 16), byte(pos >> 24)}}
+}
+
+func (h zheader) pos() int64 {
+	return int64(h.data[0]) | int64(h.data[1])<<8 | int64(h.data[2])<<16 | int64(h.data[3])<<24
+}
+
+// zmodemSession runs one rz or sz exchange over a terminal stream
+type zmodemSession struct {
+	*terminalStream
+	sending bool // The remote runs rz and this side sends files
+	handler *ZmodemHandler
+}
+
+func newZmodemSession(sessionID string, sending bool, handler *ZmodemHandler, transfers *TransferManager, out io.Writer) *zmodemSession {
+	zs := &zmodemSession{
+		terminalStream: newTerminalStream(sessionID, transfers, handler.Progress, out, zmodemTimeout),
+		sending:        sending,
+		handler:        handler,
+	}
+	zs.start(func() error {
+		var err error
+		if zs.sending {
+			err = zs.send()
+		} else {
+			err = zs.receive()
+		}
+		if err != nil && !errors.Is(err, errZmodemCancelled) {
+			// Make the remote side give up instead of waiting for its own timeout
+			zs.out.Write(zmodemAbort)
+		}
+		return err
+	})
+	return zs
+}
+
+// headerPending reports whether the peer started sending a header, discarding
+// line noise such as XON received while streaming
+func (zs *zmodemSession) headerPending() bool {
+	for {
+		select {
+		case data := <-zs.in:
+			zs.pending = append(zs.pending, data...)
+			continue
+		default:
+		}
+		break
+	}
+
+	if idx := bytes.IndexByte(zs.pending, zpad); idx >= 0 {
+		zs.pending = zs.pending[idx:]
+		return true
+	}
+	zs.pending = zs.pending[:0]
+	return false
+}
+
+// receive handles a remote sz: files offered by the peer are saved where the handler says
+func (zs *zmodemSession) receive() error {
+	var file *os.File
+	var filename string
+	var offset int64
+	var transfer *TransferContext
+	var progress *bulkProgress
+	var stopCancel func() bool
+
+	closeFile := func() {
+		if file != nil {
+			file.Close()
+			file = nil
+		}
+		if stopCancel != nil {
+			stopCancel()
+			stopCancel = nil
+		}
+	}
+	defer closeFile()
+
+	zrinitHeader := zheader{typ: zrinit, data: [4]byte{0, 0, 0, zCanFullDuplex | zCanOverlapIO | zCanCRC32}}
+
+	for {
+		h, err := zs.readHeader()
+		if errors.Is(err, errZmodemCRC) {
+			zs.sendHexHeader(zheader{typ: znak})
+			continue
+		}
+		if err != nil {
+			zs.failTransfer(transfer, err)
+			return err
+		}
+
+		switch h.typ {
+		case zrqinit:
+			if err := zs.sendHexHeader(zrinitHeader); err != nil {
+				return err
+			}
+
+		case zsinit:
+			// The attention string is not needed for full-duplex links
+			if _, _, err := zs.readData(h.crc32); err != nil {
+				zs.sendHexHeader(zheader{typ: znak})
+				continue
+			}
+			zs.sendHexHeader(zheader{typ: zack})
+
+		case zfile:
+			info, _, err := zs.readData(h.crc32)
+			if err != nil {
+				zs.sendHexHeader(zheader{typ: znak})
+				continue
+			}
+			name, size := parseZfileInfo(info)
+
+			// A repeated offer of the file being received means the ZRPOS was lost
+			if file != nil && name == filename {
+				zs.sendHexHeader(posHeader(zrpos, offset))
+				continue
+			}
+			closeFile()
+
+			localPath := ""
+			if name != "" && zs.handler.SaveFile != nil {
+				localPath, err = zs.handler.SaveFile(zs.sessionID, name, size)
+			}
+			if err != nil || localPath == "" {
+				zs.sendHexHeader(zheader{typ: zskip})
+				continue
+			}
+
+			file, err = os.Create(localPath)
+			if err != nil {
+				zs.sendHexHeader(zheader{typ: zskip})
+				continue
+			}
+			filename = name
+			offset = 0
+
+			transfer, progress, stopCancel = zs.startTransfer("download", localPath, name, size)
+			if err := zs.sendHexHeader(posHeader(zrpos, 0)); err != nil {
+				return err
+			}
+
+		case zdata:
+			if file == nil {
+				zs.sendHexHeader(zrinitHeader)
+				continue
+			}
+			if h.pos() != offset {
+				zs.sendHexHeader(posHeader(zrpos, offset))
+				continue
+			}
+
+			err := zs.receiveData(file, &offset, h.crc32, progress)
+			if errors.Is(err, errZmodemCRC) {
+				zs.sendHexHeader(posHeader(zrpos, offset))
+				continue
+			}
+			if err != nil {
+				zs.failTransfer(transfer, err)
+				return err
+			}
+
+		case zeof:
+			if file == nil || h.pos() != offset {
+				continue
+			}
+			closeFile()
+			progress.complete()
+			transfer = nil
+			if err := zs.sendHexHeader(zrinitHeader); err != nil {
+				return err
+			}
+
+		case zfin:
+			zs.sendHexHeader(zheader{typ: zfin})
+			zs.readOverAndOut()
+			return nil
+
+		case zcan, zabort, zferr:
+			zs.failTransfer(transfer, errZmodemCancelled)
+			return errZmodemCancelled
+		}
+	}
+}
+
+// receiveData writes data subpackets of a ZDATA frame to file
+func (zs *zmodemSession) receiveData(file *os.File, offset *int64, useCRC32 bool, progress *bulkProgress) error {
+	for {
+		data, end, err := zs.readData(useCRC32)
+		if err != nil {
+			return err
+		}
+
+		if _, err := file.Write(data); err != nil {
+			return fmt.Errorf("failed to write local file: %w", err)
+		}
+		*offset += int64(len(data))
+		progress.addBytes(int64(len(data)))
+
+		switch end {
+		case zcrcw:
+			return zs.sendHexHeader(posHeader(zack, *offset))
+		case zcrcq:
+			if err := zs.sendHexHeader(posHeader(zack, *offset)); err != nil {
+				return err
+			}
+		case zcrce:
+			return nil
+		}
+	}
+}
+
+// readOverAndOut consumes the "OO" that ends a session, leaving anything else for the terminal
+func (zs *zmodemSession) readOverAndOut() {
+	for i := 0; i < 2; i++ {
+		if !zs.hasInput() {
+			// Give the peer a moment to send it
+			time.Sleep(100 * time.Millisecond)
+			if !zs.hasInput() {
+				return
+			}
+		}
+		b, err := zs.readByte()
+		if err != nil {
+			return
+		}
+		if b != 'O' {
+			zs.unreadByte(b)
+			return
+		}
+	}
+}
+
+// send handles a remote rz: the handler picks the local files to send
+func (zs *zmodemSession) send() error {
+	h, err := zs.readHeader()
+	if err != nil {
+		return err
+	}
+	if h.typ != zrinit {
+		return fmt.Errorf("unexpected zmodem frame %d", h.typ)
+	}
+	useCRC32 := h.data[3]&zCanCRC32 != 0
+
+	var paths []string
+	if zs.handler.SelectFiles != nil {
+		paths, err = zs.handler.SelectFiles(zs.sessionID)
+		if err != nil {
+			return err
+		}
+	}
+
+	var totalBytes int64
+	files := make([]os.FileInfo, len(paths))
+	for i, localPath := range paths {
+		if files[i], err = os.Stat(localPath); err == nil && files[i].Mode().IsRegular() {
+			totalBytes += files[i].Size()
+		}
+	}
+
+	for i, localPath := range paths {
+		if files[i] == nil || !files[i].Mode().IsRegular() {
+			continue
+		}
+		if err := zs.sendFile(localPath, files[i], useCRC32, len(paths)-i, totalBytes); err != nil {
+			return err
+		}
+		totalBytes -= files[i].Size()
+	}
+
+	return zs.finishSending()
+}
+
+// sendFile offers one file and streams it from the position the receiver asks for
+func (zs *zmodemSession) sendFile(localPath string, info os.FileInfo, useCRC32 bool, filesLeft int, bytesLeft int64) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open local file: %w", err)
+	}
+	defer file.Close()
+
+	name := filepath.Base(localPath)
+	size := info.Size()
+	fileInfo := fmt.Sprintf("%s\x00%d %o %o 0 %d %d\x00", name, size, info.ModTime().Unix(), 0100000|info.Mode().Perm(), filesLeft, bytesLeft)
+
+	offerFile := func() error {
+		if err := zs.sendBinHeader(zheader{typ: zfile}, useCRC32); err != nil {
+			return err
+		}
+		return zs.sendData([]byte(fileInfo), zcrcw, useCRC32)
+	}
+	if err := offerFile(); err != nil {
+		return err
+	}
+
+	var pos int64
+	for retries := 0; ; retries++ {
+		if retries > zmodemMaxRetries {
+			return fmt.Errorf("receiver did not accept %s", name)
+		}
+		h, err := zs.readHeader()
+		if errors.Is(err, errZmodemCRC) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		switch h.typ {
+		case zrpos:
+			pos = h.pos()
+		case zskip:
+			return nil
+		case zrinit, znak:
+			if err := offerFile(); err != nil {
+				return err
+			}
+			continue
+		case zcrc:
+			// Resuming receivers compare a checksum of the whole file
+			crc := crc32.NewIEEE()
+			io.Copy(crc, file)
+			if err := zs.sendHexHeader(posHeader(zcrc, int64(crc.Sum32()))); err != nil {
+				return err
+			}
+			continue
+		case zfin, zabort, zcan, zferr:
+			return errZmodemCancelled
+		default:
+			continue
+		}
+		break
+	}
+
+	transfer, progress, stopCancel := zs.startTransfer("upload", localPath, name, size)
+	defer stopCancel()
+	progress.addBytes(pos)
+
+	buffer := make([]byte, zmodemBlockSize)
+	for {
+		if err := zs.sendBinHeader(posHeader(zdata, pos), useCRC32); err != nil {
+			zs.failTransfer(transfer, err)
+			return err
+		}
+
+		interrupted := false
+		for !interrupted {
+			n, readErr := file.ReadAt(buffer, pos)
+			if readErr != nil && readErr != io.EOF {
+				zs.failTransfer(transfer, readErr)
+				return fmt.Errorf("failed to read local file: %w", readErr)
+			}
+
+			end := byte(zcrcg)
+			if pos+int64(n) >= size {
+				end = zcrce
+			}
+			if err := zs.sendData(buffer[:n], end, useCRC32); err != nil {
+				zs.failTransfer(transfer, err)
+				return err
+			}
+			pos += int64(n)
+			progress.addBytes(int64(n))
+
+			if end == zcrce {
+				break
+			}
+
+			// The receiver only interrupts a stream to report an error position
+			if zs.headerPending() {
+				h, err := zs.readHeader()
+				if err == nil && h.typ == zrpos {
+					progress.addBytes(h.pos() - pos)
+					pos = h.pos()
+					interrupted = true
+				} else if err == nil && (h.typ == zabort || h.typ == zcan || h.typ == zferr) {
+					zs.failTransfer(transfer, errZmodemCancelled)
+					return errZmodemCancelled
+				}
+			}
+		}
+		if interrupted {
+			continue
+		}
+
+		if err := zs.sendBinHeader(posHeader(zeof, pos), useCRC32); err != nil {
+			zs.failTransfer(transfer, err)
+			return err
+		}
+
+		h, err := zs.waitHeader(zrinit, zrpos, zskip)
+		if err != nil {
+			zs.failTransfer(transfer, err)
+			return err
+		}
+		if h.typ == zrpos {
+			progress.addBytes(h.pos() - pos)
+			pos = h.pos()
+			continue
+		}
+
+		progress.complete()
+		return nil
+	}
+}
+
+// finishSending ends the session once all files were sent
+func (zs *zmodemSession) finishSending() error {
+	if err := zs.sendHexHeader(zheader{typ: zfin}); err != nil {
+		return err
+	}
+	if _, err := zs.waitHeader(zfin); err != nil {
+		return err
+	}
+	_, err := zs.out.Write([]byte("OO"))
+	return err
+}
+
+// waitHeader reads headers until one of the given types arrives
+func (zs *zmodemSession) waitHeader(types ...byte) (zheader, error) {
+	for retries := 0; retries <= zmodemMaxRetries; retries++ {
+		h, err := zs.readHeader()
+		if errors.Is(err, errZmodemCRC) {
+			continue
+		}
+		if err != nil {
+			return h, err
+		}
+		if bytes.IndexByte(types, h.typ) >= 0 {
+			return h, nil
+		}
+		if h.typ == zabort || h.typ == zcan || h.typ == zferr {
+			return h, errZmodemCancelled
+		}
+	}
+	return zheader{}, fmt.Errorf("zmodem peer did not respond")
+}
+
+// parseZfileInfo extracts the file name and size from a ZFILE data subpacket
+func parseZfileInfo(data []byte) (string, int64) {
+	name, rest, _ := bytes.Cut(data, []byte{0})
+
+	// Only the base name is used so the peer cannot choose the directory
+	base := path.Base(strings.ReplaceAll(string(name), "\\", "/"))
+	if base == "." || base == "/" || base == ".." {
+		base = ""
+	}
+
+	var size int64
+	if fields := strings.Fields(string(bytes.TrimRight(rest, "\x00"))); len(fields) > 0 {
+		size, _ = strconv.ParseInt(fields[0], 10, 64)
+	}
+	return base, size
+}
+
+// readHeader skips line noise until the next frame header and decodes it
+func (zs *zmodemSession) readHeader() (zheader, error) {
+	cans := 0
+	for {
+		b, err := zs.readByte()
+		if err != nil {
+			return zheader{}, err
+		}
+
+		if b == zdle {
+			if cans++; cans >= 5 {
+				return zheader{}, errZmodemCancelled
+			}
+			continue
+		}
+		cans = 0
+		if b != zpad {
+			continue
+		}
+
+		for b == zpad {
+			if b, err = zs.readByte(); err != nil {
+				return zheader{}, err
+			}
+		}
+		if b != zdle {
+			continue
+		}
+
+		format, err := zs.readByte()
+		if err != nil {
+			return zheader{}, err
+		}
+		switch format {
+		case zhex:
+			return zs.readHexHeader()
+		case zbin:
+			return zs.readBinHeader(false)
+		case zbin32:
+			return zs.readBinHeader(true)
+		}
+	}
+}
+
+func (zs *zmodemSession) readHexHeader() (zheader, error) {
+	encoded := make([]byte, 14)
+	for i := range encoded {
+		b, err := zs.readByte()
+		if err != nil {
+			return zheader{}, err
+		}
+		encoded[i] = b
+	}
+
+	raw := make([]byte, 7)
+	if _, err := hex.Decode(raw, bytes.ToLower(encoded)); err != nil {
+		return zheader{}, errZmodemCRC
+	}
+
+	// The header ends with CR LF, with or without the high bit set
+	for i := 0; i < 2; i++ {
+		b, err := zs.readByte()
+		if err != nil {
+			break
+		}
+		if b&0x7f != '\r' && b&0x7f != '\n' {
+			zs.unreadByte(b)
+			break
+		}
+	}
+
+	if crc16(raw[:5]) != uint16(raw[5])<<8|uint16(raw[6]) {
+		return zheader{}, errZmodemCRC
+	}
+
+	h := zheader{typ: raw[0]}
+	copy(h.data[:], raw[1:5])
+	return h, nil
+}
+
+func (zs *zmodemSession) readBinHeader(useCRC32 bool) (zheader, error) {
+	length := 7
+	if useCRC32 {
+		length = 9
+	}
+
+	raw := make([]byte, length)
+	for i := range raw {
+		b, end, err := zs.readEscaped()
+		if err != nil {
+			return zheader{}, err
+		}
+		if end {
+			return zheader{}, errZmodemCRC
+		}
+		raw[i] = b
+	}
+
+	if useCRC32 {
+		if crc32.ChecksumIEEE(raw[:5]) != uint32(raw[5])|uint32(raw[6])<<8|uint32(raw[7])<<16|uint32(raw[8])<<24 {
+			return zheader{}, errZmodemCRC
+		}
+	} else if crc16(raw[:5]) != uint16(raw[5])<<8|uint16(raw[6]) {
+		return zheader{}, errZmodemCRC
+	}
+
+	h := zheader{typ: raw[0], crc32: useCRC32}
+	copy(h.data[:], raw[1:5])
+	return h, nil
+}
+
+// readData reads a data subpacket and returns its payload and frame end type
+func (zs *zmodemSession) readData(useCRC32 bool) ([]byte, byte, error) {
+	var data []byte
+	for {
+		b, end, err := zs.readEscaped()
+		if err != nil {
+			return nil, 0, err
+		}
+		if end {
+			crcLength := 2
+			if useCRC32 {
+				crcLength = 4
+			}
+			crc := make([]byte, crcLength)
+			for i := range crc {
+				if crc[i], _, err = zs.readEscaped(); err != nil {
+					return nil, 0, err
+				}
+			}
+
+			if useCRC32 {
+				sum := crc32.Update(crc32.ChecksumIEEE(data), crc32.IEEETable, []byte{b})
+				if sum != uint32(crc[0])|uint32(crc[1])<<8|uint32(crc[2])<<16|uint32(crc[3])<<24 {
+					return nil, 0, errZmodemCRC
+				}
+			} else if crc16Update(crc16(data), b) != uint16(crc[0])<<8|uint16(crc[1]) {
+				return nil, 0, errZmodemCRC
+			}
+			return data, b, nil
+		}
+
+		if len(data) >= zmodemMaxBlockSize {
+			return nil, 0, errZmodemCRC
+		}
+		data = append(data, b)
+	}
+}
+
+// readEscaped reads one ZDLE-decoded byte; end is set when the byte terminates a subpacket
+func (zs *zmodemSession) readEscaped() (byte, bool, error) {
+	for {
+		b, err := zs.readByte()
+		if err != nil {
+			return 0, false, err
+		}
+
+		switch b {
+		case xon, xon | 0x80, 0x13, 0x93:
+			// Flow control characters are not part of the data
+			continue
+		case zdle:
+		default:
+			return b, false, nil
+		}
+
+		cans := 1
+		for {
+			if b, err = zs.readByte(); err != nil {
+				return 0, false, err
+			}
+			if b == xon || b == xon|0x80 || b == 0x13 || b == 0x93 {
+				continue
+			}
+			if b != zdle {
+				break
+			}
+			if cans++; cans >= 5 {
+				return 0, false, errZmodemCancelled
+			}
+		}
+
+		switch b {
+		case zcrce, zcrcg, zcrcq, zcrcw:
+			return b, true, nil
+		case zrub0:
+			return 0x7f, false, nil
+		case zrub1:
+			return 0xff, false, nil
+		}
+		if b&0x60 == 0x40 {
+			return b ^ 0x40, false, nil
+		}
+		return 0, false, errZmodemCRC
+	}
+}
+
+func (zs *zmodemSession) sendHexHeader(h zheader) error {
+	raw := append([]byte{h.typ}, h.data[:]...)
+	crc := crc16(raw)
+	raw = append(raw, byte(crc>>8), byte(crc))
+
+	frame := []byte{zpad, zpad, zdle, zhex}
+	frame = append(frame, hex.EncodeToString(raw)...)
+	frame = append(frame, '\r', '\n'|0x80)
+	if h.typ != zfin && h.typ != zack {
+		frame = append(frame, xon)
+	}
+
+	_, err := zs.out.Write(frame)
+	return err
+}
+
+func (zs *zmodemSession) sendBinHeader(h zheader, useCRC32 bool) error {
+	raw := append([]byte{h.typ}, h.data[:]...)
+
+	frame := []byte{zpad, zdle, zbin}
+	if useCRC32 {
+		frame[2] = zbin32
+		crc := crc32.ChecksumIEEE(raw)
+		raw = append(raw, byte(crc), byte(crc>>8), byte(crc>>16), byte(crc>>24))
+	} else {
+		crc := crc16(raw)
+		raw = append(raw, byte(crc>>8), byte(crc))
+	}
+
+	_, err := zs.out.Write(zdleEscape(frame, raw))
+	return err
+}
+
+func (zs *zmodemSession) sendData(data []byte, end byte, useCRC32 bool) error {
+	if err := zs.ctx.Err(); err != nil {
+		return err
+	}
+
+	frame := zdleEscape(make([]byte, 0, len(data)+len(data)/8+16), data)
+	frame = append(frame, zdle, end)
+
+	if useCRC32 {
+		crc := crc32.Update(crc32.ChecksumIEEE(data), crc32.IEEETable, []byte{end})
+		frame = zdleEscape(frame, []byte{byte(crc), byte(crc >> 8), byte(crc >> 16), byte(crc >> 24)})
+	} else {
+		crc := crc16Update(crc16(data), end)
+		frame = zdleEscape(frame, []byte{byte(crc >> 8), byte(crc)})
+	}
+	if end == zcrcw {
+		frame = append(frame, xon)
+	}
+
+	_, err := zs.out.Write(frame)
+	return err
+}
+
+// zdleEscape appends data to dst, escaping ZDLE, flow control characters and CR
+func zdleEscape(dst, data []byte) []byte {
+	for _, b := range data {
+		switch b {
+		case zdle, 0x10, 0x90, xon, xon | 0x80, 0x13, 0x93, '\r', '\r' | 0x80:
+			dst = append(dst, zdle, b^0x40)
+		default:
+			dst = append(dst, b)
+		}
+	}
+	return dst
+}
+
+// crc16 computes the CRC-16/XMODEM checksum used by ZMODEM
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc = crc16Update(crc, b)
+	}
+	return crc
+}
+
+func crc16Update(crc uint16, b byte) uint16 {
+	crc ^= uint16(b) << 8
+	for i := 0; i < 8; i++ {
+		if crc&0x8000 != 0 {
+			crc = crc<<1 ^ 0x1021
+		} else {
+			crc <<= 1
+		}
+	}
+	return crc
+}
+
+// detectZmodem looks for the hex header that rz or sz prints when it starts.
+// It returns the offset of the header and whether this side sends (remote rz) or
+// receives (remote sz). When data ends inside a possible header, partial is set
+// and the caller should hold back data[offset:] until more output arrives.
+func detectZmodem(data []byte) (offset int, sending bool, found bool, partial bool) {
+	const hexLength = 14 // Type, 4 data bytes and CRC
+
+	for start := 0; start < len(data); {
+		idx := bytes.IndexByte(data[start:], zdle)
+		if idx == -1 {
+			return 0, false, false, false
+		}
+		idx += start
+		start = idx + 1
+
+		// Only the ZDLE is required; a ZPAD split off into earlier output is harmless
+		rest := data[idx:]
+		prefix := []byte{zdle, zhex, '0'}
+		if len(rest) < len(prefix)+hexLength-1 {
+			if bytes.HasPrefix(rest, prefix[:min(int64(len(rest)), int64(len(prefix)))]) {
+				return headerStart(data, idx), false, false, true
+			}
+			continue
+		}
+		if !bytes.HasPrefix(rest, prefix) {
+			continue
+		}
+
+		raw := make([]byte, 7)
+		if _, err := hex.Decode(raw, bytes.ToLower(rest[2:2+hexLength])); err != nil {
+			continue
+		}
+		if crc16(raw[:5]) != uint16(raw[5])<<8|uint16(raw[6]) {
+			continue
+		}
+		if raw[0] != zrqinit && raw[0] != zrinit {
+			continue
+		}
+
+		return headerStart(data, idx), raw[0] == zrinit, true, false
+	}
+
+	return 0, false, false, false
+}
+
+// headerStart includes the ZPADs before a header's ZDLE so they do not reach the terminal
+func headerStart(data []byte, idx int) int {
+	for idx > 0 && data[idx-1] == zpad {
+		idx--
+	}
+	return idx
+}
@@ -0,0 +1,71 @@
+package ssh
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCRC16(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want uint16
+	}{
+		{nil, 0x0000},
+		{[]byte("123456789"), 0x31c3}, // CRC-16/XMODEM check value
+		{[]byte{zrqinit, 0, 0, 0, 0}, 0x0000},
+		{[]byte{zrinit, 0, 0, 0, 0x23}, 0xbe50}, // ZRINIT as sent by lrzsz rz
+	}
+
+	for _, tt := range tests {
+		if got := crc16(tt.data); got != tt.want {
+			t.Errorf("crc16(%x) = %04x, want %04x", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestZdleEscape(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want []byte
+	}{
+		{[]byte("abc"), []byte("abc")},
+		{[]byte{zdle}, []byte{zdle, 0x58}},
+		{[]byte{0x10, 0x90}, []byte{zdle, 0x50, zdle, 0xd0}},
+		{[]byte{xon, 0x13}, []byte{zdle, 0x51, zdle, 0x53}},
+		{[]byte{'\r', '\r' | 0x80}, []byte{zdle, 0x4d, zdle, 0xcd}},
+		{[]byte{'a', zdle, 'b'}, []byte{'a', zdle, 0x58, 'b'}},
+	}
+
+	for _, tt := range tests {
+		if got := zdleEscape(nil, tt.data); !bytes.Equal(got, tt.want) {
+			t.Errorf("zdleEscape(%x) = %x, want %x", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestDetectZmodem(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		offset  int
+		sending bool
+		found   bool
+		partial bool
+	}{
+		{"sz starts", "rz\r**\x18B00000000000000\r\x8a\x11", 3, false, true, false},
+		{"rz starts", "rz waiting to receive.**\x18B0100000023be50\r\x8a\x11", 22, true, true, false},
+		{"header split", "ls\r\n**\x18B01", 4, false, false, true},
+		{"ZDLE at the end", "abc\x18", 3, false, false, true},
+		{"bad checksum", "**\x18B00000000000001\r\n", 0, false, false, false},
+		{"other frame type", "**\x18B0300000000eed2\r\n", 0, false, false, false}, // A valid ZACK
+		{"plain output", "hello \x18x world", 0, false, false, false},
+	}
+
+	for _, tt := range tests {
+		offset, sending, found, partial := detectZmodem([]byte(tt.data))
+		if found != tt.found || partial != tt.partial || (found || partial) && (offset != tt.offset || sending != tt.sending) {
+			t.Errorf("%s: detectZmodem = %d, %t, %t, %t, want %d, %t, %t, %t", tt.name,
+				offset, sending, found, partial, tt.offset, tt.sending, tt.found, tt.partial)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"AHaSSHTools/internal/ssh"
+)
+
+const (
+	// metricsStep is the resolution metrics are written at
+	metricsStep = 60
+
+	// metricsCoarseStep is the resolution days older than metricsCoarseAfter are kept at
+	metricsCoarseStep  = 15 * 60
+	metricsCoarseAfter = 2 * 24 * time.Hour
+
+	metricsDayLayout    = "2006-01-02"
+	metricsFileSuffix   = ".jsonl"
+	metricsCoarseSuffix = ".15m.jsonl"
+)
+
+// MetricsStore persists metrics history on disk, as JSON lines in one file per host and
+// UTC day. Snapshots are averaged to a minute as they are written, and days older than
+// two days are rewritten at a quarter of an hour, so weeks of history stay small.
+type MetricsStore struct {
+	mu          sync.Mutex
+	dir         string
+	retention   time.Duration                 // 0 = keep forever
+	pending     map[string][]ssh.MetricsPoint // series -> points of the minute being written
+	lastCompact string                        // Day the store was last compacted
+}
+
+// NewMetricsStore creates a metrics store below the application's config directory
+func NewMetricsStore() *MetricsStore {
+	homeDir, _ := os.UserHomeDir()
+	return &MetricsStore{
+		dir:     filepath.Join(homeDir, ".ahasshtools", "metrics"),
+		pending: make(map[string][]ssh.MetricsPoint),
+	}
+}
+
+// SetRetention sets how long history is kept; 0 keeps it forever
+func (s *MetricsStore) SetRetention(retention time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.retention = retention
+	s.lastCompact = ""
+}
+
+// Add records a point of a series, such as "user@host:22". Points are buffered until
+// their minute is over.
+func (s *MetricsStore) Add(series string, point ssh.MetricsPoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending := s.pending[series]
+	if len(pending) > 0 && pending[0].Timestamp/metricsStep != point.Timestamp/metricsStep {
+		if err := s.flushSeries(series); err != nil {
+			return err
+		}
+	}
+	s.pending[series] = append(s.pending[series], point)
+
+	if today := time.Now().UTC().Format(metricsDayLayout); s.lastCompact != today {
+		s.lastCompact = today
+		if err := s.compact(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush writes out all buffered points
+func (s *MetricsStore) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for series := range s.pending {
+		if err := s.flushSeries(series); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flushSeries appends the buffered minute of a series to its day file
+func (s *MetricsStore) flushSeries(series string) error {
+	points := ssh.DownsamplePoints(s.pending[series], metricsStep)
+	delete(s.pending, series)
+	if len(points) == 0 {
+		return nil
+	}
+
+	dir := s.seriesDir(series)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create metrics directory: %w", err)
+	}
+
+	for _, point := range points {
+		day := time.Unix(point.Timestamp, 0).UTC().Format(metricsDayLayout)
+		if err := appendMetricsPoints(filepath.Join(dir, day+metricsFileSuffix), []ssh.MetricsPoint{point}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Query returns the points of a series between from and to (Unix seconds, inclusive),
+// oldest first, at the resolution they are stored at
+func (s *MetricsStore) Query(series string, from, to int64) ([]ssh.MetricsPoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir := s.seriesDir(series)
+	points := []ssh.MetricsPoint{}
+
+	// Only days between the oldest file and today are looked at, however wide the range
+	first := time.Unix(from, 0).UTC().Truncate(24 * time.Hour)
+	oldest, err := oldestMetricsDay(dir)
+	if err != nil {
+		return nil, err
+	}
+	if oldest.After(first) {
+		first = oldest
+	}
+	last := min(to, time.Now().Unix())
+	for day := first; !oldest.IsZero() && day.Unix() <= last; day = day.Add(24 * time.Hour) {
+		name := day.Format(metricsDayLayout)
+		for _, suffix := range []string{metricsCoarseSuffix, metricsFileSuffix} {
+			dayPoints, err := readMetricsPoints(filepath.Join(dir, name+suffix))
+			if err != nil {
+				return nil, err
+			}
+			for _, point := range dayPoints {
+				if point.Timestamp >= from && point.Timestamp <= to {
+					points = append(points, point)
+				}
+			}
+		}
+	}
+
+	for _, point := range ssh.DownsamplePoints(s.pending[series], metricsStep) {
+		if point.Timestamp >= from && point.Timestamp <= to {
+			points = append(points, point)
+		}
+	}
+
+	sort.Slice(points, func(i, j int) bool { return points[i].Timestamp < points[j].Timestamp })
+	return points, nil
+}
+
+// compact deletes days past the retention and downsamples old days
+func (s *MetricsStore) compact() error {
+	seriesDirs, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read metrics directory: %w", err)
+	}
+
+	now := time.Now().UTC()
+	for _, seriesDir := range seriesDirs {
+		if !seriesDir.IsDir() {
+			continue
+		}
+		dir := filepath.Join(s.dir, seriesDir.Name())
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			return fmt.Errorf("failed to read metrics directory: %w", err)
+		}
+
+		for _, file := range files {
+			day, coarse, ok := parseMetricsFileName(file.Name())
+			if !ok {
+				continue
+			}
+			name := day.Format(metricsDayLayout)
+			filePath := filepath.Join(dir, file.Name())
+
+			// A day's age is counted from its end
+			age := now.Sub(day.Add(24 * time.Hour))
+			switch {
+			case s.retention > 0 && age > s.retention:
+				if err := os.Remove(filePath); err != nil {
+					return fmt.Errorf("failed to delete old metrics: %w", err)
+				}
+			case !coarse && age > metricsCoarseAfter:
+				if err := downsampleMetricsFile(filePath, filepath.Join(dir, name+metricsCoarseSuffix)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// parseMetricsFileName returns the day of a day file and whether it is downsampled
+func parseMetricsFileName(fileName string) (time.Time, bool, bool) {
+	name, coarse := strings.CutSuffix(fileName, metricsCoarseSuffix)
+	if !coarse {
+		var ok bool
+		if name, ok = strings.CutSuffix(fileName, metricsFileSuffix); !ok {
+			return time.Time{}, false, false
+		}
+	}
+	day, err := time.Parse(metricsDayLayout, name)
+	if err != nil {
+		return time.Time{}, false, false
+	}
+	return day, coarse, true
+}
+
+// oldestMetricsDay returns the day of a series' oldest file, or the zero time if it has none
+func oldestMetricsDay(dir string) (time.Time, error) {
+	files, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read metrics directory: %w", err)
+	}
+
+	var oldest time.Time
+	for _, file := range files {
+		if day, _, ok := parseMetricsFileName(file.Name()); ok && (oldest.IsZero() || day.Before(oldest)) {
+			oldest = day
+		}
+	}
+	return oldest, nil
+}
+
+// downsampleMetricsFile rewrites a day file at the coarse resolution, merging it into
+// any coarse file already there
+func downsampleMetricsFile(src, dst string) error {
+	points, err := readMetricsPoints(src)
+	if err != nil {
+		return err
+	}
+	existing, err := readMetricsPoints(dst)
+	if err != nil {
+		return err
+	}
+
+	tmp := dst + ".tmp"
+	os.Remove(tmp)
+	if err := appendMetricsPoints(tmp, ssh.DownsamplePoints(append(existing, points...), metricsCoarseStep)); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return fmt.Errorf("failed to replace metrics file: %w", err)
+	}
+	if err := os.Remove(src); err != nil {
+		return fmt.Errorf("failed to delete metrics file: %w", err)
+	}
+	return nil
+}
+
+// seriesDir returns the directory of a series, with characters unsafe in file names replaced
+func (s *MetricsStore) seriesDir(series string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '@':
+			return r
+		}
+		return '_'
+	}, series)
+	return filepath.Join(s.dir, name)
+}
+
+// readMetricsPoints reads a JSON lines file, skipping lines it cannot parse such as a
+// line cut short by a crash. A missing file has no points.
+func readMetricsPoints(filePath string) ([]ssh.MetricsPoint, error) {
+	file, err := os.Open(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metrics file: %w", err)
+	}
+	defer file.Close()
+
+	var points []ssh.MetricsPoint
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var point ssh.MetricsPoint
+		if json.Unmarshal(scanner.Bytes(), &point) == nil {
+			points = append(points, point)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read metrics file: %w", err)
+	}
+	return points, nil
+}
+
+// appendMetricsPoints appends points to a JSON lines file
+func appendMetricsPoints(filePath string, points []ssh.MetricsPoint) error {
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open metrics file: %w", err)
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, point := range points {
+		if err := encoder.Encode(point); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to encode metrics: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	return file.Close()
+}
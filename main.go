@@ -54,6 +54,7 @@ func main() {
 		},
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1},
 		OnStartup:        onStartup,
+		OnShutdown:       app.shutdown,
 		Bind: []interface{}{
 			app,
 		},